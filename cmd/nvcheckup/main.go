@@ -3,10 +3,14 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/nicholasgasior/nvcheckup/internal/bundle"
@@ -16,6 +20,8 @@ import (
 	"github.com/nicholasgasior/nvcheckup/internal/remediate"
 	"github.com/nicholasgasior/nvcheckup/internal/selftest"
 	"github.com/nicholasgasior/nvcheckup/internal/snapshot"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
@@ -32,6 +38,8 @@ func main() {
 		snapshotCmd(os.Args[2:])
 	case "compare":
 		compareCmd(os.Args[2:])
+	case "analyze":
+		analyzeCmd(os.Args[2:])
 	case "doctor":
 		doctorCmd(os.Args[2:])
 	case "self-test":
@@ -67,6 +75,13 @@ func runCmd(args []string) {
 	redactFlag := fs.Bool("redact", true, "Enable PII redaction (default: true)")
 	noRedact := fs.Bool("no-redact", false, "Disable PII redaction (not recommended for sharing)")
 	includeLogs := fs.Bool("include-logs", false, "Include extended logs in the report/bundle")
+	knowledgeDir := fs.String("knowledge", "", "Directory with rules.json etc. to override the embedded knowledge pack")
+	only := fs.String("only", "", "Comma-separated collectors to run (ignores mode gating)")
+	skip := fs.String("skip", "", "Comma-separated collectors to skip")
+	budget := fs.Int("budget", 0, "Overall time budget in seconds for data collection (0 = no limit)")
+	recordDir := fs.String("record", "", "Save every external command's argv, output, exit code and duration to this directory")
+	replayDir := fs.String("replay", "", "Serve external commands from a --record directory instead of running them")
+	sysrootDir := fs.String("sysroot", "", "Read /proc, /sys, /etc and /dev below this directory (e.g. a mounted disk image)")
 
 	fs.Parse(args)
 
@@ -80,6 +95,11 @@ func runCmd(args []string) {
 		os.Exit(types.ExitError)
 	}
 
+	if *recordDir != "" && *replayDir != "" {
+		fmt.Fprintln(os.Stderr, "--record and --replay cannot be used together")
+		os.Exit(types.ExitError)
+	}
+
 	// Handle redaction flags
 	redact := *redactFlag
 	if *noRedact {
@@ -87,21 +107,37 @@ func runCmd(args []string) {
 	}
 
 	cfg := types.RunConfig{
-		Mode:        m,
-		OutDir:      *outDir,
-		Zip:         *doZip,
-		JSON:        *doJSON,
-		Markdown:    *doMD,
-		Verbose:     *verbose,
-		NoAdmin:     *noAdmin,
-		Timeout:     *timeout,
-		Redact:      redact,
-		IncludeLogs: *includeLogs,
+		Mode:          m,
+		OutDir:        *outDir,
+		Zip:           *doZip,
+		JSON:          *doJSON,
+		Markdown:      *doMD,
+		Verbose:       *verbose,
+		NoAdmin:       *noAdmin,
+		Timeout:       *timeout,
+		Redact:        redact,
+		IncludeLogs:   *includeLogs,
+		KnowledgePath: *knowledgeDir,
+		Only:          splitList(*only),
+		Skip:          splitList(*skip),
+		Budget:        *budget,
+		RecordDir:     *recordDir,
+		ReplayDir:     *replayDir,
+		Sysroot:       *sysrootDir,
 	}
 
 	printBanner()
 
-	report, err := core.Run(cfg, *verbose, func(msg string) {
+	// Ctrl-C cancels collection (killing in-flight commands) but still lets us
+	// write a partial report. A second Ctrl-C exits immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	report, err := core.Run(ctx, cfg, *verbose, func(msg string) {
 		fmt.Println(msg)
 	})
 	if err != nil {
@@ -144,7 +180,18 @@ func runCmd(args []string) {
 		fmt.Println()
 	}
 
-	// Determine exit code
+	if report.Metadata.Incomplete {
+		fmt.Printf("The report above is partial: %s.\n", report.Metadata.IncompleteReason)
+		if ctx.Err() != nil {
+			os.Exit(types.ExitInterrupted)
+		}
+	}
+
+	os.Exit(exitCodeFor(report))
+}
+
+// exitCodeFor maps the worst finding severity to the process exit code.
+func exitCodeFor(report *types.Report) int {
 	exitCode := types.ExitOK
 	for _, f := range report.Findings {
 		switch f.Severity {
@@ -156,7 +203,83 @@ func runCmd(args []string) {
 			}
 		}
 	}
-	os.Exit(exitCode)
+	return exitCode
+}
+
+func analyzeCmd(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	mode := fs.String("mode", "", "Diagnostic mode to analyze for (default: the report's original mode)")
+	outDir := fs.String("out", ".", "Output directory for the re-analyzed reports")
+	knowledgeDir := fs.String("knowledge", "", "Directory with rules.json etc. to override the embedded knowledge pack")
+	fs.Parse(args)
+
+	remaining := fs.Args()
+	if len(remaining) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: nvcheckup analyze [--mode MODE] [--out DIR] [--knowledge DIR] <report.json|bundle.zip>")
+		os.Exit(types.ExitError)
+	}
+	input := remaining[0]
+
+	report, err := core.LoadReport(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(types.ExitError)
+	}
+
+	m := report.Metadata.Mode
+	if *mode != "" {
+		m = types.RunMode(strings.ToLower(*mode))
+	}
+	switch m {
+	case types.ModeGaming, types.ModeAI, types.ModeCreator, types.ModeStreaming, types.ModeFull:
+		// ok
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid mode: %s. Use: gaming, ai, creator, streaming, full\n", m)
+		os.Exit(types.ExitError)
+	}
+
+	// Never overwrite the report being analyzed
+	if inAbs, err := filepath.Abs(input); err == nil {
+		if outAbs, err := filepath.Abs(filepath.Join(*outDir, "report.json")); err == nil && inAbs == outAbs {
+			fmt.Fprintf(os.Stderr, "Error: writing to %s would overwrite the input report; choose another --out directory\n", *outDir)
+			os.Exit(types.ExitError)
+		}
+	}
+
+	printBanner()
+	fmt.Printf("Re-analyzing %s (collected %s by v%s, mode %s -> %s)\n",
+		input, report.Metadata.Timestamp.Format("2006-01-02 15:04"), report.Metadata.ToolVersion, report.Metadata.Mode, m)
+
+	if err := core.Reanalyze(report, m, *knowledgeDir, func(msg string) {
+		fmt.Println(msg)
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(types.ExitError)
+	}
+
+	cfg := types.RunConfig{OutDir: *outDir, JSON: true, Markdown: true}
+	files, err := core.WriteReport(report, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(types.ExitError)
+	}
+
+	fmt.Println()
+	for _, f := range files {
+		fmt.Printf("  Written: %s\n", f)
+	}
+	fmt.Println()
+	fmt.Println(report.SummaryBlock)
+
+	if len(report.TopIssues) > 0 {
+		fmt.Println("Top Issues:")
+		for i, issue := range report.TopIssues {
+			fmt.Printf("  %d. %s\n", i+1, issue)
+		}
+		fmt.Println()
+	}
+
+	os.Exit(exitCodeFor(report))
 }
 
 func snapshotCmd(args []string) {
@@ -213,11 +336,17 @@ func fixCmd(args []string) {
 	dryRun := fs.Bool("dry-run", false, "Preview changes without applying")
 	outDir := fs.String("out", ".", "Directory for change journal")
 	all := fs.Bool("all", false, "Preview all available fixes")
+	knowledgeDir := fs.String("knowledge", "", "Directory with remediations.json etc. to override the embedded knowledge pack")
 	fs.Parse(args)
 
 	printBanner()
 
-	engine := remediate.NewEngine(nil, *outDir, *dryRun)
+	pack, err := knowledge.Load(*knowledgeDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(types.ExitError)
+	}
+	engine := remediate.NewEngine(nil, pack, *outDir, *dryRun)
 	actions := engine.ListAvailable()
 
 	if len(actions) == 0 {
@@ -307,7 +436,7 @@ func undoCmd(args []string) {
 
 	printBanner()
 
-	engine := remediate.NewEngine(nil, *outDir, false)
+	engine := remediate.NewEngine(nil, nil, *outDir, false)
 	journal := remediate.NewJournal(*outDir)
 	entries, err := journal.Read()
 	if err != nil {
@@ -384,7 +513,7 @@ func networkTestCmd(args []string) {
 	fmt.Println("Running network diagnostics...")
 	fmt.Println()
 
-	netInfo, netErrs := common.CollectNetworkInfo(*timeout)
+	netInfo, netErrs := common.CollectNetworkInfo(context.Background(), sysroot.Host(), *timeout)
 
 	fmt.Printf("  Interface:    %s (%s)\n", cliValueOrNA(netInfo.InterfaceName), cliValueOrNA(netInfo.InterfaceType))
 	if netInfo.InterfaceType == "wifi" {
@@ -456,6 +585,17 @@ func printBanner() {
 	fmt.Println()
 }
 
+// splitList parses a comma-separated flag value, ignoring empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func printUsage() {
 	fmt.Printf(`NVCheckup v%s — Cross-platform NVIDIA Diagnostic Tool
 %s
@@ -470,6 +610,7 @@ Commands:
   network-test  Run standalone network diagnostics
   snapshot      Create a timestamped JSON snapshot
   compare       Compare two snapshots
+  analyze       Re-analyze a saved report.json or bundle zip with current rules
   doctor        Interactive guided diagnostic mode
   self-test     Verify environment, dependencies, and permissions
   version       Show version information
@@ -486,13 +627,22 @@ Run Flags:
   --redact    Enable PII redaction (default: true)
   --no-redact Disable PII redaction
   --include-logs  Include extended system logs in the bundle
+  --knowledge DIR Override the embedded knowledge pack (rules.json, ...)
+  --only LIST     Run only these collectors, comma-separated (e.g. gpu,thermal)
+  --skip LIST     Skip these collectors, comma-separated (e.g. network)
+  --budget SECS   Overall collection deadline; collectors still running are cut off
+  --record DIR    Save every external command's output to DIR (not redacted)
+  --replay DIR    Serve command output from a --record directory instead of running anything
+  --sysroot DIR   Read /proc, /sys, /etc and /dev below DIR instead of the live system
 
 Examples:
   nvcheckup run --mode gaming --zip
   nvcheckup run --mode ai --json --md
   nvcheckup run --mode full --zip --json --out ./reports
+  nvcheckup run --record ./rec && nvcheckup run --replay ./rec
   nvcheckup snapshot --out ./snapshots
   nvcheckup compare snap1.json snap2.json
+  nvcheckup analyze --mode ai --out ./retriage report.json
   nvcheckup doctor
   nvcheckup self-test
 `, types.Version, types.Disclaimer)
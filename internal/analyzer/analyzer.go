@@ -3,61 +3,59 @@ package analyzer
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/remediate"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
+// pack supplies rule metadata (severity, confidence, modes). It defaults to
+// the embedded knowledge pack and can be replaced with UseKnowledge.
+var pack = knowledge.Default()
+
+// UseKnowledge replaces the knowledge pack used by Analyze.
+func UseKnowledge(p *knowledge.Pack) {
+	if p != nil {
+		pack = p
+	}
+}
+
 // Analyze takes a partially-filled report (with collected data) and produces findings.
+// Every check runs; findings whose rule is not enabled for the mode and
+// platform are then dropped, so mode gating lives in rules.json.
 func Analyze(report *types.Report, mode types.RunMode) {
 	var findings []types.Finding
 
-	// Always run universal checks
 	findings = append(findings, analyzeGPUPresence(report)...)
 	findings = append(findings, analyzeDriverBasics(report)...)
 	findings = append(findings, analyzeThermal(report)...)
 	findings = append(findings, analyzePCIe(report)...)
-
-	// Mode-specific analysis
-	switch mode {
-	case types.ModeGaming:
-		findings = append(findings, analyzeWindowsGaming(report)...)
-		findings = append(findings, analyzeOverlays(report)...)
-		findings = append(findings, analyzeDisplay(report)...)
-		findings = append(findings, analyzeLinuxModules(report)...)
-		findings = append(findings, analyzeLinuxAdvanced(report)...)
-		findings = append(findings, analyzeNetwork(report)...)
-	case types.ModeStreaming:
-		findings = append(findings, analyzeWindowsGaming(report)...)
-		findings = append(findings, analyzeOverlays(report)...)
-		findings = append(findings, analyzeStreaming(report)...)
-		findings = append(findings, analyzeNetwork(report)...)
-	case types.ModeAI:
-		findings = append(findings, analyzeLinuxModules(report)...)
-		findings = append(findings, analyzeSecureBoot(report)...)
-		findings = append(findings, analyzeCUDA(report)...)
-		findings = append(findings, analyzePyTorch(report)...)
-		findings = append(findings, analyzeTensorFlow(report)...)
-		findings = append(findings, analyzeLinuxAdvanced(report)...)
-	case types.ModeCreator:
-		findings = append(findings, analyzeWindowsGaming(report)...)
-		findings = append(findings, analyzeCUDA(report)...)
-		findings = append(findings, analyzeDisplay(report)...)
-	case types.ModeFull:
-		findings = append(findings, analyzeWindowsGaming(report)...)
-		findings = append(findings, analyzeOverlays(report)...)
-		findings = append(findings, analyzeStreaming(report)...)
-		findings = append(findings, analyzeLinuxModules(report)...)
-		findings = append(findings, analyzeSecureBoot(report)...)
-		findings = append(findings, analyzeCUDA(report)...)
-		findings = append(findings, analyzePyTorch(report)...)
-		findings = append(findings, analyzeTensorFlow(report)...)
-		findings = append(findings, analyzeWSL(report)...)
-		findings = append(findings, analyzeVRAM(report)...)
-		findings = append(findings, analyzeDisplay(report)...)
-		findings = append(findings, analyzeNetwork(report)...)
-		findings = append(findings, analyzeLinuxAdvanced(report)...)
-	}
+	findings = append(findings, analyzeReBAR(report)...)
+	findings = append(findings, analyzeMemoryHealth(report)...)
+	findings = append(findings, analyzeMIG(report)...)
+	findings = append(findings, analyzeWindowsGaming(report)...)
+	findings = append(findings, analyzeOverlays(report)...)
+	findings = append(findings, analyzeStreaming(report)...)
+	findings = append(findings, analyzeLinuxModules(report)...)
+	findings = append(findings, analyzeSecureBoot(report)...)
+	findings = append(findings, analyzeCUDA(report)...)
+	findings = append(findings, analyzePyTorch(report)...)
+	findings = append(findings, analyzeTensorFlow(report)...)
+	findings = append(findings, analyzeWSL(report)...)
+	findings = append(findings, analyzeVRAM(report)...)
+	findings = append(findings, analyzeDisplay(report)...)
+	findings = append(findings, analyzeNetwork(report)...)
+	findings = append(findings, analyzeLinuxAdvanced(report)...)
+	findings = append(findings, analyzePrivileges(report)...)
+
+	findings = filterFindings(findings, mode, report.Metadata.Platform)
+	discountSkippedChecks(findings, report.SkippedChecks)
+	attachRemediations(findings, report.Metadata.Platform)
 
 	// Sort by severity: CRIT first, then WARN, then INFO
 	sortFindings(findings)
@@ -81,9 +79,7 @@ func analyzeGPUPresence(report *types.Report) []types.Finding {
 	}
 
 	if nvidiaCount == 0 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "No NVIDIA GPU Detected",
+		findings = append(findings, fromRule("no-nvidia-gpu", types.Finding{
 			Evidence:     fmt.Sprintf("Found %d GPU(s) but none identified as NVIDIA.", len(report.GPUs)),
 			WhyItMatters: "NVCheckup is designed for NVIDIA GPU diagnostics. Without an NVIDIA GPU detected, most checks cannot provide useful results.",
 			NextSteps: []string{
@@ -91,37 +87,39 @@ func analyzeGPUPresence(report *types.Report) []types.Finding {
 				"Check Device Manager (Windows) or lspci (Linux) for the GPU.",
 				"Ensure the NVIDIA driver is installed.",
 			},
-			Category:   "gpu",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	// Check for hybrid GPU setup
 	if len(report.GPUs) > 1 {
 		hasNvidia := false
 		hasIGPU := false
+		var laptop string
 		for _, gpu := range report.GPUs {
 			if gpu.IsNVIDIA {
 				hasNvidia = true
+				if gpu.Class == "laptop" {
+					laptop = gpuModel(gpu)
+				}
 			}
 			if gpu.Vendor == "Intel" || gpu.Vendor == "AMD" {
 				hasIGPU = true
 			}
 		}
 		if hasNvidia && hasIGPU {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityInfo,
-				Title:        "Hybrid GPU Configuration Detected",
-				Evidence:     fmt.Sprintf("Found %d GPUs including NVIDIA + integrated graphics.", len(report.GPUs)),
+			evidence := fmt.Sprintf("Found %d GPUs including NVIDIA + integrated graphics.", len(report.GPUs))
+			if laptop != "" {
+				evidence += fmt.Sprintf(" The %s is a laptop GPU, so the built-in display is most likely driven by the integrated GPU (Optimus).", laptop)
+			}
+			findings = append(findings, fromRule("hybrid-gpu", types.Finding{
+				Evidence:     evidence,
 				WhyItMatters: "Hybrid GPU setups (laptops, some desktops) can sometimes route display output through the iGPU, causing confusion about which GPU is active.",
 				NextSteps: []string{
 					"If experiencing performance issues, verify your application is using the NVIDIA GPU.",
 					"On Windows: Check NVIDIA Control Panel > Manage 3D Settings > Preferred Graphics Processor.",
 					"On Linux: Check PRIME offloading status or use __NV_PRIME_RENDER_OFFLOAD=1.",
 				},
-				Category:   "gpu",
-				Confidence: 90,
-			})
+			}))
 		}
 	}
 
@@ -134,9 +132,7 @@ func analyzeDriverBasics(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
 	if report.Driver.Version == "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "NVIDIA Driver Version Not Detected",
+		findings = append(findings, fromRule("driver-not-detected", types.Finding{
 			Evidence:     "nvidia-smi did not return a driver version.",
 			WhyItMatters: "Without a working NVIDIA driver, GPU acceleration (gaming, CUDA, hardware encoding) will not function.",
 			NextSteps: []string{
@@ -144,15 +140,23 @@ func analyzeDriverBasics(report *types.Report) []types.Finding {
 				"On Linux: Check if the nvidia kernel module is loaded with 'lsmod | grep nvidia'.",
 				"After install, reboot and run NVCheckup again.",
 			},
-			Category:   "driver",
-			Confidence: 95,
-		})
+		}))
+	}
+
+	if report.Driver.NvidiaSmiPath != "" && driverFromProcfs(report) {
+		findings = append(findings, fromRule("nvidia-smi-driver-unreachable", types.Finding{
+			Evidence:     fmt.Sprintf("nvidia-smi did not return a driver version, but /proc/driver/nvidia shows kernel module %s loaded.", report.Driver.KernelModuleVersion),
+			WhyItMatters: "The driver is loaded but its userspace side cannot talk to it, so CUDA, NVENC and monitoring fail the same way nvidia-smi does. This usually follows a driver update without a reboot, where the new libraries no longer match the running module.",
+			NextSteps: []string{
+				"Reboot so the kernel module matches the installed driver libraries.",
+				"Run 'nvidia-smi' and check the message: 'Driver/library version mismatch' confirms an update without reboot.",
+				"Check 'dmesg | grep -i nvrm' for errors from the module.",
+			},
+		}))
 	}
 
 	if report.Driver.NvidiaSmiPath == "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "nvidia-smi Not Found in PATH",
+		findings = append(findings, fromRule("nvidia-smi-missing", types.Finding{
 			Evidence:     "The nvidia-smi utility was not found.",
 			WhyItMatters: "nvidia-smi is the primary tool for querying NVIDIA GPU status. Its absence suggests the driver may not be installed or PATH is misconfigured.",
 			NextSteps: []string{
@@ -160,35 +164,161 @@ func analyzeDriverBasics(report *types.Report) []types.Finding {
 				"On Windows: nvidia-smi is typically at C:\\Windows\\System32\\nvidia-smi.exe.",
 				"On Linux: Ensure the nvidia-utils package is installed.",
 			},
-			Category:   "driver",
-			Confidence: 90,
-		})
+		}))
 	}
 
+	findings = append(findings, analyzeLegacyBranch(report)...)
+
+	return findings
+}
+
+// analyzeLegacyBranch flags GPUs whose last supported driver branch is older
+// than the installed driver. Such a driver does not bind to the card at all,
+// which users see as a missing kernel module or "No devices were found".
+func analyzeLegacyBranch(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	version, source := installedDriverVersion(report)
+	branch, err := strconv.Atoi(majorVersion(version))
+	if err != nil {
+		return findings
+	}
+	for _, gpu := range report.GPUs {
+		last, err := strconv.Atoi(gpu.LastDriverBranch)
+		if !gpu.IsNVIDIA || err != nil || branch <= last {
+			continue
+		}
+		evidence := fmt.Sprintf("%s (%s) is supported up to the R%d driver branch (%d.xx); the installed driver is %s (%s).",
+			gpuModel(gpu), gpu.Architecture, last, last, version, source)
+		if report.Linux != nil && !report.Linux.LoadedModules["nvidia"] {
+			evidence += " This is why the nvidia kernel module is not loaded."
+		} else if report.Driver.Version == "" || driverFromProcfs(report) {
+			evidence += " This is why nvidia-smi finds no devices."
+		}
+		findings = append(findings, forGPU([]types.Finding{fromRule("gpu-legacy-driver-branch", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: fmt.Sprintf("NVIDIA drops older architectures from new driver branches. Drivers after R%d do not include support for this GPU, so the installed driver cannot drive it: no display acceleration, CUDA or nvidia-smi until a %d.xx legacy driver is installed.", last, last),
+			NextSteps: []string{
+				fmt.Sprintf("Remove the current NVIDIA driver and install the %d.xx legacy branch.", last),
+				fmt.Sprintf("Ubuntu/Debian: sudo apt install nvidia-driver-%d. Arch: install nvidia-%dxx-dkms from the AUR. Fedora (RPM Fusion): sudo dnf install akmod-nvidia-%dxx.", last, last, last),
+				fmt.Sprintf("Windows: download the R%d driver for this GPU from https://www.nvidia.com/drivers.", last),
+				"Hold the driver packages at that branch so updates do not reinstall the current driver.",
+			},
+		})}, gpu.Index, gpu.PCIBusID, len(report.GPUs) > 1)...)
+	}
 	return findings
 }
 
+// driverPackageVersion finds a driver version such as "470.256.02" in a
+// package list line, e.g. "nvidia-driver-550 550.54.14-0ubuntu1" or
+// "akmod-nvidia-550.54.14-1.fc39.x86_64".
+var driverPackageVersion = regexp.MustCompile(`(?:^|[\s-])(\d{3}\.\d+(?:\.\d+)?)`)
+
+// installedDriverVersion returns the NVIDIA driver version and where it came
+// from: nvidia-smi or the loaded kernel module, the Windows display driver, or
+// the Linux package list when the driver is installed but does not work.
+func installedDriverVersion(report *types.Report) (version, source string) {
+	if driverFromProcfs(report) {
+		return report.Driver.Version, "loaded kernel module"
+	}
+	if report.Driver.Version != "" {
+		return report.Driver.Version, "nvidia-smi"
+	}
+	for _, gpu := range report.GPUs {
+		if v := windowsDriverVersion(gpu.DriverVersion); gpu.IsNVIDIA && v != "" {
+			return v, "Windows display driver " + gpu.DriverVersion
+		}
+	}
+	if report.Linux != nil {
+		for _, pkg := range report.Linux.NVIDIAPackages {
+			if m := driverPackageVersion.FindStringSubmatch(pkg); m != nil {
+				return m[1], "package " + pkg
+			}
+		}
+	}
+	return "", ""
+}
+
+// driverFromProcfs reports whether the driver version came from
+// /proc/driver/nvidia because nvidia-smi did not return one.
+func driverFromProcfs(report *types.Report) bool {
+	return report.Driver.Sources["version"] == "procfs"
+}
+
+// windowsDriverVersion converts a Windows driver version such as
+// "31.0.15.5222" to NVIDIA's numbering ("552.22"): the last five digits of the
+// last two fields.
+func windowsDriverVersion(v string) string {
+	parts := strings.Split(v, ".")
+	if len(parts) != 4 {
+		return ""
+	}
+	digits := parts[2] + parts[3]
+	if len(digits) < 5 {
+		return ""
+	}
+	digits = digits[len(digits)-5:]
+	if _, err := strconv.Atoi(digits); err != nil {
+		return ""
+	}
+	return digits[:3] + "." + digits[3:]
+}
+
 // ── Thermal Analysis ──────────────────────────────────────────────────
 
 func analyzeThermal(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
-	if report.Thermal == nil {
-		return findings
+	thermals := gpuThermals(report)
+	for _, t := range thermals {
+		findings = append(findings, forGPU(analyzeGPUThermal(t), t.GPUIndex, t.PCIBusID, len(thermals) > 1)...)
+	}
+	return findings
+}
+
+func analyzeGPUThermal(t *types.ThermalInfo) []types.Finding {
+	var findings []types.Finding
+
+	// One finding per decoded clock event reason
+	causes := slowdownCauses(t)
+	thermalCause := containsString(causes, "hw_thermal_slowdown") || containsString(causes, "sw_thermal_slowdown")
+	for _, c := range throttleCauses {
+		if containsString(causes, c.reason) {
+			f := types.Finding{
+				Evidence:     throttleEvidence(c.label, t),
+				WhyItMatters: c.why,
+				NextSteps:    c.steps,
+			}
+			if c.reason == "sw_power_cap" {
+				// A busy GPU at its stock limit is working as designed; the
+				// cap costs performance when the limit was lowered or it
+				// holds back a GPU that is not under load
+				extra, lowered := powerLimitEvidence(t)
+				f.Evidence += extra
+				if lowered || gpuIdle(t) {
+					f.Severity = types.SeverityWarn
+				}
+			}
+			findings = append(findings, fromRule(c.rule, f))
+		}
 	}
 
-	t := report.Thermal
+	headroom, limit, haveLimits := thermalHeadroom(t)
+	overLimit := haveLimits && headroom <= 0
+	hot := t.TemperatureC >= 75 && t.TemperatureC < 85
+	if haveLimits {
+		hot = headroom > 0 && headroom <= hotHeadroomC
+	}
 
-	// Critical: active thermal throttling
-	if t.ThermalThrottle || t.SlowdownActive {
+	// Critical: thermal throttling that the reasons above do not explain,
+	// i.e. a GPU at its temperature limit or a report without decodable reasons
+	if ((t.ThermalThrottle || overLimit) && !thermalCause) || (t.SlowdownActive && len(causes) == 0) {
 		reason := "GPU temperature is critically high"
 		if t.SlowdownReason != "" {
 			reason = t.SlowdownReason
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "GPU Thermal Throttling Active",
-			Evidence:     fmt.Sprintf("Temperature: %d°C. Throttle active: %v. Reason: %s.", t.TemperatureC, t.SlowdownActive, reason),
+		findings = append(findings, fromRule("thermal-throttling", types.Finding{
+			Evidence:     fmt.Sprintf("Temperature: %d°C. Throttle active: %v. Reason: %s.", t.TemperatureC, t.SlowdownActive, reason) + limitsEvidence(t, headroom, limit, haveLimits),
 			WhyItMatters: "The GPU is actively reducing performance to prevent heat damage. This causes frame drops, stutter, and reduced compute throughput.",
 			NextSteps: []string{
 				"Check that case airflow is adequate and intake fans are working.",
@@ -197,51 +327,44 @@ func analyzeThermal(report *types.Report) []types.Finding {
 				"If overclocked, reduce clocks to stock settings.",
 				"Consider adding case fans or improving ventilation.",
 			},
-			Category:   "performance",
-			Confidence: 95,
-		})
-	} else if t.TemperatureC >= 75 && t.TemperatureC < 85 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "GPU Running Hot",
-			Evidence:     fmt.Sprintf("GPU temperature: %d°C (elevated but not throttling yet).", t.TemperatureC),
-			WhyItMatters: "While not critically high, sustained temperatures above 75°C reduce GPU lifespan and may lead to throttling under sustained load.",
+		}))
+	} else if !t.ThermalThrottle && !thermalCause && hot {
+		findings = append(findings, fromRule("gpu-running-hot", types.Finding{
+			Evidence:     fmt.Sprintf("GPU temperature: %d°C (elevated but not throttling yet).", t.TemperatureC) + limitsEvidence(t, headroom, limit, haveLimits),
+			WhyItMatters: "The GPU is close to the temperature at which it starts lowering clocks. Sustained load will push it into throttling, and running this hot reduces GPU lifespan.",
 			NextSteps: []string{
 				"Monitor temperatures during extended gaming/compute sessions.",
 				"Ensure GPU fans are spinning and case airflow is adequate.",
 				"Consider adjusting fan curves to be more aggressive.",
 			},
-			Category:   "performance",
-			Confidence: 80,
-		})
-	}
-
-	// Fan not spinning at elevated temp
-	if t.FanSpeedPct == 0 && t.TemperatureC > 60 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "GPU Fan Not Spinning at Elevated Temperature",
-			Evidence:     fmt.Sprintf("Fan speed: 0%% while temperature is %d°C.", t.TemperatureC),
-			WhyItMatters: "The GPU fan should be spinning at temperatures above 60°C. This may indicate a fan failure or aggressive zero-RPM fan curve.",
+		}))
+	}
+
+	// Fan not spinning at elevated temp; zero-RPM modes stop the fan well
+	// below the GPU's limit
+	fanSuspect := t.TemperatureC > 60
+	if haveLimits {
+		fanSuspect = headroom <= fanStopHeadroomC
+	}
+	if t.FanSpeedPct == 0 && t.TemperatureC > 0 && fanSuspect {
+		findings = append(findings, fromRule("fan-not-spinning", types.Finding{
+			Evidence:     fmt.Sprintf("Fan speed: 0%% while temperature is %d°C.", t.TemperatureC) + limitsEvidence(t, headroom, limit, haveLimits),
+			WhyItMatters: "The GPU fan should be spinning this close to the GPU's temperature limit. This may indicate a fan failure or aggressive zero-RPM fan curve.",
 			NextSteps: []string{
 				"Check if the GPU uses a zero-RPM fan mode (some cards stop fans below 60°C).",
 				"If temperature continues to rise without fan activity, the fan may be faulty.",
 				"Use MSI Afterburner or similar to set a manual fan curve.",
 			},
-			Category:   "hardware",
-			Confidence: 70,
-		})
+		}))
 	}
 
-	// Power state analysis
-	if t.PowerState != "" && t.PowerState != "P0" && t.PowerState != "P1" && t.PowerState != "P2" {
+	// Power state analysis; an idle GPU is expected to clock down
+	if t.PowerState != "" && t.PowerState != "P0" && t.PowerState != "P1" && t.PowerState != "P2" && !containsString(causes, "gpu_idle") {
 		// Only flag if GPU should be under load (we check if clock is well below max)
 		if t.MaxClockMHz > 0 && t.CurrentClockMHz > 0 {
 			ratio := float64(t.CurrentClockMHz) / float64(t.MaxClockMHz)
 			if ratio < 0.5 {
-				findings = append(findings, types.Finding{
-					Severity:     types.SeverityWarn,
-					Title:        "GPU Power State Not Reaching Maximum Performance",
+				findings = append(findings, fromRule("gpu-power-state-stuck", types.Finding{
 					Evidence:     fmt.Sprintf("Power state: %s. Clock: %d MHz / %d MHz max (%.0f%%).", t.PowerState, t.CurrentClockMHz, t.MaxClockMHz, ratio*100),
 					WhyItMatters: "The GPU is not running at full performance. This may be normal at idle, but if under load it indicates a power management issue.",
 					NextSteps: []string{
@@ -250,9 +373,7 @@ func analyzeThermal(report *types.Report) []types.Finding {
 						"Set NVIDIA Control Panel > Power Management Mode to 'Prefer Maximum Performance'.",
 						"Check for PCIe power cable connections to the GPU.",
 					},
-					Category:   "performance",
-					Confidence: 60,
-				})
+				}))
 			}
 		}
 	}
@@ -260,44 +381,284 @@ func analyzeThermal(report *types.Report) []types.Finding {
 	return findings
 }
 
+// throttleCauses gives each clock event reason its own rule and advice, in
+// the order the findings are reported.
+var throttleCauses = []struct {
+	reason string // decoded name, see nvsmi.ReasonNames
+	rule   string
+	label  string
+	why    string
+	steps  []string
+}{
+	{
+		reason: "hw_power_brake_slowdown",
+		rule:   "throttle-hw-power-brake",
+		label:  "HW power brake slowdown",
+		why:    "An external power brake signal has forced the GPU to its lowest clocks. It is asserted by the board when the supply cannot deliver enough power, which usually means a PSU, cable or connector problem rather than anything in software.",
+		steps: []string{
+			"Check that every PCIe power connector is fully seated; on 12VHPWR/12V-2x6 cards, reseat the connector until it clicks with no gap.",
+			"Use separate PSU cables for each 8-pin input instead of one daisy-chained cable.",
+			"Verify the PSU meets the GPU's recommended wattage, with headroom for transient spikes.",
+			"If it persists, test with a different PSU.",
+		},
+	},
+	{
+		reason: "hw_thermal_slowdown",
+		rule:   "throttle-hw-thermal",
+		label:  "HW thermal slowdown",
+		why:    "The GPU or its memory reached the hardware temperature limit and clocks were cut sharply to protect the chip. This causes large, sudden drops in performance.",
+		steps: []string{
+			"Check that the GPU fans spin up under load and that case airflow is adequate.",
+			"Clean dust from the heatsink, fans and case filters.",
+			"Check thermal paste and memory thermal pads on older cards.",
+			"Remove any overclock and re-test.",
+		},
+	},
+	{
+		reason: "hw_slowdown",
+		rule:   "throttle-hw-slowdown",
+		label:  "HW slowdown",
+		why:    "The hardware slowdown signal is asserted, cutting clocks by half or more. It is raised for over-temperature or by the external power brake, so it points at cooling or power delivery.",
+		steps: []string{
+			"Check the GPU temperature under load and the cooling as for thermal throttling.",
+			"Check PSU capacity and that all PCIe power connectors are fully seated.",
+			"Run `nvidia-smi -q -d PERFORMANCE` under load to see which other reasons are active with it.",
+		},
+	},
+	{
+		reason: "sw_thermal_slowdown",
+		rule:   "throttle-sw-thermal",
+		label:  "SW thermal slowdown",
+		why:    "The driver is lowering clocks to keep the GPU below its target temperature. Performance is reduced gradually rather than cut, but sustained load will run slower than it should.",
+		steps: []string{
+			"Improve case airflow or set a more aggressive fan curve.",
+			"Check whether the GPU target temperature was lowered by a tuning tool.",
+			"Clean dust from the heatsink and fans.",
+		},
+	},
+	{
+		reason: "sw_power_cap",
+		rule:   "throttle-sw-power-cap",
+		label:  "SW power cap",
+		why:    "The GPU is drawing as much power as its power limit allows, so the driver is holding clocks down. Under heavy load this is normal; with a lowered power limit it costs performance.",
+		steps: []string{
+			"Compare the current and default power limits with `nvidia-smi -q -d POWER`.",
+			"If the limit was lowered (by `nvidia-smi -pl`, MSI Afterburner or a data-center policy), restore the default.",
+			"On laptops, check that the charger is connected and the vendor power mode is set to performance.",
+		},
+	},
+	{
+		reason: "applications_clocks_setting",
+		rule:   "throttle-app-clocks",
+		label:  "Applications clocks setting",
+		why:    "Clocks are capped by an applications clocks or locked clocks setting rather than by load, temperature or power.",
+		steps: []string{
+			"Show the configured clocks with `nvidia-smi -q -d CLOCK`.",
+			"Reset applications clocks with `sudo nvidia-smi -rac` and locked clocks with `sudo nvidia-smi -rgc`.",
+		},
+	},
+	{
+		reason: "sync_boost",
+		rule:   "throttle-sync-boost",
+		label:  "Sync boost",
+		why:    "This GPU is in a sync boost group and is clocked down to match the slowest GPU in the group, so the cause is on another card.",
+		steps: []string{
+			"Check the other GPUs in the group for thermal or power throttling.",
+			"If matched clocks are not needed, remove the GPU from the sync boost group.",
+		},
+	},
+	{
+		reason: "gpu_idle",
+		rule:   "throttle-gpu-idle",
+		label:  "GPU idle",
+		why:    "Nothing was running on the GPU when the report was taken, so it was at idle clocks. This is normal; clock readings in this report do not reflect performance under load.",
+		steps: []string{
+			"If you are chasing low performance, re-run NVCheckup while the game or workload is running.",
+		},
+	},
+}
+
+// powerLimitEvidence compares the enforced power limit with the GPU's
+// default and reports whether it was lowered. Both are empty for GPUs and
+// saved reports without a default limit.
+func powerLimitEvidence(t *types.ThermalInfo) (evidence string, lowered bool) {
+	limit, _ := strconv.ParseFloat(t.PowerLimitW, 64)
+	def, _ := strconv.ParseFloat(t.DefaultPowerLimitW, 64)
+	switch {
+	case limit <= 0 || def <= 0:
+		return "", false
+	case limit < def-1: // allow for rounding in the reported values
+		return fmt.Sprintf(" The power limit was lowered from its default of %s W.", t.DefaultPowerLimitW), true
+	}
+	return fmt.Sprintf(" The power limit is the default (%s W).", t.DefaultPowerLimitW), false
+}
+
+// slowdownCauses returns the decoded clock event reasons for t. Reports from
+// before they were decoded only carry the raw bitmask.
+func slowdownCauses(t *types.ThermalInfo) []string {
+	if len(t.SlowdownReasons) > 0 {
+		return t.SlowdownReasons
+	}
+	mask, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(t.SlowdownReason), "0x"), 16, 64)
+	if err != nil {
+		return nil
+	}
+	return nvsmi.ReasonNames(mask)
+}
+
+// Headroom thresholds for GPUs that report their own temperature limits.
+const (
+	hotHeadroomC     = 10 // "running hot" within this many °C of the limit
+	fanStopHeadroomC = 25 // a stopped fan is suspicious this close to the limit
+)
+
+// thermalHeadroom returns how many °C t is below the temperature at which it
+// starts lowering clocks, and names that limit. ok is false when the GPU
+// reported no limits (older drivers, saved reports).
+func thermalHeadroom(t *types.ThermalInfo) (headroom int, limit string, ok bool) {
+	switch {
+	case t.TLimitMarginC != nil:
+		return *t.TLimitMarginC, "its T.Limit", true
+	case t.TemperatureC == 0:
+		return 0, "", false
+	case t.MaxOperatingTempC > 0:
+		return t.MaxOperatingTempC - t.TemperatureC, fmt.Sprintf("its max operating temperature (%d°C)", t.MaxOperatingTempC), true
+	case t.SlowdownTempC > 0:
+		return t.SlowdownTempC - t.TemperatureC, fmt.Sprintf("its slowdown temperature (%d°C)", t.SlowdownTempC), true
+	}
+	return 0, "", false
+}
+
+// limitsEvidence describes the headroom and the GPU's limits, or nothing
+// when the GPU did not report them.
+func limitsEvidence(t *types.ThermalInfo, headroom int, limit string, ok bool) string {
+	if !ok {
+		return ""
+	}
+	var evidence string
+	switch {
+	case headroom > 0:
+		evidence = fmt.Sprintf(" Headroom: %d°C below %s.", headroom, limit)
+	case headroom == 0:
+		evidence = fmt.Sprintf(" Headroom: none, at %s.", limit)
+	default:
+		evidence = fmt.Sprintf(" Headroom: none, %d°C past %s.", -headroom, limit)
+	}
+	var limits []string
+	if t.SlowdownTempC > 0 {
+		limits = append(limits, fmt.Sprintf("slowdown %d°C", t.SlowdownTempC))
+	}
+	if t.ShutdownTempC > 0 {
+		limits = append(limits, fmt.Sprintf("shutdown %d°C", t.ShutdownTempC))
+	}
+	if len(limits) > 0 {
+		evidence += " Limits: " + strings.Join(limits, ", ") + "."
+	}
+	return evidence
+}
+
+// throttleEvidence describes one active clock event reason with the readings
+// that bear on it.
+func throttleEvidence(label string, t *types.ThermalInfo) string {
+	evidence := fmt.Sprintf("Active clock event reason: %s.", label)
+	if t.TemperatureC > 0 {
+		evidence += fmt.Sprintf(" Temperature: %d°C.", t.TemperatureC)
+	}
+	if t.MaxClockMHz > 0 {
+		evidence += fmt.Sprintf(" Clock: %d MHz / %d MHz max.", t.CurrentClockMHz, t.MaxClockMHz)
+	}
+	if t.PowerDrawW != "" && t.PowerLimitW != "" {
+		evidence += fmt.Sprintf(" Power: %s W / %s W limit.", t.PowerDrawW, t.PowerLimitW)
+	} else if t.PowerDrawW != "" {
+		evidence += fmt.Sprintf(" Power: %s W.", t.PowerDrawW)
+	}
+	if t.SlowdownReason != "" {
+		evidence += fmt.Sprintf(" (bitmask %s)", t.SlowdownReason)
+	}
+	return evidence
+}
+
 // ── PCIe Analysis ─────────────────────────────────────────────────────
 
 func analyzePCIe(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
-	if report.PCIe == nil {
-		return findings
+	links := gpuPCIeLinks(report)
+	for _, p := range links {
+		findings = append(findings, forGPU(analyzeGPUPCIe(p, linkThermal(report, p)), p.GPUIndex, p.PCIBusID, len(links) > 1)...)
 	}
+	return findings
+}
+
+// analyzeGPUPCIe checks one GPU's link. t is the same GPU's thermal reading,
+// nil when there is none; it tells an idle GPU, whose link speed drops to
+// save power, from one that trained low.
+func analyzeGPUPCIe(p *types.PCIeInfo, t *types.ThermalInfo) []types.Finding {
+	var findings []types.Finding
 
-	p := report.PCIe
+	idle := gpuIdle(t)
+	loaded := t != nil && !idle
+	narrowed := pcieRank(p.CurrentWidth) > 0 && pcieRank(p.CurrentWidth) < pcieRank(p.MaxWidth)
 
-	if p.Downshifted {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "PCIe Link Downshifted",
-			Evidence:     fmt.Sprintf("Current: %s %s. Maximum: %s %s.", p.CurrentSpeed, p.CurrentWidth, p.MaxSpeed, p.MaxWidth),
-			WhyItMatters: "The GPU PCIe link is running below its maximum capability. This can reduce GPU bandwidth and cause performance degradation in GPU-bound workloads.",
+	if p.Downshifted && slotLimited(p) {
+		u := p.Upstream
+		findings = append(findings, fromRule("pcie-slot-limited", types.Finding{
+			Evidence: fmt.Sprintf("The GPU supports %s %s; the upstream port %s supports %s %s, and the link runs at %s %s.",
+				p.MaxSpeed, p.MaxWidth, u.BDF, u.MaxSpeed, u.MaxWidth, p.CurrentSpeed, p.CurrentWidth),
+			WhyItMatters: "The link runs at the most the slot, riser or PCIe switch side supports. This is a platform limit rather than a fault; it costs bandwidth mainly in workloads that move a lot of data between host and GPU.",
 			NextSteps: []string{
-				"Reseat the GPU in the PCIe slot.",
-				"Check for bent or dirty PCIe slot pins.",
-				"Try a different PCIe slot if available.",
-				"Update motherboard BIOS/UEFI.",
-				"Note: PCIe link may power-save at idle — recheck under GPU load.",
+				"If host-to-GPU bandwidth matters, move the GPU to a CPU-attached x16 slot of its own PCIe generation.",
+				"With a riser cable, check its rated generation: a Gen3 riser holds a Gen4 card at Gen3.",
+				"Check the motherboard manual: some slots share lanes with M.2 drives and drop to x8 or x4 when those are populated.",
 			},
-			Category:   "performance",
-			Confidence: 90,
-		})
+		}))
+	} else if p.Downshifted && idle && !narrowed {
+		// Only the speed is down, on an idle GPU: that is link power saving
+		findings = append(findings, fromRule("pcie-downshift", types.Finding{
+			Evidence:     fmt.Sprintf("Current: %s %s. Maximum: %s %s. %s", p.CurrentSpeed, p.CurrentWidth, p.MaxSpeed, p.MaxWidth, idleEvidence(t)),
+			Severity:     types.SeverityInfo,
+			Confidence:   ruleConfidence("pcie-downshift") - 50,
+			WhyItMatters: "NVIDIA GPUs lower the PCIe link speed when idle and raise it again under load, so this reading is expected. Only a link that stays below its maximum speed while the GPU is busy points at a slot or link training problem.",
+			NextSteps: []string{
+				"Re-run NVCheckup while the game or workload is running to read the link under load.",
+				"Or watch the link generation under load with `nvidia-smi --query-gpu=pcie.link.gen.current,pcie.link.gen.max --format=csv -l 1`.",
+			},
+		}))
+	} else if p.Downshifted {
+		evidence := fmt.Sprintf("Current: %s %s. Maximum: %s %s.", p.CurrentSpeed, p.CurrentWidth, p.MaxSpeed, p.MaxWidth)
+		if u := p.Upstream; u != nil && u.MaxSpeed != "" && u.MaxWidth != "" && (narrowed || loaded) {
+			evidence += fmt.Sprintf(" The upstream port %s supports %s %s, so the slot is not the limit; the link trained below what both ends support.",
+				u.BDF, u.MaxSpeed, u.MaxWidth)
+		}
+		steps := []string{
+			"Reseat the GPU in the PCIe slot.",
+			"Check for bent or dirty PCIe slot pins.",
+			"Try a different PCIe slot if available.",
+			"Update motherboard BIOS/UEFI.",
+		}
+		if !loaded && !narrowed {
+			steps = append(steps, "Note: PCIe link may power-save at idle — recheck under GPU load.")
+		}
+		findings = append(findings, fromRule("pcie-downshift", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: "The GPU PCIe link is running below its maximum capability. This can reduce GPU bandwidth and cause performance degradation in GPU-bound workloads.",
+			NextSteps:    steps,
+		}))
+	}
+
+	if f, ok := aerFinding(p); ok {
+		findings = append(findings, f)
 	}
 
-	// Check for legacy PCIe speed
-	if p.CurrentSpeed == "Gen1" || p.CurrentSpeed == "Gen2" {
-		confidence := 85
+	// Check for legacy PCIe speed; an idle GPU's power-saving Gen1 is
+	// already covered by the downshift finding above
+	idleDownshift := idle && !narrowed && pcieRank(p.CurrentSpeed) < pcieRank(p.MaxSpeed)
+	if (p.CurrentSpeed == "Gen1" || p.CurrentSpeed == "Gen2") && !idleDownshift {
+		confidence := ruleConfidence("pcie-legacy-speed")
 		if p.MaxSpeed == p.CurrentSpeed {
-			confidence = 50 // Might just be an old slot/GPU
+			confidence -= 35 // Might just be an old slot/GPU
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "PCIe Running at Legacy Speed",
+		findings = append(findings, fromRule("pcie-legacy-speed", types.Finding{
 			Evidence:     fmt.Sprintf("Link speed: %s %s.", p.CurrentSpeed, p.CurrentWidth),
 			WhyItMatters: "Gen1/Gen2 PCIe speeds significantly limit bandwidth for modern GPUs. This may be normal for older hardware or indicate a configuration issue.",
 			NextSteps: []string{
@@ -305,14 +666,378 @@ func analyzePCIe(report *types.Report) []types.Finding {
 				"Check BIOS PCIe settings (some BIOSes default to Gen2 for compatibility).",
 				"Ensure no riser cables or adapters are limiting link speed.",
 			},
-			Category:   "performance",
 			Confidence: confidence,
-		})
+		}))
+	}
+
+	return findings
+}
+
+// gpuIdle reports whether t shows an idle GPU: the gpu_idle clock event
+// reason, or a power state of P8 or lower.
+func gpuIdle(t *types.ThermalInfo) bool {
+	if t == nil {
+		return false
+	}
+	if containsString(slowdownCauses(t), "gpu_idle") {
+		return true
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(t.PowerState, "P"))
+	return err == nil && n >= 8
+}
+
+// idleEvidence describes why the GPU counts as idle.
+func idleEvidence(t *types.ThermalInfo) string {
+	if t.PowerState != "" {
+		return fmt.Sprintf("The GPU was idle (power state %s) when the report was taken.", t.PowerState)
+	}
+	return "The GPU was idle when the report was taken."
+}
+
+// pcieAERCorrectableNoisy is the correctable AER count since boot above which
+// a link is reported as marginal.
+const pcieAERCorrectableNoisy = 100
+
+// slotLimited reports whether every way the link is below the GPU's maximum is
+// explained by the upstream port supporting no more than the current value.
+func slotLimited(p *types.PCIeInfo) bool {
+	u := p.Upstream
+	if u == nil {
+		return false
+	}
+	limited := func(current, max, upMax string) bool {
+		c, m := pcieRank(current), pcieRank(max)
+		return c == 0 || c >= m || pcieRank(upMax) == c
+	}
+	return limited(p.CurrentSpeed, p.MaxSpeed, u.MaxSpeed) && limited(p.CurrentWidth, p.MaxWidth, u.MaxWidth)
+}
+
+// pcieRank is the number in "Gen4" or "x16", or 0 when unknown.
+func pcieRank(s string) int {
+	n, _ := strconv.Atoi(strings.TrimLeft(s, "Genx"))
+	return n
+}
+
+// aerFinding reports AER errors logged by the GPU or its upstream port:
+// uncorrectable ones always, correctable ones once they pile up.
+func aerFinding(p *types.PCIeInfo) (types.Finding, bool) {
+	var counts []string
+	var cor, unc, fatal int64
+	add := func(label string, a *types.PCIeAER) {
+		if a == nil {
+			return
+		}
+		counts = append(counts, fmt.Sprintf("%s: %d correctable, %d non-fatal, %d fatal", label, a.Correctable, a.NonFatal, a.Fatal))
+		cor += a.Correctable
+		unc += a.NonFatal + a.Fatal
+		fatal += a.Fatal
+	}
+	add("GPU", p.AER)
+	if p.Upstream != nil {
+		add("upstream port "+p.Upstream.BDF, p.Upstream.AER)
+	}
+	evidence := "AER counts since boot — " + strings.Join(counts, "; ") + "."
+
+	switch {
+	case unc > 0:
+		return fromRule("pcie-aer-uncorrectable", types.Finding{
+			Evidence:     evidence,
+			Severity:     escalate("pcie-aer-uncorrectable", float64(fatal)),
+			WhyItMatters: "Uncorrectable PCIe errors mean transactions between the host and the GPU were lost. Non-fatal errors can crash the application or driver; fatal ones take the link down and usually end in Xid 79 (GPU has fallen off the bus).",
+			NextSteps: []string{
+				"Reseat the GPU and any riser cable, and check the PCIe power connectors.",
+				"Set the slot to a fixed, lower PCIe generation in the BIOS to test whether errors stop.",
+				"Check 'sudo dmesg | grep -i aer' for the error types and which device reported them.",
+			},
+		}), true
+	case cor >= pcieAERCorrectableNoisy:
+		return fromRule("pcie-aer-correctable", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: "Correctable errors are fixed by retransmission, so nothing fails, but a steady stream of them points at a marginal link: a riser, a dirty slot or signal integrity at the current generation.",
+			NextSteps: []string{
+				"Check whether the count keeps rising under load; a few after boot are harmless.",
+				"Reseat the GPU and replace or remove any riser cable.",
+			},
+		}), true
+	}
+	return types.Finding{}, false
+}
+
+// ── Resizable BAR ─────────────────────────────────────────────────────
+
+func analyzeReBAR(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	var gpus []types.GPUInfo
+	for _, g := range report.GPUs {
+		if g.ReBAR != nil {
+			gpus = append(gpus, g)
+		}
+	}
+	for _, g := range gpus {
+		r := g.ReBAR
+		// Only a resizable BAR1 whose current size was read is known to be
+		// disabled; a large BAR1 alone may be fixed (V100, T4, A100)
+		if !r.Supported || r.Enabled == nil || *r.Enabled || r.BAR1SizeMB == 0 {
+			continue
+		}
+
+		evidence := fmt.Sprintf("%s supports Resizable BAR, but BAR1 is %d MB", gpuModel(g), r.BAR1SizeMB)
+		if r.BAR1MaxMB > r.BAR1SizeMB {
+			evidence += fmt.Sprintf(" of a possible %s", formatMB(r.BAR1MaxMB))
+		}
+		evidence += "."
+		switch r.Above4GDecoding {
+		case "enabled":
+			evidence += " BAR1 is mapped above 4 GB, so Above 4G Decoding is on."
+		case "disabled":
+			evidence += " BAR1 is mapped below 4 GB, so Above 4G Decoding is likely off."
+		}
+		if r.BAR1UsedMB > 0 && r.BAR1UsedMB*100 >= r.BAR1SizeMB*90 {
+			evidence += fmt.Sprintf(" %d of %d MB of BAR1 are in use.", r.BAR1UsedMB, r.BAR1SizeMB)
+		}
+
+		steps := []string{
+			"Enable 'Above 4G Decoding' and then 'Re-Size BAR Support' in the motherboard UEFI setup.",
+		}
+		if report.System.BootMode == "Legacy/BIOS" {
+			steps = append(steps, "The system boots in Legacy/BIOS mode: ReBAR needs UEFI boot with CSM disabled, which may mean converting the boot disk to GPT first.")
+		}
+		steps = append(steps,
+			"Update the motherboard BIOS; many boards only gained ReBAR in later releases.",
+			"Update the GPU driver, and the GPU VBIOS if the card shipped before ReBAR support (some RTX 30 series cards need a vendor VBIOS update).",
+		)
+
+		findings = append(findings, forGPU([]types.Finding{fromRule("rebar-disabled", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: "Without Resizable BAR the CPU can only reach the GPU's memory through a 256 MB window, so large transfers are split into many small ones. Enabling it gains a few percent in many games and avoids the window filling up with several GPU applications open.",
+			NextSteps:    steps,
+		})}, g.Index, g.PCIBusID, len(gpus) > 1)...)
+	}
+	return findings
+}
+
+// formatMB formats a size in MB as "512 MB" or, from 1 GB up, "32 GB".
+func formatMB(mb int64) string {
+	if mb >= 1024 && mb%1024 == 0 {
+		return fmt.Sprintf("%d GB", mb/1024)
+	}
+	return fmt.Sprintf("%d MB", mb)
+}
+
+// ── GPU Memory Health ─────────────────────────────────────────────────
+
+// retiredPagesRMA is the retired page count at which NVIDIA considers a
+// pre-Ampere data-center GPU eligible for replacement.
+const retiredPagesRMA = 60
+
+// memoryXids are the Xid codes the driver logs for ECC, page retirement and
+// row remapping events.
+var memoryXids = []int{48, 63, 64, 92, 94, 95}
+
+func analyzeMemoryHealth(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	var gpus []*types.MemoryHealthInfo
+	for _, g := range report.GPUs {
+		if g.MemoryHealth != nil {
+			gpus = append(gpus, g.MemoryHealth)
+		}
+	}
+	xids := memoryXidEvidence(report)
+	for _, m := range gpus {
+		findings = append(findings, forGPU(analyzeGPUMemoryHealth(m, xids), m.GPUIndex, m.PCIBusID, len(gpus) > 1)...)
+	}
+	return findings
+}
+
+func analyzeGPUMemoryHealth(m *types.MemoryHealthInfo, xids string) []types.Finding {
+	var findings []types.Finding
+
+	retired := m.RetiredSingleBit + m.RetiredDoubleBit
+	var rma []string
+	if m.RemapFailed {
+		rma = append(rma, fmt.Sprintf("row remapping failed (%d rows remapped for uncorrectable errors)", m.RemappedUncorrectable))
+	}
+	if m.SRAMThresholdExceeded {
+		rma = append(rma, "the uncorrectable SRAM error threshold was exceeded")
+	}
+	if m.RetiredPagesSupported && retired >= retiredPagesRMA {
+		rma = append(rma, fmt.Sprintf("%d pages retired (%d single-bit, %d double-bit), at or above the limit of %d",
+			retired, m.RetiredSingleBit, m.RetiredDoubleBit, retiredPagesRMA))
+	}
+	if len(rma) > 0 {
+		evidence := "The GPU reports that " + strings.Join(rma, "; ") + "."
+		if len(m.RemapHistogram) == 5 && m.RemapHistogram[4] > 0 {
+			evidence += fmt.Sprintf(" %d memory bank(s) have no spare rows left.", m.RemapHistogram[4])
+		}
+		findings = append(findings, fromRule("gpu-memory-rma", types.Finding{
+			Evidence:     evidence + xids,
+			WhyItMatters: "The GPU has run out of ways to repair its own memory. Further errors will corrupt data or crash workloads, and the GPU is eligible for replacement under NVIDIA's RMA policy.",
+			NextSteps: []string{
+				"Drain workloads from this GPU.",
+				"Save the output of 'nvidia-smi -q -d ROW_REMAPPER,PAGE_RETIREMENT,ECC' for the vendor.",
+				"Contact your system vendor or NVIDIA to RMA the GPU, attaching this report.",
+			},
+		}))
+	}
+
+	var pending []string
+	if m.RemapPending {
+		pending = append(pending, "a row remap")
+	}
+	if m.RetirementPending {
+		pending = append(pending, "a page retirement")
+	}
+	if m.ECCSupported && m.ECCEnabled != m.ECCPendingEnabled {
+		pending = append(pending, fmt.Sprintf("an ECC mode change (%s after reset)", onOff(m.ECCPendingEnabled)))
+	}
+	if len(pending) > 0 {
+		findings = append(findings, fromRule("gpu-memory-reset-needed", types.Finding{
+			Evidence:     "Pending until the next GPU reset: " + strings.Join(pending, ", ") + "." + xids,
+			WhyItMatters: "Memory repairs and ECC mode changes only take effect after a GPU reset. Until then, memory the GPU has marked as faulty can still be handed to applications, so new jobs may hit the same errors.",
+			NextSteps: []string{
+				"Stop the processes using this GPU.",
+				"Reset it with 'sudo nvidia-smi -r -i <index>', or reboot the machine.",
+				"Re-run NVCheckup afterwards to confirm nothing is pending.",
+			},
+		}))
+	}
+
+	if m.VolatileUncorrectable > 0 && len(rma) == 0 {
+		findings = append(findings, fromRule("ecc-uncorrectable-errors", types.Finding{
+			Evidence: fmt.Sprintf("%d uncorrectable and %d correctable ECC error(s) since the driver loaded; %d uncorrectable over the GPU's lifetime.",
+				m.VolatileUncorrectable, m.VolatileCorrectable, m.AggregateUncorrectable) + xids,
+			WhyItMatters: "Uncorrectable ECC errors mean data in GPU memory was lost. The application that owned it was most likely killed, and repeated errors point to failing memory.",
+			NextSteps: []string{
+				"Check which jobs failed around the time of the errors.",
+				"Reset the GPU so the driver can retire or remap the affected memory.",
+				"If the count keeps growing after a reset, plan to replace the GPU.",
+			},
+		}))
+	}
+
+	return findings
+}
+
+// memoryXidEvidence notes any memory-related Xid errors in the kernel log,
+// for appending to memory health evidence.
+func memoryXidEvidence(report *types.Report) string {
+	if report.Linux == nil {
+		return ""
+	}
+	var codes []string
+	for _, x := range report.Linux.XidErrors {
+		for _, c := range memoryXids {
+			if x.Code == c {
+				codes = append(codes, fmt.Sprintf("Xid %d x%d", x.Code, x.Count))
+			}
+		}
+	}
+	if len(codes) == 0 {
+		return ""
+	}
+	return " Kernel log: " + strings.Join(codes, ", ") + "."
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// ── MIG ───────────────────────────────────────────────────────────────
+
+func analyzeMIG(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	var migGPUs []types.GPUInfo
+	for _, g := range report.GPUs {
+		if g.MIG != nil {
+			migGPUs = append(migGPUs, g)
+		}
+	}
+	for _, g := range migGPUs {
+		findings = append(findings, forGPU(analyzeGPUMIG(g.MIG), g.Index, g.PCIBusID, len(migGPUs) > 1)...)
+	}
+	return findings
+}
+
+func analyzeGPUMIG(m *types.MIGInfo) []types.Finding {
+	var findings []types.Finding
+
+	if m.Enabled != m.PendingEnabled {
+		findings = append(findings, fromRule("mig-reset-pending", types.Finding{
+			Evidence:     fmt.Sprintf("MIG mode is %s now and %s after the next GPU reset.", onOff(m.Enabled), onOff(m.PendingEnabled)),
+			WhyItMatters: "MIG mode changes only take effect after the GPU is reset. Until then the GPU keeps its old layout, so jobs see either the whole GPU or the MIG slices they were not configured for.",
+			NextSteps: []string{
+				"Stop all processes using the GPU (including nvidia-persistenced and DCGM if they hold it).",
+				"Reset it with 'sudo nvidia-smi -r -i <index>', or reboot the machine.",
+				"Confirm with 'nvidia-smi -q -d MIG' that current and pending modes match.",
+			},
+		}))
+	}
+
+	if m.Enabled && m.PendingEnabled && len(m.Instances) == 0 {
+		findings = append(findings, fromRule("mig-no-instances", types.Finding{
+			Evidence:     "MIG mode is enabled, but no GPU or compute instances exist.",
+			WhyItMatters: "With MIG enabled the GPU itself is not a CUDA device; only its MIG instances are. With no instances, CUDA applications on this GPU find no device at all.",
+			NextSteps: []string{
+				"Create instances, e.g. 'sudo nvidia-smi mig -cgi 9,9 -C' (list profiles with 'nvidia-smi mig -lgip').",
+				"Or disable MIG with 'sudo nvidia-smi -i <index> -mig 0' and reset the GPU.",
+			},
+		}))
 	}
 
 	return findings
 }
 
+// migVisibilityNotes explains how MIG can hide GPUs from CUDA on this
+// machine, for the evidence of findings about CUDA not seeing a GPU.
+func migVisibilityNotes(report *types.Report) []string {
+	var notes []string
+	visible := ""
+	if report.Topology != nil {
+		visible = report.Topology.VisibleDevices
+	}
+	for _, g := range report.GPUs {
+		if g.MIG == nil || !g.MIG.Enabled {
+			continue
+		}
+		if len(g.MIG.Instances) == 0 {
+			notes = append(notes, fmt.Sprintf("GPU %d is in MIG mode with no instances, so CUDA has no device on it.", g.Index))
+			continue
+		}
+		for _, entry := range strings.Split(visible, ",") {
+			if strings.TrimSpace(entry) == strconv.Itoa(g.Index) {
+				notes = append(notes, fmt.Sprintf("CUDA_VISIBLE_DEVICES=%s names GPU %d by index, but it is in MIG mode; select its instances by MIG UUID instead.", visible, g.Index))
+			}
+		}
+	}
+	return notes
+}
+
+// migSlice returns the MIG instance a device name such as
+// "NVIDIA H100 80GB HBM3 MIG 3g.40gb" refers to, if any.
+func migSlice(report *types.Report, deviceName string) *types.MIGInstance {
+	_, profile, ok := strings.Cut(deviceName, " MIG ")
+	if !ok {
+		return nil
+	}
+	for _, g := range report.GPUs {
+		if g.MIG == nil {
+			continue
+		}
+		for i, inst := range g.MIG.Instances {
+			if inst.Profile == strings.TrimSpace(profile) {
+				return &g.MIG.Instances[i]
+			}
+		}
+	}
+	return nil
+}
+
 // ── Display Analysis ──────────────────────────────────────────────────
 
 func analyzeDisplay(report *types.Report) []types.Finding {
@@ -334,9 +1059,7 @@ func analyzeDisplay(report *types.Report) []types.Finding {
 		for r := range refreshRates {
 			rates = append(rates, fmt.Sprintf("%dHz", r))
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Mixed Refresh Rate Multi-Monitor Setup",
+		findings = append(findings, fromRule("mixed-refresh-rate", types.Finding{
 			Evidence:     fmt.Sprintf("%d monitors with different refresh rates: %s.", len(report.Displays), strings.Join(rates, ", ")),
 			WhyItMatters: "Mixed refresh rates across monitors can cause frame pacing issues, stutter, and micro-lag in some applications and desktop compositors.",
 			NextSteps: []string{
@@ -344,25 +1067,19 @@ func analyzeDisplay(report *types.Report) []types.Finding {
 				"On Windows: Ensure both monitors use the correct refresh rate in Display Settings.",
 				"Consider closing secondary monitor apps during competitive gaming.",
 			},
-			Category:   "display",
-			Confidence: 65,
-		})
+		}))
 	}
 
 	// High display chain complexity (3+ monitors on same GPU)
 	if len(report.Displays) >= 3 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "High Display Chain Complexity",
+		findings = append(findings, fromRule("display-chain-complex", types.Finding{
 			Evidence:     fmt.Sprintf("%d displays connected.", len(report.Displays)),
 			WhyItMatters: "Running 3 or more displays from a single GPU increases GPU compositor load and may reduce gaming performance by a few percent.",
 			NextSteps: []string{
 				"If experiencing performance issues, try disconnecting unused monitors during demanding workloads.",
 				"Consider using the iGPU for secondary displays if available.",
 			},
-			Category:   "display",
-			Confidence: 50,
-		})
+		}))
 	}
 
 	return findings
@@ -383,9 +1100,7 @@ func analyzeNetwork(report *types.Report) []types.Finding {
 	// High jitter
 	if n.JitterMs > 15 {
 		hasIssue = true
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "High Network Jitter Detected",
+		findings = append(findings, fromRule("high-jitter", types.Finding{
 			Evidence:     fmt.Sprintf("Jitter: %.1f ms (threshold: 15 ms). Interface: %s (%s).", n.JitterMs, n.InterfaceName, n.InterfaceType),
 			WhyItMatters: "High jitter causes inconsistent latency, leading to lag spikes and stutter in online games and real-time applications.",
 			NextSteps: []string{
@@ -393,23 +1108,14 @@ func analyzeNetwork(report *types.Report) []types.Finding {
 				"Check for background downloads or streaming on the network.",
 				"If on ethernet, check cable quality and switch/router condition.",
 			},
-			Category:   "network",
-			Confidence: 85,
-		})
+		}))
 	}
 
 	// Packet loss
 	if n.PacketLossPct > 0 {
 		hasIssue = true
-		sev := types.SeverityWarn
-		confidence := 90
-		if n.PacketLossPct > 5 {
-			sev = types.SeverityCrit
-			confidence = 95
-		}
-		findings = append(findings, types.Finding{
-			Severity:     sev,
-			Title:        "Packet Loss Detected",
+		findings = append(findings, fromRule("packet-loss", types.Finding{
+			Severity:     escalate("packet-loss", n.PacketLossPct),
 			Evidence:     fmt.Sprintf("Packet loss: %.1f%%. Interface: %s (%s).", n.PacketLossPct, n.InterfaceName, n.InterfaceType),
 			WhyItMatters: "Packet loss causes disconnections, rubber-banding in games, and degraded streaming quality. This is a significant network quality issue.",
 			NextSteps: []string{
@@ -418,21 +1124,17 @@ func analyzeNetwork(report *types.Report) []types.Finding {
 				"Contact your ISP if packet loss persists on ethernet.",
 				"Check for failing network hardware (cable, switch, NIC).",
 			},
-			Category:   "network",
-			Confidence: confidence,
-		})
+		}))
 	}
 
 	// Wi-Fi congestion
 	if n.InterfaceType == "wifi" && n.WifiBand == "2.4GHz" {
 		hasIssue = true
-		confidence := 60
+		confidence := ruleConfidence("wifi-congestion")
 		if n.WifiSignalDBM < -70 {
-			confidence = 75
+			confidence += 15 // Weak signal makes congestion more likely to bite
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Wi-Fi Congestion Likely",
+		findings = append(findings, fromRule("wifi-congestion", types.Finding{
 			Evidence:     fmt.Sprintf("Connected on %s Wi-Fi. Signal: %d dBm.", n.WifiBand, n.WifiSignalDBM),
 			WhyItMatters: "2.4 GHz Wi-Fi is more susceptible to congestion from nearby networks, microwaves, and other devices. This can cause latency spikes.",
 			NextSteps: []string{
@@ -440,39 +1142,30 @@ func analyzeNetwork(report *types.Report) []types.Finding {
 				"Use ethernet for the most reliable connection.",
 				"Move closer to the router or remove obstructions.",
 			},
-			Category:   "network",
 			Confidence: confidence,
-		})
+		}))
 	}
 
 	// DNS slow
 	if n.DNSTimeMs > 100 {
 		hasIssue = true
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Slow DNS Resolution",
+		findings = append(findings, fromRule("dns-slow", types.Finding{
 			Evidence:     fmt.Sprintf("DNS resolution time: %.0f ms.", n.DNSTimeMs),
 			WhyItMatters: "Slow DNS adds latency to the initial connection to servers. While it doesn't affect ongoing connections, it delays matchmaking and page loads.",
 			NextSteps: []string{
 				"Consider switching to a faster DNS provider (1.1.1.1, 8.8.8.8, or 9.9.9.9).",
 				"Check if your router's DNS settings are optimal.",
 			},
-			Category:   "network",
-			Confidence: 70,
-		})
+		}))
 	}
 
 	// Network healthy
 	if !hasIssue {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Network Appears Healthy",
+		findings = append(findings, fromRule("network-healthy", types.Finding{
 			Evidence:     fmt.Sprintf("Latency: %.1f ms. Jitter: %.1f ms. Packet loss: %.1f%%. DNS: %.0f ms.", n.LatencyMs, n.JitterMs, n.PacketLossPct, n.DNSTimeMs),
 			WhyItMatters: "Local network and LAN diagnostics look good. If you are experiencing online issues, they are likely upstream or service-side.",
 			NextSteps:    []string{"No network action needed. Issue may be external to your network."},
-			Category:     "network",
-			Confidence:   80,
-		})
+		}))
 	}
 
 	return findings
@@ -490,15 +1183,35 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 	// Xid errors
 	if len(report.Linux.XidErrors) > 0 {
 		totalCount := 0
-		var codes []string
+		worst := types.SeverityInfo
+		var codes, details, unrecognized []string
 		for _, xid := range report.Linux.XidErrors {
 			totalCount += xid.Count
-			codes = append(codes, fmt.Sprintf("Xid %d (%s) x%d", xid.Code, xid.Message, xid.Count))
+			if severityRank(xid.Severity) < severityRank(worst) {
+				worst = xid.Severity
+			}
+			if xid.Unrecognized {
+				unrecognized = append(unrecognized, fmt.Sprintf("%d", xid.Code))
+				codes = append(codes, fmt.Sprintf("Xid %d (unrecognized) x%d", xid.Code, xid.Count))
+				continue
+			}
+			codes = append(codes, fmt.Sprintf("Xid %d (%s, %s) x%d", xid.Code, xid.Message, xid.Severity, xid.Count))
+			if xid.Detail != "" {
+				details = append(details, fmt.Sprintf("Xid %d: %s", xid.Code, xid.Detail))
+			}
+		}
+
+		evidence := fmt.Sprintf("%d Xid error(s) found: %s.", totalCount, strings.Join(codes, "; "))
+		if len(details) > 0 {
+			evidence += " " + strings.Join(details, " ")
+		}
+		if len(unrecognized) > 0 {
+			evidence += fmt.Sprintf(" Note: unrecognized Xid code(s) %s are not in the knowledge pack; see NVIDIA's Xid error catalog.", strings.Join(unrecognized, ", "))
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "NVIDIA Xid Errors Detected",
-			Evidence:     fmt.Sprintf("%d Xid error(s) found: %s.", totalCount, strings.Join(codes, "; ")),
+
+		findings = append(findings, fromRule("xid-errors", types.Finding{
+			Severity:     worst,
+			Evidence:     evidence,
 			WhyItMatters: "Xid errors are GPU hardware/driver fault reports from the NVIDIA kernel module. They indicate serious issues ranging from memory faults to the GPU falling off the PCIe bus.",
 			NextSteps: []string{
 				"Update to the latest NVIDIA driver.",
@@ -507,9 +1220,7 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 				"If overclocked, revert to stock clocks.",
 				"Run a GPU stress test (e.g., furmark) while monitoring for new Xid errors.",
 			},
-			Category:   "hardware",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	// llvmpipe fallback
@@ -518,9 +1229,7 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 		if renderer == "" {
 			renderer = "llvmpipe (software)"
 		}
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "Software Rendering (llvmpipe) Active",
+		findings = append(findings, fromRule("llvmpipe-fallback", types.Finding{
 			Evidence:     fmt.Sprintf("OpenGL renderer: %s.", renderer),
 			WhyItMatters: "The system is using CPU-based software rendering instead of the NVIDIA GPU. All graphics and CUDA workloads will be extremely slow.",
 			NextSteps: []string{
@@ -529,9 +1238,7 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 				"Verify /dev/nvidia* device nodes exist.",
 				"If using Wayland, ensure the correct EGL driver is being selected.",
 			},
-			Category:   "driver",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	// Wayland + NVIDIA
@@ -542,9 +1249,7 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 			nvidiaLoaded = true
 		}
 		if nvidiaLoaded {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityWarn,
-				Title:        "Wayland Session with NVIDIA — Known Compatibility Issues",
+			findings = append(findings, fromRule("wayland-nvidia-issue", types.Finding{
 				Evidence:     fmt.Sprintf("Session type: Wayland. NVIDIA driver: %s.", report.Driver.Version),
 				WhyItMatters: "While NVIDIA Wayland support has improved significantly, some applications and compositors may still exhibit screen tearing, window glitches, or reduced performance compared to X11.",
 				NextSteps: []string{
@@ -552,9 +1257,7 @@ func analyzeLinuxAdvanced(report *types.Report) []types.Finding {
 					"If experiencing issues, test with X11 session to compare.",
 					"Check if your compositor supports direct scanout and explicit sync.",
 				},
-				Category:   "driver",
-				Confidence: 70,
-			})
+			}))
 		}
 	}
 
@@ -576,16 +1279,8 @@ func analyzeWindowsGaming(report *types.Report) []types.Finding {
 		count := len(w.DriverResetEvents)
 		lastEvent := w.DriverResetEvents[0] // Most recent first
 
-		sev := types.SeverityWarn
-		confidence := 85
-		if count >= 3 {
-			sev = types.SeverityCrit
-			confidence = 92
-		}
-
-		findings = append(findings, types.Finding{
-			Severity: sev,
-			Title:    "Display Driver Resets Detected (Event ID 4101)",
+		findings = append(findings, fromRule("driver-resets-4101", types.Finding{
+			Severity: escalate("driver-resets-4101", float64(count)),
 			Evidence: fmt.Sprintf("%d driver reset event(s) in the last 30 days. Most recent: %s.",
 				count, lastEvent.Time.Format("2006-01-02 15:04")),
 			WhyItMatters: "Event ID 4101 indicates the display driver stopped responding and was recovered by Windows. Frequent occurrences cause black screens, freezes, and application crashes.",
@@ -596,24 +1291,14 @@ func analyzeWindowsGaming(report *types.Report) []types.Finding {
 				"Test with Hardware-Accelerated GPU Scheduling (HAGS) toggled off.",
 				"If recent Windows Update coincides with issues, consider testing a rollback (understand security implications first).",
 			},
-			Category:   "driver",
-			Confidence: confidence,
-		})
+		}))
 	}
 
 	// nvlddmkm errors
 	if len(w.NvlddmkmErrors) > 0 {
 		count := len(w.NvlddmkmErrors)
-		sev := types.SeverityWarn
-		confidence := 85
-		if count >= 5 {
-			sev = types.SeverityCrit
-			confidence = 92
-		}
-
-		findings = append(findings, types.Finding{
-			Severity:     sev,
-			Title:        "nvlddmkm Driver Errors Detected",
+		findings = append(findings, fromRule("nvlddmkm-errors", types.Finding{
+			Severity:     escalate("nvlddmkm-errors", float64(count)),
 			Evidence:     fmt.Sprintf("%d nvlddmkm error(s) in the last 30 days.", count),
 			WhyItMatters: "nvlddmkm is the NVIDIA Windows kernel-mode driver. Errors here often correlate with crashes, BSODs, or display instability.",
 			NextSteps: []string{
@@ -622,16 +1307,12 @@ func analyzeWindowsGaming(report *types.Report) []types.Finding {
 				"Check for BIOS/UEFI updates for your motherboard.",
 				"Test GPU in another PCIe slot if available.",
 			},
-			Category:   "driver",
-			Confidence: confidence,
-		})
+		}))
 	}
 
 	// WHEA errors
 	if len(w.WHEAErrors) > 0 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "Hardware Errors (WHEA) Detected",
+		findings = append(findings, fromRule("whea-errors", types.Finding{
 			Evidence:     fmt.Sprintf("%d WHEA hardware error(s) in the last 30 days.", len(w.WHEAErrors)),
 			WhyItMatters: "WHEA (Windows Hardware Error Architecture) errors indicate hardware-level issues. These can be CPU, memory, or PCIe related and may contribute to system instability.",
 			NextSteps: []string{
@@ -640,70 +1321,36 @@ func analyzeWindowsGaming(report *types.Report) []types.Finding {
 				"Check PCIe slot seating and power connections.",
 				"Update motherboard BIOS/UEFI to latest version.",
 			},
-			Category:   "hardware",
-			Confidence: 75,
-		})
+		}))
 	}
 
 	// Power plan
 	if w.PowerPlan != "" && !strings.Contains(strings.ToLower(w.PowerPlan), "high performance") && !strings.Contains(strings.ToLower(w.PowerPlan), "ultimate") {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Power Plan Not Set to High Performance",
+		findings = append(findings, fromRule("power-plan-suboptimal", types.Finding{
 			Evidence:     fmt.Sprintf("Active power plan: %s.", w.PowerPlan),
 			WhyItMatters: "Balanced or Power Saver plans may throttle CPU/GPU performance. For gaming or CUDA workloads, High Performance is generally recommended.",
 			NextSteps: []string{
 				"Open Power Options and switch to 'High Performance' for testing.",
 				"This is a reversible change with no risk.",
 			},
-			Category:   "performance",
-			Confidence: 40,
-			Remediation: &types.RemediationAction{
-				ID:          "set-high-performance",
-				Title:       "Switch Power Plan to High Performance",
-				Risk:        types.RiskLow,
-				Description: "Changes the Windows power plan to High Performance using powercfg.",
-				DryRunDesc:  "Would run: powercfg /setactive 8c5e7fda-e8bf-4a96-9a85-a6e23a8c635c",
-				UndoDesc:    "Restore the previous power plan.",
-				Platform:    "windows",
-				NeedsReboot: false,
-				NeedsAdmin:  true,
-			},
-		})
+		}))
 	}
 
 	// HAGS info
 	if w.HAGSEnabled == "Enabled" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Hardware-Accelerated GPU Scheduling (HAGS) is Enabled",
+		findings = append(findings, fromRule("hags-enabled", types.Finding{
 			Evidence:     "HAGS is currently enabled.",
 			WhyItMatters: "HAGS can improve performance in some scenarios but has been reported to cause stuttering or instability in certain games or driver versions.",
 			NextSteps: []string{
 				"If experiencing stutter or instability, try disabling HAGS in Settings > System > Display > Graphics > Change default graphics settings.",
 				"This is a reversible change.",
 			},
-			Category:   "performance",
-			Confidence: 45,
-			Remediation: &types.RemediationAction{
-				ID:          "disable-hags",
-				Title:       "Disable Hardware-Accelerated GPU Scheduling",
-				Risk:        types.RiskLow,
-				Description: "Sets the HAGS registry key to Disabled.",
-				DryRunDesc:  "Would set registry HwSchMode to 1 (Disabled).",
-				UndoDesc:    "Restore HwSchMode to 2 (Enabled). Requires reboot.",
-				Platform:    "windows",
-				NeedsReboot: true,
-				NeedsAdmin:  true,
-			},
-		})
+		}))
 	}
 
 	// Recent updates correlation
 	if len(w.RecentKBs) > 0 && len(w.DriverResetEvents) > 0 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Recent Windows Updates Installed",
+		findings = append(findings, fromRule("recent-windows-updates", types.Finding{
 			Evidence:     fmt.Sprintf("%d Windows Update(s) installed in the last 60 days.", len(w.RecentKBs)),
 			WhyItMatters: "Windows Updates can occasionally introduce driver compatibility issues. If issues started after a specific update, it may be worth investigating.",
 			NextSteps: []string{
@@ -711,9 +1358,7 @@ func analyzeWindowsGaming(report *types.Report) []types.Finding {
 				"Rollback specific updates only if you understand the security implications.",
 				"Prefer updating NVIDIA drivers over rolling back Windows updates.",
 			},
-			Category:   "updates",
-			Confidence: 35,
-		})
+		}))
 	}
 
 	return findings
@@ -737,8 +1382,7 @@ func analyzeOverlays(report *types.Report) []types.Finding {
 			version = report.Windows.GFEVersion
 		}
 
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
+		findings = append(findings, fromRule("nvidia-app-detected", types.Finding{
 			Title:        fmt.Sprintf("%s Detected (v%s)", appName, version),
 			Evidence:     fmt.Sprintf("%s version %s is installed.", appName, version),
 			WhyItMatters: "The in-game overlay, Game Filters, and Photo Mode features can occasionally impact performance or cause alt-tab issues in some games.",
@@ -746,26 +1390,20 @@ func analyzeOverlays(report *types.Report) []types.Finding {
 				"If experiencing performance drops or alt-tab bugs, try disabling the in-game overlay temporarily.",
 				"This does not require uninstalling — just toggle the overlay feature off in settings.",
 			},
-			Category:   "overlay",
-			Confidence: 50,
-		})
+		}))
 	}
 
 	// Other overlays
 	if len(report.Windows.OverlaySoftware) > 0 {
 		overlayList := strings.Join(report.Windows.OverlaySoftware, ", ")
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "Overlay/Recording Software Detected",
+		findings = append(findings, fromRule("overlay-software", types.Finding{
 			Evidence:     fmt.Sprintf("Detected: %s.", overlayList),
 			WhyItMatters: "Multiple active overlays can compete for resources and cause frame pacing issues, stutter, or input lag. This is informational — these tools are commonly used and are not inherently problematic.",
 			NextSteps: []string{
 				"If experiencing stutter, try disabling overlays one at a time to isolate the cause.",
 				"Ensure only one overlay/recording tool is active during gaming.",
 			},
-			Category:   "overlay",
-			Confidence: 50,
-		})
+		}))
 	}
 
 	return findings
@@ -785,18 +1423,14 @@ func analyzeStreaming(report *types.Report) []types.Finding {
 	}
 
 	if !hasNvidiaGPU {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "No NVIDIA GPU Available for Hardware Encoding",
+		findings = append(findings, fromRule("no-nvidia-gpu-encoding", types.Finding{
 			Evidence:     "No NVIDIA GPU detected — hardware encoding is not available.",
 			WhyItMatters: "NVIDIA hardware encoding is used by OBS, Shadowplay, and other streaming/recording tools. Without an NVIDIA GPU, software encoding must be used instead.",
 			NextSteps: []string{
 				"Ensure the NVIDIA GPU is properly installed and detected.",
 				"Install the NVIDIA driver.",
 			},
-			Category:   "gpu",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	return findings
@@ -815,9 +1449,7 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 
 	// Check for nouveau
 	if loaded, exists := mods["nouveau"]; exists && loaded {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "Nouveau Driver is Active (Instead of NVIDIA)",
+		findings = append(findings, fromRule("nouveau-active", types.Finding{
 			Evidence:     "The open-source 'nouveau' kernel module is loaded instead of the proprietary NVIDIA driver.",
 			WhyItMatters: "Nouveau does not support CUDA or Vulkan performance comparable to the NVIDIA driver. GPU acceleration will be severely limited.",
 			NextSteps: []string{
@@ -828,20 +1460,7 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 				"Fedora: sudo dnf install akmod-nvidia",
 				"Arch: sudo pacman -S nvidia",
 			},
-			Category:   "driver",
-			Confidence: 95,
-			Remediation: &types.RemediationAction{
-				ID:          "blacklist-nouveau",
-				Title:       "Blacklist Nouveau Driver",
-				Risk:        types.RiskMedium,
-				Description: "Creates /etc/modprobe.d/blacklist-nouveau.conf to prevent nouveau from loading.",
-				DryRunDesc:  "Would create /etc/modprobe.d/blacklist-nouveau.conf with blacklist entries.",
-				UndoDesc:    "Remove /etc/modprobe.d/blacklist-nouveau.conf and rebuild initramfs.",
-				Platform:    "linux",
-				NeedsReboot: true,
-				NeedsAdmin:  true,
-			},
-		})
+		}))
 	}
 
 	// Check nvidia module not loaded
@@ -851,9 +1470,7 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 	}
 
 	if !nvidiaLoaded && report.Driver.Version == "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "NVIDIA Kernel Module Not Loaded",
+		findings = append(findings, fromRule("nvidia-module-not-loaded", types.Finding{
 			Evidence:     "The 'nvidia' kernel module is not loaded. nvidia-smi will fail.",
 			WhyItMatters: "Without the NVIDIA kernel module, the GPU cannot be used for any accelerated workload.",
 			NextSteps: []string{
@@ -863,16 +1480,12 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 				"If Secure Boot is enabled, the module may need to be signed (see Secure Boot finding).",
 				"If using DKMS, check dkms status for build failures.",
 			},
-			Category:   "driver",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	// /dev/nvidia* nodes
 	if nvidiaLoaded && len(report.Linux.DevNvidiaNodes) == 0 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "No /dev/nvidia* Device Nodes Found",
+		findings = append(findings, fromRule("no-dev-nvidia", types.Finding{
 			Evidence:     "NVIDIA module appears loaded but /dev/nvidia* device nodes are missing.",
 			WhyItMatters: "Applications need /dev/nvidia0, /dev/nvidiactl, etc. to communicate with the GPU.",
 			NextSteps: []string{
@@ -880,16 +1493,12 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 				"Check if nvidia-persistenced is running.",
 				"Ensure nvidia_uvm module is loaded: sudo modprobe nvidia_uvm.",
 			},
-			Category:   "driver",
-			Confidence: 85,
-		})
+		}))
 	}
 
 	// libcuda.so
 	if report.Linux.LibCudaPath == "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "libcuda.so Not Found",
+		findings = append(findings, fromRule("libcuda-not-found", types.Finding{
 			Evidence:     "libcuda.so could not be located via ldconfig or common paths.",
 			WhyItMatters: "CUDA applications link against libcuda.so. If missing, frameworks like PyTorch and TensorFlow cannot access the GPU.",
 			NextSteps: []string{
@@ -897,28 +1506,23 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 				"Run 'sudo ldconfig' to update the library cache.",
 				"Check LD_LIBRARY_PATH if using a non-standard installation.",
 			},
-			Category:   "cuda",
-			Confidence: 85,
-			Remediation: &types.RemediationAction{
-				ID:          "update-ldconfig",
-				Title:       "Refresh Library Cache (ldconfig)",
-				Risk:        types.RiskLow,
-				Description: "Runs ldconfig to refresh the shared library cache.",
-				DryRunDesc:  "Would run: sudo ldconfig",
-				UndoDesc:    "No undo needed — ldconfig only refreshes the cache.",
-				Platform:    "linux",
-				NeedsReboot: false,
-				NeedsAdmin:  true,
-			},
-		})
+		}))
 	}
 
 	// DKMS failures
 	if report.Linux.DKMSErrors != "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "DKMS Build Failure Detected",
-			Evidence:     "DKMS reports errors for NVIDIA modules. The driver may not be built for the current kernel.",
+		evidence := "DKMS reports errors for NVIDIA modules. The driver may not be built for the current kernel."
+		var broken []string
+		for _, m := range report.Linux.DKMSModules {
+			if m.Module == "nvidia" && m.Kernel != "" && (m.State != "installed" || m.Note != "") {
+				broken = append(broken, fmt.Sprintf("%s %s for %s: %s", m.Module, m.Version, m.Kernel, strings.TrimSpace(m.State+" "+m.Note)))
+			}
+		}
+		if len(broken) > 0 {
+			evidence += " " + strings.Join(broken, "; ") + "."
+		}
+		findings = append(findings, fromRule("dkms-failure", types.Finding{
+			Evidence:     evidence,
 			WhyItMatters: "If DKMS fails to build the NVIDIA module for your running kernel (e.g., after a kernel update), the GPU will not function.",
 			NextSteps: []string{
 				"Run 'sudo dkms autoinstall' to retry building modules.",
@@ -927,11 +1531,149 @@ func analyzeLinuxModules(report *types.Report) []types.Finding {
 				"Fedora: sudo dnf install kernel-devel-$(uname -r)",
 				"Check 'dkms status' output for specific error details.",
 			},
-			Category:   "driver",
-			Confidence: 90,
-		})
+		}))
+	}
+
+	findings = append(findings, analyzeNextBootKernel(report)...)
+	findings = append(findings, analyzeDriverVersions(report)...)
+
+	return findings
+}
+
+// analyzeNextBootKernel checks that the kernel the bootloader starts next has
+// an nvidia module. A kernel update whose DKMS build failed leaves the running
+// system working and the next boot without a driver.
+func analyzeNextBootKernel(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	l := report.Linux
+	if l.NextBootKernel == "" {
+		return findings
+	}
+	var builtFor []string
+	next := -1
+	for i, k := range l.Kernels {
+		if k.Version == l.NextBootKernel {
+			next = i
+		}
+		if k.NVIDIAModule != "" {
+			builtFor = append(builtFor, k.Version)
+		}
+	}
+	var dkms []string
+	usesDKMS := false
+	for _, m := range l.DKMSModules {
+		if m.Module != "nvidia" {
+			continue
+		}
+		usesDKMS = true
+		if m.Kernel == l.NextBootKernel || m.Kernel == "" {
+			dkms = append(dkms, fmt.Sprintf("nvidia %s: %s", m.Version, m.State))
+		}
+	}
+	_, hasDriver := l.LoadedModules["nvidia"]
+	if next < 0 || l.Kernels[next].NVIDIAModule != "" || (!hasDriver && !usesDKMS && len(builtFor) == 0) {
+		return findings
+	}
+
+	k := l.NextBootKernel
+	evidence := fmt.Sprintf("The next boot starts kernel %s (%s), and /lib/modules/%s has no nvidia module.", k, l.NextBootSource, k)
+	if len(builtFor) > 0 {
+		evidence += " The module is built for: " + strings.Join(builtFor, ", ") + "."
+	}
+	if len(dkms) > 0 {
+		evidence += fmt.Sprintf(" DKMS for %s: %s.", k, strings.Join(dkms, ", "))
+	} else if usesDKMS {
+		evidence += fmt.Sprintf(" DKMS has no nvidia build for %s.", k)
+	}
+
+	steps := []string{
+		fmt.Sprintf("Install the headers for %s. Debian/Ubuntu: sudo apt install linux-headers-%s. Fedora: sudo dnf install kernel-devel-%s.", k, k, k),
 	}
+	if usesDKMS {
+		steps = append(steps,
+			fmt.Sprintf("Build the module: sudo dkms autoinstall -k %s, and check /var/lib/dkms/nvidia/<version>/build/make.log if it fails.", k))
+	} else {
+		steps = append(steps, fmt.Sprintf("Fedora/akmods: sudo akmods --kernels %s --force. Other distributions: install the nvidia module package built for this kernel.", k))
+	}
+	if len(builtFor) > 0 {
+		steps = append(steps, fmt.Sprintf("Until it is fixed, choose %s from the boot menu instead.", builtFor[len(builtFor)-1]))
+	}
+
+	findings = append(findings, fromRule("next-boot-kernel-no-nvidia", types.Finding{
+		Evidence:     evidence,
+		WhyItMatters: "The running kernel is fine, so nothing looks wrong yet. After the next reboot the nvidia module will not load, which usually means a black screen or a fallback to a low-resolution display, and no CUDA.",
+		NextSteps:    steps,
+	}))
+	return findings
+}
+
+// driverVersionPart is one place a Linux driver install reports its version.
+type driverVersionPart struct {
+	label, version string
+}
+
+// analyzeDriverVersions compares the loaded kernel module with the driver
+// version on disk. When everything on disk agrees but the loaded module
+// differs, the driver was upgraded without a reboot; when the parts on disk
+// disagree, an upgrade only partly completed.
+func analyzeDriverVersions(report *types.Report) []types.Finding {
+	var findings []types.Finding
+
+	v := report.Linux.DriverVersions
+	if v == nil {
+		return findings
+	}
+	loaded := v.LoadedModule
+	if loaded == "" {
+		loaded = report.Driver.KernelModuleVersion
+	}
+	modinfo := "modinfo nvidia"
+	if report.System.KernelVersion != "" {
+		modinfo += " (kernel " + report.System.KernelVersion + ")"
+	}
+	onDisk := []driverVersionPart{
+		{"libnvidia-ml.so (" + v.NVMLPath + ")", v.NVML},
+		{"libcuda.so (" + v.LibCUDAPath + ")", v.LibCUDA},
+		{"package " + v.PackageName, v.Package},
+		{modinfo, v.Modinfo},
+	}
+
+	var disk []string
+	lines := []string{"loaded kernel module: " + valueOr(loaded, "not loaded")}
+	for _, p := range onDisk {
+		if p.version == "" {
+			continue
+		}
+		lines = append(lines, p.label+": "+p.version)
+		if !containsString(disk, p.version) {
+			disk = append(disk, p.version)
+		}
+	}
+	evidence := "Driver versions — " + strings.Join(lines, "; ") + "."
 
+	switch {
+	case len(disk) == 1 && loaded != "" && loaded != disk[0]:
+		findings = append(findings, fromRule("driver-reboot-required", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: fmt.Sprintf("The driver on disk was upgraded to %s, but the running kernel still has the %s module loaded. The new libraries refuse to talk to the old module, so nvidia-smi, CUDA and NVENC fail with \"Failed to initialize NVML: Driver/library version mismatch\" until the module is reloaded.", disk[0], loaded),
+			NextSteps: []string{
+				"Reboot. This loads the new kernel module and is the reliable fix.",
+				"Without a reboot: stop everything using the GPU (display manager, nvidia-persistenced, containers), then 'sudo rmmod nvidia_uvm nvidia_drm nvidia_modeset nvidia' and 'sudo modprobe nvidia'.",
+			},
+		}))
+	case len(disk) > 1:
+		findings = append(findings, fromRule("driver-partial-upgrade", types.Finding{
+			Evidence:     evidence,
+			WhyItMatters: "Parts of the driver on disk are from different versions, so an upgrade stopped partway or packages from two sources (distribution, NVIDIA's repository, the .run installer) are mixed. Mismatched libraries and kernel module fail with \"Driver/library version mismatch\", and a reboot alone will not fix it.",
+			NextSteps: []string{
+				"Finish the upgrade. Debian/Ubuntu: sudo apt --fix-broken install && sudo apt full-upgrade. Fedora: sudo dnf upgrade '*nvidia*'. Arch: sudo pacman -Syu.",
+				"If a library version matches no installed package, it was left by the .run installer: remove it with 'sudo nvidia-uninstall' and reinstall the packaged driver.",
+				"If modinfo is behind the package, the kernel module was not rebuilt: check 'dkms status' and the kernel headers.",
+				"Reboot once every version above matches.",
+			},
+		}))
+	}
 	return findings
 }
 
@@ -951,9 +1693,7 @@ func analyzeSecureBoot(report *types.Report) []types.Finding {
 		}
 
 		if !nvidiaLoaded {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityCrit,
-				Title:        "Secure Boot Enabled — NVIDIA Module May Be Blocked",
+			findings = append(findings, fromRule("secureboot-blocking", types.Finding{
 				Evidence:     "Secure Boot is enabled and the NVIDIA kernel module is not loaded.",
 				WhyItMatters: "Secure Boot requires kernel modules to be signed with an enrolled key. Unsigned NVIDIA modules will be rejected by the kernel.",
 				NextSteps: []string{
@@ -961,19 +1701,13 @@ func analyzeSecureBoot(report *types.Report) []types.Finding {
 					"Option B: Disable Secure Boot in BIOS/UEFI (reduces system security).",
 					"Some distributions (Ubuntu) handle signing automatically with DKMS.",
 				},
-				Category:   "secureboot",
-				Confidence: 85,
-			})
+			}))
 		} else {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityInfo,
-				Title:        "Secure Boot Enabled — NVIDIA Module is Loading Successfully",
+			findings = append(findings, fromRule("secureboot-ok", types.Finding{
 				Evidence:     "Secure Boot is enabled and the NVIDIA module is loaded. Module signing appears to be properly configured.",
 				WhyItMatters: "This is the ideal configuration — security is maintained while NVIDIA drivers function correctly.",
 				NextSteps:    []string{"No action needed."},
-				Category:     "secureboot",
-				Confidence:   95,
-			})
+			}))
 		}
 	}
 
@@ -985,6 +1719,11 @@ func analyzeSecureBoot(report *types.Report) []types.Finding {
 func analyzeCUDA(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
+	// Multi-GPU interconnect and CPU affinity
+	if report.Topology != nil {
+		findings = append(findings, analyzeTopology(report.Topology)...)
+	}
+
 	if report.AI == nil {
 		return findings
 	}
@@ -995,9 +1734,7 @@ func analyzeCUDA(report *types.Report) []types.Finding {
 		driverMajor := majorVersion(report.Driver.CUDAVersion)
 
 		if toolkitMajor != "" && driverMajor != "" && toolkitMajor != driverMajor {
-			findings = append(findings, types.Finding{
-				Severity: types.SeverityWarn,
-				Title:    "CUDA Toolkit / Driver Version Mismatch",
+			findings = append(findings, fromRule("cuda-mismatch", types.Finding{
 				Evidence: fmt.Sprintf("CUDA Toolkit: %s, Driver CUDA runtime: %s.",
 					report.AI.CUDAToolkitVersion, report.Driver.CUDAVersion),
 				WhyItMatters: "Major version mismatches between the CUDA toolkit and driver can cause compilation or runtime failures. The driver's CUDA runtime must be >= the toolkit version.",
@@ -1006,15 +1743,192 @@ func analyzeCUDA(report *types.Report) []types.Finding {
 					"Or install a CUDA toolkit version matching the driver's supported CUDA version.",
 					"Check compatibility at: https://docs.nvidia.com/cuda/cuda-toolkit-release-notes/",
 				},
-				Category:   "cuda",
-				Confidence: 80,
+			}))
+		}
+	}
+
+	return findings
+}
+
+// hostPaths are topo -m connections that go through the CPU rather than
+// NVLink or a PCIe switch. SOC is the name drivers before R390 used for SYS.
+var hostPaths = []string{"PHB", "NODE", "SYS", "SOC"}
+
+func analyzeTopology(topo *types.TopologyInfo) []types.Finding {
+	var findings []types.Finding
+
+	visible := visibleGPUs(topo)
+
+	// GPUs with no NVLink or PCIe switch path to any other visible GPU
+	if len(visible) > 1 {
+		var hostOnly []string
+		crossSocket := false
+		for _, i := range visible {
+			g := topo.GPUs[i]
+			best := ""
+			for _, j := range visible {
+				if j == i || j >= len(g.Paths) {
+					continue
+				}
+				p := g.Paths[j]
+				if best == "" || pathRank(p) < pathRank(best) {
+					best = p
+				}
+			}
+			if best != "" && containsString(hostPaths, best) {
+				hostOnly = append(hostOnly, fmt.Sprintf("GPU %d (best peer path %s)", g.Index, best))
+				crossSocket = crossSocket || best == "SYS" || best == "SOC"
+			}
+		}
+		if len(hostOnly) > 0 {
+			f := fromRule("gpu-topology-host-only", types.Finding{
+				Evidence:     fmt.Sprintf("%s. Paths: PHB = through the CPU's PCIe host bridge, NODE = between host bridges on one CPU, SYS = across the CPU interconnect.", strings.Join(hostOnly, ", ")),
+				WhyItMatters: "NCCL all-reduce and peer-to-peer copies between these GPUs are staged through CPU memory or cross the socket interconnect, which is several times slower than NVLink or a PCIe switch and often the bottleneck in multi-GPU training.",
+				NextSteps: []string{
+					"Run 'nvidia-smi topo -m' and pick GPUs that share NVLink, PIX or PXB paths for the same job.",
+					"If the GPUs should be bridged, check that NVLink bridges are installed and seated.",
+					"Set NCCL_DEBUG=INFO and check which transport NCCL picks (P2P, SHM or NET).",
+				},
 			})
+			if !crossSocket {
+				f.Severity = types.SeverityInfo
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	// NVLinks that are down, on GPUs or systems that use NVLink
+	fabric := false
+	for _, g := range topo.GPUs {
+		for _, l := range g.NVLinks {
+			fabric = fabric || l.Up
+		}
+	}
+	for _, g := range topo.GPUs {
+		var down []string
+		for _, l := range g.NVLinks {
+			if !l.Up {
+				down = append(down, fmt.Sprintf("%d", l.Link))
+			}
+		}
+		if len(down) == 0 || !fabric {
+			continue
+		}
+		findings = append(findings, forGPU([]types.Finding{fromRule("nvlink-down", types.Finding{
+			Evidence:     fmt.Sprintf("NVLink %s inactive (%d of %d links up).", strings.Join(down, ", "), len(g.NVLinks)-len(down), len(g.NVLinks)),
+			WhyItMatters: "Each inactive link removes a share of the GPU's peer bandwidth. NCCL still runs, but collectives that include this GPU slow down, and a link that drops at runtime often shows up as Xid 74.",
+			NextSteps: []string{
+				"Check 'nvidia-smi nvlink --status' and 'nvidia-smi nvlink -e' for error counters.",
+				"Reseat the NVLink bridge, or on SXM systems ask the vendor to check the baseboard.",
+				"Reset the GPU or reboot; if the link stays down, open a hardware ticket.",
+			},
+		})}, g.Index, "", true)...)
+	}
+
+	// GPUs whose CPU affinity misses every CPU this process may run on
+	allowed := parseCPUList(topo.AllowedCPUs)
+	if len(allowed) > 0 {
+		for _, i := range visible {
+			g := topo.GPUs[i]
+			affinity := parseCPUList(g.CPUAffinity)
+			if len(affinity) == 0 || cpusOverlap(allowed, affinity) {
+				continue
+			}
+			node := ""
+			if g.NUMANode >= 0 {
+				node = fmt.Sprintf(", NUMA node %d", g.NUMANode)
+			}
+			findings = append(findings, forGPU([]types.Finding{fromRule("gpu-cpu-affinity-mismatch", types.Finding{
+				Evidence:     fmt.Sprintf("GPU is attached to CPUs %s%s, but this process may only run on CPUs %s.", g.CPUAffinity, node, topo.AllowedCPUs),
+				WhyItMatters: "Data loaders and host-to-device copies for this GPU run on a remote NUMA node, so every transfer crosses the CPU interconnect. Throughput drops and step times become uneven between GPUs.",
+				NextSteps: []string{
+					"Bind each rank to the CPUs near its GPU (e.g. 'numactl --cpunodebind=<node>' or your launcher's GPU binding option).",
+					"In Slurm, request --gpu-bind=closest or allocate CPUs on the GPU's socket.",
+					"Or set CUDA_VISIBLE_DEVICES to GPUs attached to the CPUs this job runs on.",
+				},
+			})}, g.Index, "", true)...)
 		}
 	}
 
 	return findings
 }
 
+// visibleGPUs returns the positions in topo.GPUs of the GPUs a CUDA job
+// would see. CUDA_VISIBLE_DEVICES entries that are not plain indices (UUIDs,
+// MIG devices) cannot be matched to the matrix, so all GPUs are kept.
+func visibleGPUs(topo *types.TopologyInfo) []int {
+	var all []int
+	for i := range topo.GPUs {
+		all = append(all, i)
+	}
+	if strings.TrimSpace(topo.VisibleDevices) == "" {
+		return all
+	}
+	var visible []int
+	for _, entry := range strings.Split(topo.VisibleDevices, ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err != nil {
+			return all
+		}
+		for i, g := range topo.GPUs {
+			if g.Index == index {
+				visible = append(visible, i)
+			}
+		}
+	}
+	return visible
+}
+
+// pathRank orders topo -m connections from nearest to farthest.
+func pathRank(path string) int {
+	switch {
+	case path == "X":
+		return 0
+	case strings.HasPrefix(path, "NV"):
+		return 1
+	case path == "PIX":
+		return 2
+	case path == "PXB":
+		return 3
+	case path == "PHB":
+		return 4
+	case path == "NODE":
+		return 5
+	}
+	return 6 // SYS, SOC and anything unknown
+}
+
+// parseCPUList parses a kernel CPU list such as "0-31,64-95" into a set.
+func parseCPUList(list string) map[int]bool {
+	cpus := map[int]bool{}
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil {
+				continue
+			}
+		}
+		for c := first; c <= last; c++ {
+			cpus[c] = true
+		}
+	}
+	return cpus
+}
+
+func cpusOverlap(a, b map[int]bool) bool {
+	for c := range a {
+		if b[c] {
+			return true
+		}
+	}
+	return false
+}
+
 func analyzePyTorch(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
@@ -1025,26 +1939,20 @@ func analyzePyTorch(report *types.Report) []types.Finding {
 	pt := report.AI.PyTorchInfo
 
 	if pt.Error != "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "PyTorch Import Error",
+		findings = append(findings, fromRule("pytorch-import-error", types.Finding{
 			Evidence:     fmt.Sprintf("Error importing PyTorch: %s", pt.Error),
 			WhyItMatters: "PyTorch could not be loaded, which will prevent GPU-accelerated training and inference.",
 			NextSteps: []string{
 				"Check your Python environment and PyTorch installation.",
 				"Reinstall PyTorch: pip install torch --index-url https://download.pytorch.org/whl/cu121",
 			},
-			Category:   "ai",
-			Confidence: 90,
-		})
+		}))
 		return findings
 	}
 
 	if !pt.CUDAAvailable {
 		if pt.CUDAVersion == "" {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityWarn,
-				Title:        "PyTorch Installed Without CUDA Support",
+			findings = append(findings, fromRule("pytorch-cpu-only", types.Finding{
 				Evidence:     fmt.Sprintf("PyTorch %s is installed but torch.version.cuda is empty — this is a CPU-only build.", pt.Version),
 				WhyItMatters: "A CPU-only PyTorch wheel was installed. torch.cuda.is_available() returns False because the CUDA runtime is not compiled in.",
 				NextSteps: []string{
@@ -1053,13 +1961,9 @@ func analyzePyTorch(report *types.Report) []types.Finding {
 					"Example: pip install torch torchvision torchaudio --index-url https://download.pytorch.org/whl/cu121",
 					"Make sure to select the correct CUDA version matching your driver.",
 				},
-				Category:   "ai",
-				Confidence: 95,
-			})
+			}))
 		} else {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityWarn,
-				Title:        "PyTorch CUDA Available but GPU Not Accessible",
+			f := fromRule("pytorch-cuda-no-gpu", types.Finding{
 				Evidence:     fmt.Sprintf("PyTorch %s has CUDA %s compiled in, but torch.cuda.is_available() is False.", pt.Version, pt.CUDAVersion),
 				WhyItMatters: "PyTorch was built with CUDA support but cannot access the GPU. This usually indicates a driver issue or environment mismatch.",
 				NextSteps: []string{
@@ -1068,25 +1972,70 @@ func analyzePyTorch(report *types.Report) []types.Finding {
 					"If using conda, ensure you're in the correct environment.",
 					"Check LD_LIBRARY_PATH (Linux) or PATH (Windows) includes CUDA libraries.",
 				},
-				Category:   "ai",
-				Confidence: 80,
 			})
+			// MIG hides the whole GPU from CUDA; that, not the driver, is
+			// the likely cause when it applies
+			if notes := migVisibilityNotes(report); len(notes) > 0 {
+				f.Evidence += " " + strings.Join(notes, " ")
+				f.NextSteps = append([]string{
+					"List MIG instances with 'nvidia-smi -L' and set CUDA_VISIBLE_DEVICES to a MIG-<uuid>, or create instances if there are none.",
+				}, f.NextSteps...)
+			}
+			findings = append(findings, f)
 		}
 	} else {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "PyTorch CUDA is Working",
-			Evidence:     fmt.Sprintf("PyTorch %s with CUDA %s. GPU: %s.", pt.Version, pt.CUDAVersion, pt.DeviceName),
+		evidence := fmt.Sprintf("PyTorch %s with CUDA %s. GPU: %s.", pt.Version, pt.CUDAVersion, pt.DeviceName)
+		if inst := migSlice(report, pt.DeviceName); inst != nil {
+			evidence += fmt.Sprintf(" This is MIG slice %s (%d MB, %d SMs); a CUDA process uses one MIG instance, not the whole GPU.",
+				inst.Profile, inst.MemoryTotalMB, inst.SMCount)
+		}
+		findings = append(findings, fromRule("pytorch-cuda-ok", types.Finding{
+			Evidence:     evidence,
 			WhyItMatters: "GPU acceleration is available for PyTorch workloads.",
 			NextSteps:    []string{"No action needed."},
-			Category:     "ai",
-			Confidence:   95,
-		})
+		}))
+	}
+
+	if pt.CUDAVersion != "" {
+		findings = append(findings, pytorchArchSupport(report, pt)...)
 	}
 
 	return findings
 }
 
+// minCUDAForCapability is the first CUDA release that can build kernels for
+// a compute capability. Older capabilities are covered by every CUDA 11+.
+var minCUDAForCapability = map[string]string{
+	"8.0":  "11.0",
+	"8.6":  "11.1",
+	"8.9":  "11.8",
+	"9.0":  "11.8",
+	"10.0": "12.8",
+	"12.0": "12.8",
+}
+
+// pytorchArchSupport flags GPUs whose compute capability is newer than the
+// CUDA version PyTorch was built with.
+func pytorchArchSupport(report *types.Report, pt *types.PyTorchInfo) []types.Finding {
+	var findings []types.Finding
+	for _, gpu := range report.GPUs {
+		need, ok := minCUDAForCapability[gpu.ComputeCapability]
+		if !gpu.IsNVIDIA || !ok || versionAtLeast(pt.CUDAVersion, need) {
+			continue
+		}
+		findings = append(findings, forGPU([]types.Finding{fromRule("pytorch-arch-unsupported", types.Finding{
+			Evidence: fmt.Sprintf("%s is %s (compute capability %s), which needs CUDA %s or newer; PyTorch %s was built with CUDA %s.",
+				gpuModel(gpu), gpu.Architecture, gpu.ComputeCapability, need, pt.Version, pt.CUDAVersion),
+			WhyItMatters: "A PyTorch build only ships kernels for the GPU architectures its CUDA version knows. On a newer GPU, torch.cuda.is_available() can still return True, but the first kernel launch fails with \"no kernel image is available for execution on the device\".",
+			NextSteps: []string{
+				"Reinstall PyTorch built for CUDA " + need + " or newer from https://pytorch.org/get-started/locally/",
+				"Check the architectures the build supports with: python -c \"import torch; print(torch.cuda.get_arch_list())\"",
+			},
+		})}, gpu.Index, gpu.PCIBusID, len(report.GPUs) > 1)...)
+	}
+	return findings
+}
+
 func analyzeTensorFlow(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
@@ -1097,25 +2046,19 @@ func analyzeTensorFlow(report *types.Report) []types.Finding {
 	tf := report.AI.TensorFlowInfo
 
 	if tf.Error != "" {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "TensorFlow Import Error",
+		findings = append(findings, fromRule("tensorflow-import-error", types.Finding{
 			Evidence:     fmt.Sprintf("Error: %s", tf.Error),
 			WhyItMatters: "TensorFlow could not be loaded properly.",
 			NextSteps: []string{
 				"Check your Python environment and TensorFlow installation.",
 				"Reinstall: pip install tensorflow[and-cuda]",
 			},
-			Category:   "ai",
-			Confidence: 90,
-		})
+		}))
 		return findings
 	}
 
 	if len(tf.GPUs) == 0 {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "TensorFlow Cannot See GPU",
+		findings = append(findings, fromRule("tensorflow-no-gpu", types.Finding{
 			Evidence:     fmt.Sprintf("TensorFlow %s detected no GPU devices.", tf.Version),
 			WhyItMatters: "TensorFlow will fall back to CPU-only execution, which is significantly slower for training.",
 			NextSteps: []string{
@@ -1124,19 +2067,13 @@ func analyzeTensorFlow(report *types.Report) []types.Finding {
 				"Verify nvidia-smi shows your GPU and driver is working.",
 				"See https://www.tensorflow.org/install/pip for compatibility matrix.",
 			},
-			Category:   "ai",
-			Confidence: 85,
-		})
+		}))
 	} else {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityInfo,
-			Title:        "TensorFlow GPU is Working",
+		findings = append(findings, fromRule("tensorflow-gpu-ok", types.Finding{
 			Evidence:     fmt.Sprintf("TensorFlow %s detected %d GPU(s): %s.", tf.Version, len(tf.GPUs), strings.Join(tf.GPUs, ", ")),
 			WhyItMatters: "GPU acceleration is available for TensorFlow workloads.",
 			NextSteps:    []string{"No action needed."},
-			Category:     "ai",
-			Confidence:   95,
-		})
+		}))
 	}
 
 	return findings
@@ -1152,9 +2089,7 @@ func analyzeWSL(report *types.Report) []types.Finding {
 	}
 
 	if !report.WSL.DevDxgExists {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityCrit,
-			Title:        "WSL2 GPU Device (/dev/dxg) Not Found",
+		findings = append(findings, fromRule("wsl-no-dxg", types.Finding{
 			Evidence:     "/dev/dxg does not exist in this WSL2 environment.",
 			WhyItMatters: "GPU acceleration in WSL2 requires /dev/dxg, which is provided by the Windows host driver. Without it, CUDA will not work in WSL.",
 			NextSteps: []string{
@@ -1163,15 +2098,11 @@ func analyzeWSL(report *types.Report) []types.Finding {
 				"Update WSL: wsl --update",
 				"Restart WSL: wsl --shutdown, then reopen.",
 			},
-			Category:   "wsl",
-			Confidence: 95,
-		})
+		}))
 	}
 
 	if report.WSL.DevDxgExists && !report.WSL.NvidiaSmiOK {
-		findings = append(findings, types.Finding{
-			Severity:     types.SeverityWarn,
-			Title:        "WSL2: /dev/dxg Exists but nvidia-smi Fails",
+		findings = append(findings, fromRule("wsl-dxg-smi-fail", types.Finding{
 			Evidence:     "/dev/dxg is present but nvidia-smi did not run successfully.",
 			WhyItMatters: "The GPU paravirtualization device exists but the NVIDIA tools may not be properly configured in the WSL2 guest.",
 			NextSteps: []string{
@@ -1179,9 +2110,7 @@ func analyzeWSL(report *types.Report) []types.Finding {
 				"Ensure nvidia-smi is available: it should be provided by the Windows driver.",
 				"Try: wsl --shutdown from Windows, then reopen WSL.",
 			},
-			Category:   "wsl",
-			Confidence: 80,
-		})
+		}))
 	}
 
 	return findings
@@ -1189,13 +2118,53 @@ func analyzeWSL(report *types.Report) []types.Finding {
 
 // ── VRAM ──────────────────────────────────────────────────────────────
 
+// vramNearlyFullPct is the share of VRAM in use, before the user's own job
+// starts, at which a GPU is reported as nearly full. zombieVRAMMB is the
+// memory a dead or orphaned process must hold to be worth reporting.
+const (
+	vramNearlyFullPct = 90
+	zombieVRAMMB      = 1024
+)
+
 func analyzeVRAM(report *types.Report) []types.Finding {
 	var findings []types.Finding
 
+	// A PID missing from /proc only means the process is gone if nvcheckup
+	// shares a PID namespace with the GPU's processes; in a container it
+	// sees none of them.
+	sharedPIDs := false
+	for _, gpu := range report.GPUs {
+		for _, p := range gpu.Processes {
+			sharedPIDs = sharedPIDs || (p.State != "" && p.State != "missing")
+		}
+	}
+
 	for _, gpu := range report.GPUs {
+		findings = append(findings, forGPU(analyzeGPUProcesses(gpu, sharedPIDs), gpu.Index, gpu.PCIBusID, len(report.GPUs) > 1)...)
+
+		// With MIG enabled no job gets the whole GPU; judge each slice
+		if gpu.MIG != nil && gpu.MIG.Enabled {
+			for _, inst := range gpu.MIG.Instances {
+				if inst.MemoryTotalMB > 0 && inst.MemoryTotalMB < 4096 {
+					slice := fmt.Sprintf("MIG device %d", inst.DeviceIndex)
+					if inst.Profile != "" {
+						slice += " (" + inst.Profile + ")"
+					}
+					findings = append(findings, fromRule("low-vram", types.Finding{
+						Title:        fmt.Sprintf("Low VRAM Detected: %s %s (%d MB)", gpu.Name, slice, inst.MemoryTotalMB),
+						Evidence:     fmt.Sprintf("%s on GPU %d has %d MB of the GPU's %d MB.", slice, gpu.Index, inst.MemoryTotalMB, gpu.VRAMTotalMB),
+						WhyItMatters: "A job on this MIG instance can only use the instance's memory, not the whole GPU's. Less than 4 GB limits which models fit.",
+						NextSteps: []string{
+							"Run memory-heavy jobs on a larger MIG profile, or on a GPU with MIG disabled.",
+							"Otherwise use smaller model variants, reduce batch sizes, or enable gradient checkpointing.",
+						},
+					}))
+				}
+			}
+			continue
+		}
 		if gpu.IsNVIDIA && gpu.VRAMTotalMB > 0 && gpu.VRAMTotalMB < 4096 {
-			findings = append(findings, types.Finding{
-				Severity:     types.SeverityInfo,
+			findings = append(findings, fromRule("low-vram", types.Finding{
 				Title:        fmt.Sprintf("Low VRAM Detected: %s (%d MB)", gpu.Name, gpu.VRAMTotalMB),
 				Evidence:     fmt.Sprintf("GPU %s has %d MB of VRAM.", gpu.Name, gpu.VRAMTotalMB),
 				WhyItMatters: "Less than 4 GB of VRAM may limit performance in modern games and prevent loading larger AI models.",
@@ -1203,26 +2172,314 @@ func analyzeVRAM(report *types.Report) []types.Finding {
 					"For AI workloads: use smaller model variants, reduce batch sizes, or enable gradient checkpointing.",
 					"For gaming: lower texture quality and resolution settings.",
 				},
-				Category:   "hardware",
-				Confidence: 90,
-			})
+			}))
+		}
+
+		if gpu.IsNVIDIA && gpu.VRAMTotalMB > 0 && gpu.VRAMUsedMB*100 >= gpu.VRAMTotalMB*vramNearlyFullPct {
+			evidence := fmt.Sprintf("%d of %d MB in use (%d%%) before any new job starts.",
+				gpu.VRAMUsedMB, gpu.VRAMTotalMB, gpu.VRAMUsedMB*100/gpu.VRAMTotalMB)
+			if holders := topVRAMHolders(gpu.Processes, 3); holders != "" {
+				evidence += " Largest holders: " + holders + "."
+			}
+			findings = append(findings, forGPU([]types.Finding{fromRule("gpu-vram-nearly-full", types.Finding{
+				Evidence:     evidence,
+				WhyItMatters: "A new CUDA job only gets the memory other processes leave free. Starting work on this GPU now is likely to fail with CUDA out-of-memory errors that have nothing to do with the job itself.",
+				NextSteps: []string{
+					"Check who holds the memory with 'nvidia-smi --query-compute-apps=pid,process_name,used_memory --format=csv'.",
+					"Stop finished notebooks, inference servers or stale training runs on this GPU.",
+					"Or point the job at another GPU with CUDA_VISIBLE_DEVICES.",
+				},
+			})}, gpu.Index, gpu.PCIBusID, len(report.GPUs) > 1)...)
 		}
 	}
 
 	return findings
 }
 
+// analyzeGPUProcesses flags dead processes still holding VRAM: zombies, and
+// PIDs that no longer exist at all when /proc is comparable.
+func analyzeGPUProcesses(gpu types.GPUInfo, sharedPIDs bool) []types.Finding {
+	var held []string
+	var total int64
+	for _, p := range gpu.Processes {
+		if p.UsedMemoryMB < zombieVRAMMB {
+			continue
+		}
+		var why string
+		switch {
+		case p.State == "Z" || p.State == "X":
+			why = "zombie"
+		case p.State == "missing" && sharedPIDs:
+			why = "no longer running"
+		default:
+			continue
+		}
+		held = append(held, fmt.Sprintf("%s (PID %d, %s) holds %d MB", processName(p.Name), p.PID, why, p.UsedMemoryMB))
+		total += p.UsedMemoryMB
+	}
+	if len(held) == 0 {
+		return nil
+	}
+	return []types.Finding{fromRule("gpu-process-zombie", types.Finding{
+		Evidence:     fmt.Sprintf("%s; %d MB in total.", strings.Join(held, "; "), total),
+		WhyItMatters: "The driver frees a process's GPU memory only once the process is fully gone. A zombie, or a context whose owner vanished, keeps that memory out of reach of every new job until it is cleaned up.",
+		NextSteps: []string{
+			"Find the parent of a zombie with 'ps -o ppid= -p <pid>' and stop or restart it so it reaps the child.",
+			"Check 'sudo fuser -v /dev/nvidia*' for other processes keeping the context open.",
+			"If the memory is still held, reset the GPU with 'sudo nvidia-smi -r -i <index>' or reboot.",
+		},
+	})}
+}
+
+// topVRAMHolders lists up to n processes using the most VRAM.
+func topVRAMHolders(procs []types.GPUProcess, n int) string {
+	sorted := make([]types.GPUProcess, 0, len(procs))
+	for _, p := range procs {
+		if p.UsedMemoryMB > 0 {
+			sorted = append(sorted, p)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].UsedMemoryMB > sorted[j].UsedMemoryMB })
+	var out []string
+	for i, p := range sorted {
+		if i == n {
+			break
+		}
+		out = append(out, fmt.Sprintf("%s (PID %d) %d MB", processName(p.Name), p.PID, p.UsedMemoryMB))
+	}
+	return strings.Join(out, ", ")
+}
+
+// processName shortens a process path to its executable name.
+func processName(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 && i < len(name)-1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// ── Privileges ────────────────────────────────────────────────────────
+
+// partialDataConfidencePct is the share of confidence kept by a finding whose
+// data came partly from checks that were skipped for lack of privileges.
+const partialDataConfidencePct = 60
+
+func analyzePrivileges(report *types.Report) []types.Finding {
+	if len(report.SkippedChecks) == 0 {
+		return nil
+	}
+
+	var checks []string
+	var unverified []string
+	seen := make(map[string]bool)
+	for _, s := range report.SkippedChecks {
+		checks = append(checks, fmt.Sprintf("%s (%s)", s.Check, s.Reason))
+		for _, id := range s.Affects {
+			if r, ok := pack.Rule(id); ok && !seen[id] {
+				seen[id] = true
+				unverified = append(unverified, r.Title)
+			}
+		}
+	}
+
+	evidence := "Skipped: " + strings.Join(checks, ", ") + "."
+	if len(unverified) > 0 {
+		evidence += " Not verified: " + strings.Join(unverified, "; ") + "."
+	}
+
+	return []types.Finding{fromRule("privileged-checks-skipped", types.Finding{
+		Evidence:     evidence,
+		WhyItMatters: "The absence of a finding for these checks does not mean the system is healthy — the data could not be read.",
+		NextSteps: []string{
+			"Linux: re-run with sudo for a complete report.",
+			"Windows: re-run from an Administrator terminal for a complete report.",
+		},
+	})}
+}
+
+// discountSkippedChecks lowers the confidence of findings whose rule relies on
+// data from a skipped check, and says so in the evidence.
+func discountSkippedChecks(findings []types.Finding, skipped []types.SkippedCheck) {
+	for i := range findings {
+		f := &findings[i]
+		for _, s := range skipped {
+			if !containsString(s.Affects, f.RuleID) {
+				continue
+			}
+			f.Confidence = f.Confidence * partialDataConfidencePct / 100
+			f.Evidence += fmt.Sprintf(" (Partial data: %s was skipped — %s.)", s.Check, s.Reason)
+			break
+		}
+	}
+}
+
 // ── Helpers ───────────────────────────────────────────────────────────
 
-func sortFindings(findings []types.Finding) {
-	sevOrder := map[types.Severity]int{
-		types.SeverityCrit: 0,
-		types.SeverityWarn: 1,
-		types.SeverityInfo: 2,
+// valueOr returns s, or fallback when s is empty.
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
 	}
+	return false
+}
+
+// gpuThermals returns the thermal data of each GPU. Reports written before
+// thermal data was kept per GPU only have the report-level reading.
+func gpuThermals(report *types.Report) []*types.ThermalInfo {
+	var out []*types.ThermalInfo
+	for _, g := range report.GPUs {
+		if g.Thermal != nil {
+			out = append(out, g.Thermal)
+		}
+	}
+	if len(out) == 0 && report.Thermal != nil {
+		out = append(out, report.Thermal)
+	}
+	return out
+}
+
+// linkThermal returns the thermal reading of the GPU that owns link p, or
+// the report-level reading for a report without per-GPU data.
+func linkThermal(report *types.Report, p *types.PCIeInfo) *types.ThermalInfo {
+	for _, g := range report.GPUs {
+		if g.PCIe == p {
+			return g.Thermal
+		}
+	}
+	return report.Thermal
+}
+
+// gpuPCIeLinks returns the PCIe link state of each GPU, falling back to the
+// report-level reading like gpuThermals.
+func gpuPCIeLinks(report *types.Report) []*types.PCIeInfo {
+	var out []*types.PCIeInfo
+	for _, g := range report.GPUs {
+		if g.PCIe != nil {
+			out = append(out, g.PCIe)
+		}
+	}
+	if len(out) == 0 && report.PCIe != nil {
+		out = append(out, report.PCIe)
+	}
+	return out
+}
+
+// forGPU names the GPU a finding is about: the evidence always starts with
+// its index and bus ID, and on multi-GPU machines the title does too, so
+// the top-issues list tells the cards apart.
+func forGPU(findings []types.Finding, index int, busID string, multiGPU bool) []types.Finding {
+	label := fmt.Sprintf("GPU %d", index)
+	if busID != "" {
+		label += fmt.Sprintf(" (%s)", busID)
+	}
+	for i := range findings {
+		findings[i].Evidence = label + ": " + findings[i].Evidence
+		if multiGPU {
+			findings[i].Title = fmt.Sprintf("%s — GPU %d", findings[i].Title, index)
+		}
+	}
+	return findings
+}
+
+// fromRule fills in the rule ID and any title, category, severity or
+// confidence the caller left unset from the knowledge pack rule.
+func fromRule(id string, f types.Finding) types.Finding {
+	f.RuleID = id
+	r, ok := pack.Rule(id)
+	if !ok {
+		return f
+	}
+	if f.Title == "" {
+		f.Title = r.Title
+	}
+	if f.Category == "" {
+		f.Category = r.Category
+	}
+	if f.Severity == "" {
+		f.Severity = r.Severity
+	}
+	if f.Confidence == 0 {
+		f.Confidence = r.BaseConfidence
+	}
+	return f
+}
+
+// escalate returns the rule severity for a measured value, applying the
+// rule's severity_escalation threshold if it has one.
+func escalate(id string, value float64) types.Severity {
+	r, ok := pack.Rule(id)
+	if !ok {
+		return types.SeverityWarn
+	}
+	return r.SeverityFor(value)
+}
+
+// ruleConfidence returns the base confidence of a rule, for checks that
+// adjust it based on evidence.
+func ruleConfidence(id string) int {
+	r, _ := pack.Rule(id)
+	return r.BaseConfidence
+}
+
+// attachRemediations links each finding to the remediation action whose
+// finding_ids list its rule, if that action targets the current platform and
+// this build implements it.
+func attachRemediations(findings []types.Finding, platform string) {
+	for i := range findings {
+		a, ok := pack.RemediationFor(findings[i].RuleID)
+		if !ok {
+			continue
+		}
+		if a.Platform != "all" && platform != "" && a.Platform != platform {
+			continue
+		}
+		// fix only lists actions this build can apply
+		if !remediate.Implemented(a.ID) {
+			continue
+		}
+		findings[i].Remediation = &a
+	}
+}
+
+// filterFindings drops findings whose rule is unknown or not enabled for the
+// given mode and platform.
+func filterFindings(findings []types.Finding, mode types.RunMode, platform string) []types.Finding {
+	var kept []types.Finding
+	for _, f := range findings {
+		r, ok := pack.Rule(f.RuleID)
+		if !ok || !r.AppliesTo(mode, platform) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
+
+// severityRank orders severities for sorting: CRIT < WARN < INFO.
+func severityRank(s types.Severity) int {
+	switch s {
+	case types.SeverityCrit:
+		return 0
+	case types.SeverityWarn:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func sortFindings(findings []types.Finding) {
 	for i := 0; i < len(findings); i++ {
 		for j := i + 1; j < len(findings); j++ {
-			if sevOrder[findings[j].Severity] < sevOrder[findings[i].Severity] {
+			if severityRank(findings[j].Severity) < severityRank(findings[i].Severity) {
 				findings[i], findings[j] = findings[j], findings[i]
 			}
 		}
@@ -1282,6 +2539,9 @@ func buildNextSteps(findings []types.Finding) []string {
 func buildSummaryBlock(report *types.Report) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("NVCheckup v%s | %s\n", report.Metadata.ToolVersion, report.Metadata.Timestamp.Format("2006-01-02 15:04:05")))
+	if report.Metadata.Incomplete {
+		sb.WriteString("PARTIAL REPORT: " + report.Metadata.IncompleteReason + "\n")
+	}
 	sb.WriteString(fmt.Sprintf("OS: %s %s", report.System.OSName, report.System.OSVersion))
 	if report.System.KernelVersion != "" {
 		sb.WriteString(fmt.Sprintf(" | Kernel: %s", report.System.KernelVersion))
@@ -1308,19 +2568,27 @@ func buildSummaryBlock(report *types.Report) string {
 		sb.WriteString("\n")
 	}
 
-	// Thermal summary
-	if report.Thermal != nil {
-		sb.WriteString(fmt.Sprintf("Temp: %d°C", report.Thermal.TemperatureC))
-		if report.Thermal.PowerState != "" {
-			sb.WriteString(fmt.Sprintf(" | P-State: %s", report.Thermal.PowerState))
+	// Thermal summary, one line per GPU on multi-GPU machines
+	thermals := gpuThermals(report)
+	for _, t := range thermals {
+		if len(thermals) > 1 {
+			sb.WriteString(fmt.Sprintf("GPU %d ", t.GPUIndex))
+		}
+		sb.WriteString(fmt.Sprintf("Temp: %d°C", t.TemperatureC))
+		if t.PowerState != "" {
+			sb.WriteString(fmt.Sprintf(" | P-State: %s", t.PowerState))
 		}
 		sb.WriteString("\n")
 	}
 
 	// PCIe summary
-	if report.PCIe != nil {
-		sb.WriteString(fmt.Sprintf("PCIe: %s %s", report.PCIe.CurrentSpeed, report.PCIe.CurrentWidth))
-		if report.PCIe.Downshifted {
+	links := gpuPCIeLinks(report)
+	for _, p := range links {
+		if len(links) > 1 {
+			sb.WriteString(fmt.Sprintf("GPU %d ", p.GPUIndex))
+		}
+		sb.WriteString(fmt.Sprintf("PCIe: %s %s", p.CurrentSpeed, p.CurrentWidth))
+		if p.Downshifted {
 			sb.WriteString(" (DOWNSHIFTED)")
 		}
 		sb.WriteString("\n")
@@ -1348,6 +2616,32 @@ func buildSummaryBlock(report *types.Report) string {
 	return sb.String()
 }
 
+// versionAtLeast reports whether a dotted version such as "11.8" is at least
+// min, comparing numerically component by component.
+func versionAtLeast(version, min string) bool {
+	v, m := strings.Split(version, "."), strings.Split(min, ".")
+	for i := range m {
+		var a, b int
+		if i < len(v) {
+			a, _ = strconv.Atoi(v[i])
+		}
+		b, _ = strconv.Atoi(m[i])
+		if a != b {
+			return a > b
+		}
+	}
+	return true
+}
+
+// gpuModel names a GPU by its marketing name when the PCI ID table knows it,
+// since lspci names look like "GA102 [GeForce RTX 3080]".
+func gpuModel(gpu types.GPUInfo) string {
+	if gpu.MarketingName != "" {
+		return gpu.MarketingName
+	}
+	return gpu.Name
+}
+
 func majorVersion(version string) string {
 	parts := strings.Split(version, ".")
 	if len(parts) >= 1 {
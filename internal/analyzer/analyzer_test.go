@@ -1,8 +1,11 @@
 package analyzer
 
 import (
+	"runtime"
+	"strings"
 	"testing"
 
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
@@ -56,6 +59,75 @@ func TestAnalyzeGPUPresence_HybridDetected(t *testing.T) {
 	}
 }
 
+func TestAnalyzeDriverBasics_LegacyBranch(t *testing.T) {
+	k80 := types.GPUInfo{
+		Name: "GK210GL [Tesla K80]", Vendor: "NVIDIA", IsNVIDIA: true, PCIDeviceID: "102d",
+		MarketingName: "Tesla K80", Architecture: "Kepler", LastDriverBranch: "470",
+	}
+	report := &types.Report{
+		GPUs:   []types.GPUInfo{k80},
+		Driver: types.DriverInfo{NvidiaSmiPath: "nvidia-smi"},
+		Linux: &types.LinuxInfo{
+			NVIDIAPackages: []string{"nvidia-container-toolkit 1.14.3-1", "nvidia-driver-550 550.54.14-0ubuntu1"},
+			LoadedModules:  map[string]bool{},
+		},
+	}
+
+	var legacy *types.Finding
+	findings := analyzeDriverBasics(report)
+	for i := range findings {
+		if findings[i].RuleID == "gpu-legacy-driver-branch" {
+			legacy = &findings[i]
+		}
+	}
+	if legacy == nil {
+		t.Fatalf("expected gpu-legacy-driver-branch, got %+v", findings)
+	}
+	if !strings.Contains(legacy.Evidence, "Tesla K80 (Kepler) is supported up to the R470 driver branch") ||
+		!strings.Contains(legacy.Evidence, "550.54.14 (package nvidia-driver-550 550.54.14-0ubuntu1)") ||
+		!strings.Contains(legacy.Evidence, "nvidia kernel module is not loaded") {
+		t.Errorf("unexpected evidence: %s", legacy.Evidence)
+	}
+	if !strings.Contains(legacy.NextSteps[0], "470.xx legacy branch") {
+		t.Errorf("expected the legacy branch in the next steps, got %v", legacy.NextSteps)
+	}
+
+	// The 470 branch itself still drives the card
+	report.Driver.Version = "470.256.02"
+	for _, f := range analyzeDriverBasics(report) {
+		if f.RuleID == "gpu-legacy-driver-branch" {
+			t.Errorf("did not expect a legacy finding on R470, got %+v", f)
+		}
+	}
+}
+
+func TestWindowsDriverVersion(t *testing.T) {
+	tests := map[string]string{
+		"31.0.15.5222":  "552.22",
+		"30.0.14.7247":  "472.47",
+		"27.21.14.5671": "456.71",
+		"10.0":          "",
+	}
+	for in, want := range tests {
+		if got := windowsDriverVersion(in); got != want {
+			t.Errorf("windowsDriverVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnalyzeGPUPresence_HybridLaptop(t *testing.T) {
+	report := &types.Report{
+		GPUs: []types.GPUInfo{
+			{Name: "GA106M [GeForce RTX 3060 Mobile / Max-Q]", Vendor: "NVIDIA", IsNVIDIA: true, MarketingName: "GeForce RTX 3060 Laptop GPU", Class: "laptop"},
+			{Name: "Intel UHD 770", Vendor: "Intel"},
+		},
+	}
+	findings := analyzeGPUPresence(report)
+	if len(findings) != 1 || !strings.Contains(findings[0].Evidence, "The GeForce RTX 3060 Laptop GPU is a laptop GPU") {
+		t.Errorf("expected hybrid finding naming the laptop GPU, got %+v", findings)
+	}
+}
+
 func TestAnalyzeDriverBasics_NoDriver(t *testing.T) {
 	report := &types.Report{
 		Driver: types.DriverInfo{},
@@ -90,6 +162,24 @@ func TestAnalyzeDriverBasics_DriverPresent(t *testing.T) {
 	}
 }
 
+func TestAnalyzeDriverBasics_SmiCannotReachModule(t *testing.T) {
+	report := &types.Report{
+		Driver: types.DriverInfo{
+			Version:             "550.54.14",
+			KernelModuleVersion: "550.54.14",
+			NvidiaSmiPath:       "nvidia-smi",
+			Sources:             map[string]string{"version": "procfs", "kernel_module_version": "procfs"},
+		},
+	}
+	findings := analyzeDriverBasics(report)
+	if len(findings) != 1 || findings[0].RuleID != "nvidia-smi-driver-unreachable" || !strings.Contains(findings[0].Evidence, "kernel module 550.54.14") {
+		t.Fatalf("expected only nvidia-smi-driver-unreachable, got %+v", findings)
+	}
+	if version, source := installedDriverVersion(report); version != "550.54.14" || source != "loaded kernel module" {
+		t.Errorf("installedDriverVersion = %q, %q", version, source)
+	}
+}
+
 func TestAnalyzeWindowsGaming_DriverResets(t *testing.T) {
 	report := &types.Report{
 		Windows: &types.WindowsInfo{
@@ -201,6 +291,101 @@ func TestAnalyzeLinuxModules_Nouveau(t *testing.T) {
 	}
 }
 
+func TestAnalyzeNextBootKernel(t *testing.T) {
+	report := &types.Report{
+		Linux: &types.LinuxInfo{
+			LoadedModules: map[string]bool{"nvidia": true},
+			DKMSModules: []types.DKMSModule{
+				{Module: "nvidia", Version: "550.54.14", Kernel: "6.5.0-27-generic", Arch: "x86_64", State: "installed"},
+				{Module: "nvidia", Version: "550.54.14", Kernel: "6.8.0-31-generic", Arch: "x86_64", State: "built"},
+			},
+			Kernels: []types.KernelModules{
+				{Version: "6.5.0-27-generic", NVIDIAModule: "/lib/modules/6.5.0-27-generic/updates/dkms/nvidia.ko.zst"},
+				{Version: "6.8.0-31-generic"},
+			},
+			NextBootKernel: "6.8.0-31-generic",
+			NextBootSource: "/boot/grub/grub.cfg first entry",
+		},
+	}
+	findings := analyzeNextBootKernel(report)
+	if len(findings) != 1 || findings[0].RuleID != "next-boot-kernel-no-nvidia" || findings[0].Severity != types.SeverityWarn {
+		t.Fatalf("expected next-boot-kernel-no-nvidia, got %+v", findings)
+	}
+	for _, want := range []string{"kernel 6.8.0-31-generic (/boot/grub/grub.cfg first entry)", "built for: 6.5.0-27-generic", "nvidia 550.54.14: built"} {
+		if !strings.Contains(findings[0].Evidence, want) {
+			t.Errorf("evidence missing %q: %s", want, findings[0].Evidence)
+		}
+	}
+	if !strings.Contains(strings.Join(findings[0].NextSteps, " "), "dkms autoinstall -k 6.8.0-31-generic") {
+		t.Errorf("expected a DKMS build step, got %v", findings[0].NextSteps)
+	}
+
+	report.Linux.DKMSErrors = "Error! Bad return status for module build on kernel: 6.8.0-31-generic (x86_64)"
+	evidence := ""
+	for _, f := range analyzeLinuxModules(report) {
+		if f.RuleID == "dkms-failure" {
+			evidence = f.Evidence
+		}
+	}
+	if !strings.Contains(evidence, "nvidia 550.54.14 for 6.8.0-31-generic: built") {
+		t.Errorf("expected dkms-failure to name the failing kernel, got %q", evidence)
+	}
+
+	report.Linux.Kernels[1].NVIDIAModule = "/lib/modules/6.8.0-31-generic/updates/dkms/nvidia.ko.zst"
+	if findings := analyzeNextBootKernel(report); len(findings) != 0 {
+		t.Errorf("expected no finding once the module is installed, got %+v", findings)
+	}
+
+	// Systems without the NVIDIA driver have nothing to lose
+	none := &types.Report{Linux: &types.LinuxInfo{
+		Kernels:        []types.KernelModules{{Version: "6.8.0-31-generic"}},
+		NextBootKernel: "6.8.0-31-generic",
+	}}
+	if findings := analyzeNextBootKernel(none); len(findings) != 0 {
+		t.Errorf("expected no finding without the NVIDIA driver, got %+v", findings)
+	}
+}
+
+func TestAnalyzeDriverVersions(t *testing.T) {
+	upgraded := &types.DriverVersions{
+		LoadedModule: "550.54.14",
+		NVML:         "550.67", NVMLPath: "/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.550.67",
+		LibCUDA: "550.67", LibCUDAPath: "/usr/lib/x86_64-linux-gnu/libcuda.so.550.67",
+		Package: "550.67", PackageName: "nvidia-driver-550",
+		Modinfo: "550.67",
+	}
+	report := &types.Report{
+		System: types.SystemInfo{KernelVersion: "6.5.0-27-generic"},
+		Linux:  &types.LinuxInfo{DriverVersions: upgraded},
+	}
+	findings := analyzeDriverVersions(report)
+	if len(findings) != 1 || findings[0].RuleID != "driver-reboot-required" || findings[0].Severity != types.SeverityCrit {
+		t.Fatalf("expected driver-reboot-required, got %+v", findings)
+	}
+	for _, want := range []string{"loaded kernel module: 550.54.14", "package nvidia-driver-550: 550.67", "modinfo nvidia (kernel 6.5.0-27-generic): 550.67"} {
+		if !strings.Contains(findings[0].Evidence, want) {
+			t.Errorf("evidence missing %q: %s", want, findings[0].Evidence)
+		}
+	}
+
+	// The libraries were upgraded but the module was not rebuilt
+	partial := *upgraded
+	partial.Modinfo = "550.54.14"
+	report.Linux.DriverVersions = &partial
+	if findings := analyzeDriverVersions(report); len(findings) != 1 || findings[0].RuleID != "driver-partial-upgrade" {
+		t.Errorf("expected driver-partial-upgrade, got %+v", findings)
+	}
+
+	// The loaded module from /proc/driver/nvidia counts when sysfs has none
+	consistent := *upgraded
+	consistent.LoadedModule = ""
+	report.Linux.DriverVersions = &consistent
+	report.Driver.KernelModuleVersion = "550.67"
+	if findings := analyzeDriverVersions(report); len(findings) != 0 {
+		t.Errorf("expected no findings when every version matches, got %+v", findings)
+	}
+}
+
 func TestAnalyzeSecureBoot_EnabledBlocking(t *testing.T) {
 	report := &types.Report{
 		Linux: &types.LinuxInfo{
@@ -268,6 +453,51 @@ func TestSortFindings(t *testing.T) {
 	}
 }
 
+func TestAnalyzePyTorch_ArchUnsupported(t *testing.T) {
+	report := &types.Report{
+		GPUs: []types.GPUInfo{{
+			Name: "NVIDIA GeForce RTX 5090", IsNVIDIA: true, MarketingName: "GeForce RTX 5090",
+			Architecture: "Blackwell", ComputeCapability: "12.0",
+		}},
+		AI: &types.AIInfo{PyTorchInfo: &types.PyTorchInfo{Version: "2.5.1", CUDAVersion: "12.4", CUDAAvailable: true}},
+	}
+	var unsupported *types.Finding
+	findings := analyzePyTorch(report)
+	for i := range findings {
+		if findings[i].RuleID == "pytorch-arch-unsupported" {
+			unsupported = &findings[i]
+		}
+	}
+	if unsupported == nil || !strings.Contains(unsupported.Evidence, "needs CUDA 12.8 or newer; PyTorch 2.5.1 was built with CUDA 12.4") {
+		t.Fatalf("expected pytorch-arch-unsupported, got %+v", findings)
+	}
+
+	report.AI.PyTorchInfo.CUDAVersion = "12.8"
+	for _, f := range analyzePyTorch(report) {
+		if f.RuleID == "pytorch-arch-unsupported" {
+			t.Errorf("a CUDA 12.8 build supports Blackwell, got %+v", f)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, min string
+		want         bool
+	}{
+		{"12.8", "12.8", true},
+		{"12.10", "12.8", true},
+		{"11.8", "12.8", false},
+		{"12", "11.8", true},
+		{"11", "11.1", false},
+	}
+	for _, tt := range tests {
+		if got := versionAtLeast(tt.version, tt.min); got != tt.want {
+			t.Errorf("versionAtLeast(%q, %q) = %v, want %v", tt.version, tt.min, got, tt.want)
+		}
+	}
+}
+
 func TestMajorVersion(t *testing.T) {
 	tests := []struct {
 		input string
@@ -309,3 +539,613 @@ func TestAnalyzeFullPipeline(t *testing.T) {
 		t.Error("expected at least one next step")
 	}
 }
+
+func TestAnalyze_ModeGating(t *testing.T) {
+	// nouveau-active is enabled for ai/full only (rules.json).
+	newReport := func() *types.Report {
+		return &types.Report{
+			Metadata: types.ReportMetadata{Platform: "linux"},
+			GPUs:     []types.GPUInfo{{Name: "RTX 4090", Vendor: "NVIDIA", IsNVIDIA: true}},
+			Driver:   types.DriverInfo{Version: "550.54", NvidiaSmiPath: "nvidia-smi"},
+			Linux:    &types.LinuxInfo{LoadedModules: map[string]bool{"nouveau": true}},
+		}
+	}
+	hasRule := func(r *types.Report, id string) bool {
+		for _, f := range r.Findings {
+			if f.RuleID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	rep := newReport()
+	Analyze(rep, types.ModeAI)
+	if !hasRule(rep, "nouveau-active") {
+		t.Error("expected nouveau-active in ai mode")
+	}
+
+	rep = newReport()
+	Analyze(rep, types.ModeStreaming)
+	if hasRule(rep, "nouveau-active") {
+		t.Error("nouveau-active should be gated out of streaming mode")
+	}
+
+	rep = newReport()
+	rep.Metadata.Platform = "windows"
+	Analyze(rep, types.ModeAI)
+	if hasRule(rep, "nouveau-active") {
+		t.Error("linux-only rule should not apply on windows")
+	}
+}
+
+func TestFromRule_FillsMetadata(t *testing.T) {
+	f := fromRule("pytorch-cpu-only", types.Finding{Evidence: "x"})
+	if f.Title == "" || f.Category == "" {
+		t.Error("expected title and category from rules.json")
+	}
+	if f.Severity != types.SeverityWarn || f.Confidence != 95 {
+		t.Errorf("got severity %s confidence %d, want WARN/95", f.Severity, f.Confidence)
+	}
+}
+
+func TestAnalyzeLinuxAdvanced_XidWorstSeverity(t *testing.T) {
+	report := &types.Report{
+		Linux: &types.LinuxInfo{
+			XidErrors: []types.XidError{
+				{Code: 8, Message: "GPU stopped processing", Severity: types.SeverityWarn, Count: 1},
+				{Code: 9999, Message: "Unrecognized Xid", Severity: types.SeverityWarn, Unrecognized: true, Count: 1},
+			},
+		},
+	}
+	findings := analyzeLinuxAdvanced(report)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(findings))
+	}
+	f := findings[0]
+	if f.Severity != types.SeverityWarn {
+		t.Errorf("expected WARN from worst code, got %s", f.Severity)
+	}
+	if !strings.Contains(f.Evidence, "unrecognized Xid code(s) 9999") {
+		t.Errorf("expected unrecognized note in evidence, got %q", f.Evidence)
+	}
+
+	report.Linux.XidErrors = append(report.Linux.XidErrors,
+		types.XidError{Code: 79, Message: "GPU has fallen off the bus", Severity: types.SeverityCrit, Count: 2})
+	findings = analyzeLinuxAdvanced(report)
+	if findings[0].Severity != types.SeverityCrit {
+		t.Errorf("expected CRIT once Xid 79 is present, got %s", findings[0].Severity)
+	}
+}
+
+func TestAnalyzeThermal_PerGPU(t *testing.T) {
+	report := &types.Report{
+		GPUs: []types.GPUInfo{
+			{Index: 0, PCIBusID: "00000000:01:00.0", IsNVIDIA: true,
+				Thermal: &types.ThermalInfo{GPUIndex: 0, PCIBusID: "00000000:01:00.0", TemperatureC: 55, FanSpeedPct: 40}},
+			{Index: 3, PCIBusID: "00000000:81:00.0", IsNVIDIA: true,
+				Thermal: &types.ThermalInfo{GPUIndex: 3, PCIBusID: "00000000:81:00.0", TemperatureC: 88, FanSpeedPct: 90, ThermalThrottle: true}},
+		},
+		// Report-level reading is the first GPU and must not be analyzed twice
+		Thermal: &types.ThermalInfo{GPUIndex: 0, PCIBusID: "00000000:01:00.0", TemperatureC: 55, FanSpeedPct: 40},
+	}
+	findings := analyzeThermal(report)
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 finding for the hot GPU, got %d: %+v", len(findings), findings)
+	}
+	f := findings[0]
+	if f.RuleID != "thermal-throttling" {
+		t.Errorf("expected thermal-throttling, got %s", f.RuleID)
+	}
+	if !strings.HasPrefix(f.Evidence, "GPU 3 (00000000:81:00.0): ") {
+		t.Errorf("expected evidence to name GPU 3 and its bus ID, got %q", f.Evidence)
+	}
+	if !strings.HasSuffix(f.Title, "GPU 3") {
+		t.Errorf("expected title to name GPU 3 on a multi-GPU machine, got %q", f.Title)
+	}
+}
+
+func TestAnalyzeThermal_DecodedCauses(t *testing.T) {
+	report := &types.Report{
+		Thermal: &types.ThermalInfo{
+			TemperatureC: 70, FanSpeedPct: 60, SlowdownActive: true,
+			PowerDrawW: "449.80", PowerLimitW: "450.00",
+			SlowdownReason:  "0x0000000000000084",
+			SlowdownReasons: []string{"sw_power_cap", "hw_power_brake_slowdown"},
+		},
+	}
+	findings := analyzeThermal(report)
+	rules := map[string]types.Finding{}
+	for _, f := range findings {
+		rules[f.RuleID] = f
+	}
+	if len(findings) != 2 {
+		t.Fatalf("expected one finding per cause, got %+v", findings)
+	}
+	brake, ok := rules["throttle-hw-power-brake"]
+	if !ok || brake.Severity != types.SeverityCrit || !strings.Contains(strings.Join(brake.NextSteps, " "), "PSU") {
+		t.Errorf("expected CRIT power-brake finding pointing at the PSU, got %+v", brake)
+	}
+	capped, ok := rules["throttle-sw-power-cap"]
+	if !ok || !strings.Contains(capped.Evidence, "449.80 W / 450.00 W limit") || capped.Severity != types.SeverityInfo {
+		t.Errorf("expected INFO power-cap finding with power readings, got %+v", capped)
+	}
+	if _, ok := rules["thermal-throttling"]; ok {
+		t.Error("generic thermal-throttling should not fire when the causes are known and not thermal")
+	}
+}
+
+func TestAnalyzeThermal_PowerCapLimit(t *testing.T) {
+	busy := &types.ThermalInfo{TemperatureC: 70, FanSpeedPct: 60, SlowdownActive: true, PowerState: "P0",
+		PowerDrawW: "349.60", PowerLimitW: "350.00", DefaultPowerLimitW: "350.00", SlowdownReasons: []string{"sw_power_cap"}}
+	findings := analyzeGPUThermal(busy)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityInfo || !strings.Contains(findings[0].Evidence, "is the default (350.00 W)") {
+		t.Errorf("expected INFO power cap at the default limit, got %+v", findings)
+	}
+
+	busy.PowerDrawW, busy.PowerLimitW = "249.70", "250.00"
+	findings = analyzeGPUThermal(busy)
+	if len(findings) != 1 || findings[0].Severity != types.SeverityWarn || !strings.Contains(findings[0].Evidence, "lowered from its default of 350.00 W") {
+		t.Errorf("expected WARN power cap with a lowered limit, got %+v", findings)
+	}
+}
+
+func TestAnalyzeThermal_LegacyBitmaskAndIdle(t *testing.T) {
+	// Saved reports only have the raw bitmask; 0x1 is GPU idle
+	report := &types.Report{
+		Thermal: &types.ThermalInfo{TemperatureC: 40, FanSpeedPct: 30, PowerState: "P8",
+			CurrentClockMHz: 210, MaxClockMHz: 2100, SlowdownReason: "0x0000000000000001"},
+	}
+	findings := analyzeThermal(report)
+	if len(findings) != 1 || findings[0].RuleID != "throttle-gpu-idle" {
+		t.Fatalf("expected only the idle finding (no stuck power state), got %+v", findings)
+	}
+}
+
+func TestAnalyzeThermal_OwnLimits(t *testing.T) {
+	// 80°C is fine for a laptop GPU that slows down at 102°C
+	laptop := &types.Report{Thermal: &types.ThermalInfo{TemperatureC: 80, FanSpeedPct: -1, SlowdownTempC: 102, ShutdownTempC: 105}}
+	if findings := analyzeThermal(laptop); len(findings) != 0 {
+		t.Errorf("expected no findings with 22°C of headroom, got %+v", findings)
+	}
+
+	// 78°C is hot for a card whose max operating temperature is 83°C
+	desktop := &types.Report{Thermal: &types.ThermalInfo{TemperatureC: 78, FanSpeedPct: 55, MaxOperatingTempC: 83, SlowdownTempC: 89, ShutdownTempC: 92}}
+	findings := analyzeThermal(desktop)
+	if len(findings) != 1 || findings[0].RuleID != "gpu-running-hot" {
+		t.Fatalf("expected gpu-running-hot, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "Headroom: 5°C below its max operating temperature (83°C)") {
+		t.Errorf("expected headroom in evidence, got %q", findings[0].Evidence)
+	}
+
+	// T.Limit margin wins when reported
+	margin := 0
+	atLimit := &types.Report{Thermal: &types.ThermalInfo{TemperatureC: 83, FanSpeedPct: 90, MaxOperatingTempC: 90, TLimitMarginC: &margin}}
+	findings = analyzeThermal(atLimit)
+	if len(findings) != 1 || findings[0].RuleID != "thermal-throttling" || !strings.Contains(findings[0].Evidence, "at its T.Limit") {
+		t.Errorf("expected thermal-throttling at the T.Limit, got %+v", findings)
+	}
+}
+
+func TestAnalyzePCIe_PerGPUAndLegacyReport(t *testing.T) {
+	report := &types.Report{
+		GPUs: []types.GPUInfo{
+			{Index: 0, PCIBusID: "00000000:01:00.0", IsNVIDIA: true,
+				PCIe: &types.PCIeInfo{GPUIndex: 0, PCIBusID: "00000000:01:00.0", CurrentSpeed: "Gen4", MaxSpeed: "Gen4", CurrentWidth: "x16", MaxWidth: "x16"}},
+			{Index: 1, PCIBusID: "00000000:41:00.0", IsNVIDIA: true,
+				PCIe: &types.PCIeInfo{GPUIndex: 1, PCIBusID: "00000000:41:00.0", CurrentSpeed: "Gen4", MaxSpeed: "Gen4", CurrentWidth: "x8", MaxWidth: "x16", Downshifted: true}},
+		},
+	}
+	findings := analyzePCIe(report)
+	if len(findings) != 1 || !strings.Contains(findings[0].Evidence, "GPU 1 (00000000:41:00.0)") {
+		t.Fatalf("expected one downshift finding for GPU 1, got %+v", findings)
+	}
+
+	// Reports saved before per-GPU data only have the report-level reading
+	legacy := &types.Report{PCIe: &types.PCIeInfo{CurrentSpeed: "Gen3", MaxSpeed: "Gen4", CurrentWidth: "x16", MaxWidth: "x16", Downshifted: true}}
+	findings = analyzePCIe(legacy)
+	if len(findings) != 1 || strings.Contains(findings[0].Title, "— GPU") {
+		t.Errorf("expected one untagged finding for a legacy report, got %+v", findings)
+	}
+}
+
+func TestAnalyzeGPUPCIe_SlotLimitVersusDownshift(t *testing.T) {
+	// Gen4 card in a Gen3 slot: the link runs at what the slot allows
+	p := &types.PCIeInfo{CurrentSpeed: "Gen3", MaxSpeed: "Gen4", CurrentWidth: "x16", MaxWidth: "x16", Downshifted: true,
+		Upstream: &types.PCIeLink{BDF: "0000:00:01.0", MaxSpeed: "Gen3", MaxWidth: "x16"}}
+	findings := analyzeGPUPCIe(p, nil)
+	if len(findings) != 1 || findings[0].RuleID != "pcie-slot-limited" || findings[0].Severity != types.SeverityInfo {
+		t.Fatalf("expected pcie-slot-limited, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "upstream port 0000:00:01.0 supports Gen3 x16") {
+		t.Errorf("unexpected evidence: %s", findings[0].Evidence)
+	}
+
+	// x8 in a slot that supports x16: the card side trained narrow
+	p = &types.PCIeInfo{CurrentSpeed: "Gen4", MaxSpeed: "Gen4", CurrentWidth: "x8", MaxWidth: "x16", Downshifted: true,
+		Upstream: &types.PCIeLink{BDF: "0000:00:01.0", MaxSpeed: "Gen4", MaxWidth: "x16"}}
+	findings = analyzeGPUPCIe(p, nil)
+	if len(findings) != 1 || findings[0].RuleID != "pcie-downshift" || !strings.Contains(findings[0].Evidence, "so the slot is not the limit") {
+		t.Errorf("expected pcie-downshift blaming the card side, got %+v", findings)
+	}
+}
+
+func TestAnalyzeGPUPCIe_Idle(t *testing.T) {
+	up := &types.PCIeLink{BDF: "0000:00:01.0", MaxSpeed: "Gen4", MaxWidth: "x16"}
+	idle := &types.ThermalInfo{PowerState: "P8", SlowdownReasons: []string{"gpu_idle"}}
+
+	// sysfs reads Gen1 on an idle GPU: power saving, not a training fault
+	p := &types.PCIeInfo{CurrentSpeed: "Gen1", MaxSpeed: "Gen4", CurrentWidth: "x16", MaxWidth: "x16", Downshifted: true, Upstream: up}
+	findings := analyzeGPUPCIe(p, idle)
+	if len(findings) != 1 || findings[0].RuleID != "pcie-downshift" || findings[0].Severity != types.SeverityInfo {
+		t.Fatalf("expected one INFO pcie-downshift at idle, got %+v", findings)
+	}
+	if strings.Contains(findings[0].Evidence, "trained below") || !strings.Contains(findings[0].Evidence, "idle (power state P8)") {
+		t.Errorf("unexpected evidence: %s", findings[0].Evidence)
+	}
+
+	// The same reading under load is a link that trained low
+	busy := &types.ThermalInfo{PowerState: "P0"}
+	findings = analyzeGPUPCIe(p, busy)
+	if len(findings) != 2 || findings[0].RuleID != "pcie-downshift" || findings[0].Severity != types.SeverityWarn ||
+		!strings.Contains(findings[0].Evidence, "trained below") || findings[1].RuleID != "pcie-legacy-speed" {
+		t.Errorf("expected WARN pcie-downshift and pcie-legacy-speed under load, got %+v", findings)
+	}
+
+	// Without a load reading, a speed-only downshift is not blamed on training
+	findings = analyzeGPUPCIe(p, nil)
+	if len(findings) != 2 || findings[0].Severity != types.SeverityWarn || strings.Contains(findings[0].Evidence, "trained below") {
+		t.Errorf("expected WARN pcie-downshift without a training claim, got %+v", findings)
+	}
+
+	// A narrowed link is a fault even at idle
+	p = &types.PCIeInfo{CurrentSpeed: "Gen1", MaxSpeed: "Gen4", CurrentWidth: "x8", MaxWidth: "x16", Downshifted: true, Upstream: up}
+	findings = analyzeGPUPCIe(p, idle)
+	if len(findings) != 2 || findings[0].Severity != types.SeverityWarn || !strings.Contains(findings[0].Evidence, "trained below") {
+		t.Errorf("expected WARN pcie-downshift for a narrowed idle link, got %+v", findings)
+	}
+}
+
+func TestAnalyzeGPUPCIe_AER(t *testing.T) {
+	p := &types.PCIeInfo{CurrentSpeed: "Gen4", MaxSpeed: "Gen4", CurrentWidth: "x16", MaxWidth: "x16",
+		AER:      &types.PCIeAER{Correctable: 15, NonFatal: 2},
+		Upstream: &types.PCIeLink{BDF: "0000:00:01.0", AER: &types.PCIeAER{Fatal: 1}}}
+	findings := analyzeGPUPCIe(p, nil)
+	if len(findings) != 1 || findings[0].RuleID != "pcie-aer-uncorrectable" || findings[0].Severity != types.SeverityCrit {
+		t.Fatalf("expected a CRIT pcie-aer-uncorrectable after a fatal error, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "GPU: 15 correctable, 2 non-fatal, 0 fatal; upstream port 0000:00:01.0: 0 correctable, 0 non-fatal, 1 fatal") {
+		t.Errorf("unexpected evidence: %s", findings[0].Evidence)
+	}
+
+	p.AER, p.Upstream = &types.PCIeAER{Correctable: 40}, nil
+	if findings := analyzeGPUPCIe(p, nil); len(findings) != 0 {
+		t.Errorf("a few correctable errors should not be reported, got %+v", findings)
+	}
+	p.AER.Correctable = 400
+	if findings := analyzeGPUPCIe(p, nil); len(findings) != 1 || findings[0].RuleID != "pcie-aer-correctable" {
+		t.Errorf("expected pcie-aer-correctable, got %+v", findings)
+	}
+}
+
+func TestAnalyzeReBAR(t *testing.T) {
+	enabled, disabled := true, false
+	report := &types.Report{
+		System: types.SystemInfo{BootMode: "Legacy/BIOS"},
+		GPUs: []types.GPUInfo{
+			{Index: 0, PCIBusID: "00000000:01:00.0", IsNVIDIA: true, MarketingName: "GeForce RTX 3080",
+				ReBAR: &types.ReBARInfo{Supported: true, Enabled: &disabled, BAR1SizeMB: 256, BAR1MaxMB: 16384, BAR1UsedMB: 240, Above4GDecoding: "disabled"}},
+			{Index: 1, PCIBusID: "00000000:02:00.0", IsNVIDIA: true,
+				ReBAR: &types.ReBARInfo{Supported: true, Enabled: &enabled, BAR1SizeMB: 16384}},
+			// Pre-Ampere cards have no ReBAR capability
+			{Index: 2, PCIBusID: "00000000:03:00.0", IsNVIDIA: true,
+				ReBAR: &types.ReBARInfo{BAR1SizeMB: 256}},
+			// resource1_resize exists but no BAR1 size could be read
+			{Index: 3, PCIBusID: "00000000:04:00.0", IsNVIDIA: true,
+				ReBAR: &types.ReBARInfo{Supported: true}},
+			// The capability without its current size, e.g. lspci denied
+			{Index: 4, PCIBusID: "00000000:05:00.0", IsNVIDIA: true,
+				ReBAR: &types.ReBARInfo{Supported: true, BAR1SizeMB: 256}},
+		},
+	}
+	findings := analyzeReBAR(report)
+	if len(findings) != 1 || findings[0].RuleID != "rebar-disabled" || !strings.Contains(findings[0].Title, "GPU 0") {
+		t.Fatalf("expected one rebar-disabled finding for GPU 0, got %+v", findings)
+	}
+	f := findings[0]
+	for _, want := range []string{"GeForce RTX 3080", "BAR1 is 256 MB of a possible 16 GB", "Above 4G Decoding is likely off", "240 of 256 MB"} {
+		if !strings.Contains(f.Evidence, want) {
+			t.Errorf("evidence missing %q: %s", want, f.Evidence)
+		}
+	}
+	if !strings.Contains(strings.Join(f.NextSteps, " "), "CSM disabled") {
+		t.Errorf("expected a UEFI boot step on a Legacy/BIOS system, got %v", f.NextSteps)
+	}
+}
+
+func TestAnalyzeMemoryHealth(t *testing.T) {
+	report := &types.Report{
+		GPUs: []types.GPUInfo{
+			{Index: 0, PCIBusID: "00000000:07:00.0", IsNVIDIA: true,
+				MemoryHealth: &types.MemoryHealthInfo{GPUIndex: 0, PCIBusID: "00000000:07:00.0", ECCSupported: true, ECCEnabled: true, ECCPendingEnabled: true,
+					RowRemapSupported: true, RemappedUncorrectable: 3, RemapPending: true, RemapHistogram: []int{637, 3, 0, 0, 0}}},
+			{Index: 1, PCIBusID: "00000000:0F:00.0", IsNVIDIA: true,
+				MemoryHealth: &types.MemoryHealthInfo{GPUIndex: 1, PCIBusID: "00000000:0F:00.0", ECCSupported: true, ECCEnabled: true, ECCPendingEnabled: true,
+					VolatileUncorrectable: 2, RowRemapSupported: true, RemappedUncorrectable: 640, RemapFailed: true, RemapHistogram: []int{0, 0, 0, 2, 638}}},
+		},
+		Linux: &types.LinuxInfo{XidErrors: []types.XidError{{Code: 64, Count: 1}, {Code: 79, Count: 1}}},
+	}
+	findings := analyzeMemoryHealth(report)
+	if len(findings) != 2 {
+		t.Fatalf("expected a reset finding for GPU 0 and an RMA finding for GPU 1, got %+v", findings)
+	}
+	reset, rma := findings[0], findings[1]
+	if reset.RuleID != "gpu-memory-reset-needed" || !strings.HasPrefix(reset.Evidence, "GPU 0 ") || !strings.Contains(reset.Evidence, "a row remap") {
+		t.Errorf("unexpected reset finding: %+v", reset)
+	}
+	if rma.RuleID != "gpu-memory-rma" || rma.Severity != types.SeverityCrit || !strings.HasSuffix(rma.Title, "GPU 1") {
+		t.Errorf("unexpected RMA finding: %+v", rma)
+	}
+	if !strings.Contains(rma.Evidence, "638 memory bank(s) have no spare rows") || !strings.Contains(rma.Evidence, "Xid 64 x1") || strings.Contains(rma.Evidence, "Xid 79") {
+		t.Errorf("expected bank and memory Xid evidence only, got %q", rma.Evidence)
+	}
+}
+
+func TestAnalyzeMemoryHealth_RetiredPages(t *testing.T) {
+	m := &types.MemoryHealthInfo{ECCSupported: true, ECCEnabled: true, ECCPendingEnabled: true, VolatileUncorrectable: 1,
+		RetiredPagesSupported: true, RetiredSingleBit: 20, RetiredDoubleBit: 4}
+	report := &types.Report{GPUs: []types.GPUInfo{{IsNVIDIA: true, MemoryHealth: m}}}
+	findings := analyzeMemoryHealth(report)
+	if len(findings) != 1 || findings[0].RuleID != "ecc-uncorrectable-errors" {
+		t.Fatalf("expected only ecc-uncorrectable-errors below the retirement limit, got %+v", findings)
+	}
+
+	m.RetiredSingleBit = 58
+	m.RetirementPending = true
+	findings = analyzeMemoryHealth(report)
+	rules := map[string]bool{}
+	for _, f := range findings {
+		rules[f.RuleID] = true
+	}
+	if !rules["gpu-memory-rma"] || !rules["gpu-memory-reset-needed"] || rules["ecc-uncorrectable-errors"] {
+		t.Errorf("expected RMA and reset findings at 62 retired pages, got %+v", findings)
+	}
+}
+
+func TestAnalyzeCUDA_Topology(t *testing.T) {
+	report := &types.Report{
+		Topology: &types.TopologyInfo{
+			AllowedCPUs: "0-15",
+			GPUs: []types.GPUTopology{
+				{Index: 0, Paths: []string{"X", "NV4", "SYS"}, CPUAffinity: "0-15", NUMANode: 0,
+					NVLinks: []types.NVLinkState{{Link: 0, Up: true, SpeedGBs: 25}, {Link: 1, Up: true, SpeedGBs: 25}}},
+				{Index: 1, Paths: []string{"NV4", "X", "SYS"}, CPUAffinity: "0-15", NUMANode: 0,
+					NVLinks: []types.NVLinkState{{Link: 0, Up: true, SpeedGBs: 25}, {Link: 1}}},
+				{Index: 2, Paths: []string{"SYS", "SYS", "X"}, CPUAffinity: "16-31", NUMANode: 1},
+			},
+		},
+	}
+	rules := map[string]types.Finding{}
+	for _, f := range analyzeCUDA(report) {
+		rules[f.RuleID] = f
+	}
+	if len(rules) != 3 {
+		t.Fatalf("expected host-only, nvlink-down and affinity findings, got %+v", rules)
+	}
+	if f := rules["gpu-topology-host-only"]; f.Severity != types.SeverityWarn || !strings.Contains(f.Evidence, "GPU 2 (best peer path SYS)") || strings.Contains(f.Evidence, "GPU 0") {
+		t.Errorf("unexpected host-only finding: %+v", f)
+	}
+	if f := rules["nvlink-down"]; !strings.HasPrefix(f.Evidence, "GPU 1: NVLink 1 inactive (1 of 2 links up)") {
+		t.Errorf("unexpected nvlink-down evidence: %q", f.Evidence)
+	}
+	if f := rules["gpu-cpu-affinity-mismatch"]; !strings.Contains(f.Evidence, "CPUs 16-31, NUMA node 1") || !strings.HasSuffix(f.Title, "GPU 2") {
+		t.Errorf("unexpected affinity finding: %+v", f)
+	}
+
+	// Restricting the job to the NVLink pair leaves nothing to report but the down link
+	report.Topology.VisibleDevices = "0,1"
+	findings := analyzeCUDA(report)
+	if len(findings) != 1 || findings[0].RuleID != "nvlink-down" {
+		t.Errorf("expected only nvlink-down with CUDA_VISIBLE_DEVICES=0,1, got %+v", findings)
+	}
+}
+
+func TestAnalyzeCUDA_NoNVLinkFabric(t *testing.T) {
+	// Bridgeless GeForce cards list their links as inactive; that is not a fault
+	report := &types.Report{Topology: &types.TopologyInfo{GPUs: []types.GPUTopology{
+		{Index: 0, Paths: []string{"X", "PHB"}, NUMANode: -1, NVLinks: []types.NVLinkState{{Link: 0}, {Link: 1}}},
+		{Index: 1, Paths: []string{"PHB", "X"}, NUMANode: -1, NVLinks: []types.NVLinkState{{Link: 0}, {Link: 1}}},
+	}}}
+	findings := analyzeCUDA(report)
+	if len(findings) != 1 || findings[0].RuleID != "gpu-topology-host-only" || findings[0].Severity != types.SeverityInfo {
+		t.Errorf("expected only an INFO host-only finding for PHB peers, got %+v", findings)
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus := parseCPUList("0-3,8,10-11")
+	if len(cpus) != 7 || !cpus[3] || !cpus[8] || cpus[9] || !cpus[11] {
+		t.Errorf("parseCPUList = %v", cpus)
+	}
+	if len(parseCPUList("")) != 0 {
+		t.Error("expected an empty set for an empty list")
+	}
+}
+
+func migReport() *types.Report {
+	return &types.Report{
+		GPUs: []types.GPUInfo{
+			{Index: 0, Name: "NVIDIA H100 80GB HBM3", PCIBusID: "00000000:19:00.0", IsNVIDIA: true, VRAMTotalMB: 81559,
+				MIG: &types.MIGInfo{Enabled: true, PendingEnabled: true, Instances: []types.MIGInstance{
+					{DeviceIndex: 0, GPUInstanceID: 2, Profile: "3g.40gb", SMCount: 60, MemoryTotalMB: 40192},
+					{DeviceIndex: 1, GPUInstanceID: 9, Profile: "1g.10gb", SMCount: 16, MemoryTotalMB: 3968},
+				}}},
+			{Index: 1, Name: "NVIDIA H100 80GB HBM3", PCIBusID: "00000000:3B:00.0", IsNVIDIA: true, VRAMTotalMB: 81559,
+				MIG: &types.MIGInfo{PendingEnabled: true}},
+		},
+	}
+}
+
+func TestAnalyzeMIG(t *testing.T) {
+	report := migReport()
+	findings := analyzeMIG(report)
+	if len(findings) != 1 || findings[0].RuleID != "mig-reset-pending" || !strings.HasSuffix(findings[0].Title, "GPU 1") {
+		t.Fatalf("expected mig-reset-pending for GPU 1, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "disabled now and enabled after the next GPU reset") {
+		t.Errorf("unexpected evidence %q", findings[0].Evidence)
+	}
+
+	report.GPUs[0].MIG.Instances = nil
+	findings = analyzeMIG(report)
+	if len(findings) != 2 || findings[0].RuleID != "mig-no-instances" {
+		t.Errorf("expected mig-no-instances for GPU 0, got %+v", findings)
+	}
+}
+
+func TestAnalyzePyTorch_MIG(t *testing.T) {
+	report := migReport()
+	report.AI = &types.AIInfo{PyTorchInfo: &types.PyTorchInfo{Version: "2.3.0", CUDAVersion: "12.1", CUDAAvailable: true,
+		DeviceName: "NVIDIA H100 80GB HBM3 MIG 3g.40gb"}}
+	findings := analyzePyTorch(report)
+	if len(findings) != 1 || !strings.Contains(findings[0].Evidence, "MIG slice 3g.40gb (40192 MB, 60 SMs)") {
+		t.Errorf("expected the MIG slice in pytorch-cuda-ok evidence, got %+v", findings)
+	}
+
+	// CUDA_VISIBLE_DEVICES by GPU index hides MIG instances
+	report.AI.PyTorchInfo = &types.PyTorchInfo{Version: "2.3.0", CUDAVersion: "12.1"}
+	report.Topology = &types.TopologyInfo{VisibleDevices: "0"}
+	findings = analyzePyTorch(report)
+	if len(findings) != 1 || findings[0].RuleID != "pytorch-cuda-no-gpu" {
+		t.Fatalf("expected pytorch-cuda-no-gpu, got %+v", findings)
+	}
+	if !strings.Contains(findings[0].Evidence, "names GPU 0 by index, but it is in MIG mode") || !strings.Contains(findings[0].NextSteps[0], "MIG-<uuid>") {
+		t.Errorf("expected MIG visibility notes first, got %+v", findings[0])
+	}
+}
+
+func TestAnalyzeVRAM_MIGSlices(t *testing.T) {
+	findings := analyzeVRAM(migReport())
+	if len(findings) != 1 || !strings.Contains(findings[0].Title, "MIG device 1 (1g.10gb) (3968 MB)") {
+		t.Errorf("expected one low-vram finding for the small slice only, got %+v", findings)
+	}
+}
+
+func TestAnalyzeVRAM_NearlyFullAndZombies(t *testing.T) {
+	report := &types.Report{GPUs: []types.GPUInfo{{
+		Index: 0, PCIBusID: "00000000:01:00.0", IsNVIDIA: true,
+		VRAMTotalMB: 24564, VRAMUsedMB: 23100,
+		Processes: []types.GPUProcess{
+			{PID: 2214, Name: "/usr/lib/xorg/Xorg", Type: "G", UsedMemoryMB: 312, State: "S"},
+			{PID: 48311, Name: "/home/[REDACTED]/venv/bin/python3", Type: "C", UsedMemoryMB: 17850, State: "Z"},
+			{PID: 50120, Name: "ollama", Type: "C", UsedMemoryMB: 4900, State: "missing"},
+		},
+	}}}
+
+	var full, zombie *types.Finding
+	findings := analyzeVRAM(report)
+	for i := range findings {
+		switch findings[i].RuleID {
+		case "gpu-vram-nearly-full":
+			full = &findings[i]
+		case "gpu-process-zombie":
+			zombie = &findings[i]
+		}
+	}
+	if full == nil || !strings.Contains(full.Evidence, "(94%)") || !strings.Contains(full.Evidence, "python3 (PID 48311) 17850 MB, ollama (PID 50120) 4900 MB, Xorg (PID 2214) 312 MB") {
+		t.Errorf("expected gpu-vram-nearly-full with the top holders, got %+v", full)
+	}
+	if zombie == nil || !strings.Contains(zombie.Evidence, "python3 (PID 48311, zombie) holds 17850 MB") ||
+		!strings.Contains(zombie.Evidence, "ollama (PID 50120, no longer running)") || !strings.Contains(zombie.Evidence, "22750 MB in total") {
+		t.Errorf("expected gpu-process-zombie for both dead holders, got %+v", zombie)
+	}
+
+	// Inside a container /proc shows none of the GPU's processes, so missing
+	// PIDs say nothing about whether they are still running.
+	for i := range report.GPUs[0].Processes {
+		report.GPUs[0].Processes[i].State = "missing"
+	}
+	report.GPUs[0].VRAMUsedMB = 2000
+	if findings := analyzeVRAM(report); len(findings) != 0 {
+		t.Errorf("expected no findings without a shared PID namespace, got %+v", findings)
+	}
+}
+
+func TestAnalyze_AttachesRemediation(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("blacklist-nouveau is only implemented on Linux")
+	}
+	report := &types.Report{
+		Metadata: types.ReportMetadata{Platform: "linux"},
+		GPUs:     []types.GPUInfo{{Name: "RTX 4090", Vendor: "NVIDIA", IsNVIDIA: true}},
+		Driver:   types.DriverInfo{Version: "550.54", NvidiaSmiPath: "nvidia-smi"},
+		Linux:    &types.LinuxInfo{LoadedModules: map[string]bool{"nouveau": true}},
+	}
+	Analyze(report, types.ModeAI)
+	for _, f := range report.Findings {
+		if f.RuleID != "nouveau-active" {
+			continue
+		}
+		if f.Remediation == nil || f.Remediation.ID != "blacklist-nouveau" {
+			t.Errorf("expected blacklist-nouveau remediation, got %+v", f.Remediation)
+		}
+		return
+	}
+	t.Error("expected nouveau-active finding")
+}
+
+func TestAttachRemediations_SkipsUnimplemented(t *testing.T) {
+	custom := *knowledge.Default()
+	custom.Remediations = []types.RemediationAction{
+		{ID: "reflash-vbios", Platform: "all", FindingIDs: []string{"nouveau-active"}},
+	}
+	saved := pack
+	pack = &custom
+	defer func() { pack = saved }()
+
+	findings := []types.Finding{{RuleID: "nouveau-active"}}
+	attachRemediations(findings, "linux")
+	if findings[0].Remediation != nil {
+		t.Errorf("expected no remediation for an action without an implementation, got %+v", findings[0].Remediation)
+	}
+}
+
+func TestAnalyze_SkippedChecksLowerConfidence(t *testing.T) {
+	report := &types.Report{
+		Metadata: types.ReportMetadata{Platform: "linux"},
+		GPUs:     []types.GPUInfo{{Name: "RTX 4090", Vendor: "NVIDIA", IsNVIDIA: true}},
+		Driver:   types.DriverInfo{Version: "550.54", NvidiaSmiPath: "nvidia-smi"},
+		Linux: &types.LinuxInfo{
+			LoadedModules: map[string]bool{"nvidia": true},
+			XidErrors:     []types.XidError{{Code: 79, Message: "GPU has fallen off the bus", Severity: types.SeverityCrit, Count: 1}},
+		},
+		SkippedChecks: []types.SkippedCheck{
+			{Check: "linux.xid.dmesg", Needs: "admin", Reason: "--no-admin", Affects: []string{"xid-errors"}},
+		},
+	}
+	Analyze(report, types.ModeFull)
+
+	var xid, skipped *types.Finding
+	for i := range report.Findings {
+		switch report.Findings[i].RuleID {
+		case "xid-errors":
+			xid = &report.Findings[i]
+		case "privileged-checks-skipped":
+			skipped = &report.Findings[i]
+		}
+	}
+	if xid == nil || skipped == nil {
+		t.Fatalf("expected xid-errors and privileged-checks-skipped findings, got %+v", report.Findings)
+	}
+	base, _ := pack.Rule("xid-errors")
+	if xid.Confidence >= base.BaseConfidence {
+		t.Errorf("expected lowered confidence, got %d (base %d)", xid.Confidence, base.BaseConfidence)
+	}
+	if !strings.Contains(xid.Evidence, "linux.xid.dmesg was skipped") {
+		t.Errorf("expected a partial-data note, got %q", xid.Evidence)
+	}
+	if skipped.Severity != types.SeverityInfo || !strings.Contains(skipped.Evidence, "linux.xid.dmesg (--no-admin)") {
+		t.Errorf("unexpected skipped-checks finding: %+v", skipped)
+	}
+}
@@ -2,36 +2,39 @@
 package ai
 
 import (
+	"context"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // CollectAIInfo gathers AI framework and CUDA environment information.
-func CollectAIInfo(timeout int) (types.AIInfo, []types.CollectorError) {
+func CollectAIInfo(ctx context.Context, fsys sysroot.FS, timeout int) (types.AIInfo, []types.CollectorError) {
 	var info types.AIInfo
 	var errs []types.CollectorError
 
-	collectCUDAToolkit(&info, &errs, timeout)
-	collectCuDNN(&info, &errs, timeout)
-	collectPythonEnvs(&info, &errs, timeout)
-	collectConda(&info, &errs, timeout)
-	collectPyTorch(&info, &errs, timeout)
-	collectTensorFlow(&info, &errs, timeout)
-	collectKeyPackages(&info, &errs, timeout)
+	collectCUDAToolkit(ctx, fsys, &info, &errs, timeout)
+	collectCuDNN(ctx, fsys, &info, &errs, timeout)
+	collectPythonEnvs(ctx, &info, &errs, timeout)
+	collectConda(ctx, &info, &errs, timeout)
+	collectPyTorch(ctx, &info, &errs, timeout)
+	collectTensorFlow(ctx, &info, &errs, timeout)
+	collectKeyPackages(ctx, &info, &errs, timeout)
 
 	return info, errs
 }
 
-func collectCUDAToolkit(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+func collectCUDAToolkit(ctx context.Context, fsys sysroot.FS, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
 	// Check nvcc
 	if util.CommandExists("nvcc") {
-		r := util.RunCommand(timeout, "nvcc", "--version")
+		r := util.RunCommandContext(ctx, timeout, "nvcc", "--version")
 		if r.Err == nil {
 			info.NvccPath = "nvcc"
 			// Parse version: "Cuda compilation tools, release 12.2, V12.2.140"
@@ -44,7 +47,10 @@ func collectCUDAToolkit(info *types.AIInfo, errs *[]types.CollectorError, timeou
 
 	// On Linux, check /usr/local/cuda symlink
 	if runtime.GOOS == "linux" {
-		if target, err := os.Readlink("/usr/local/cuda"); err == nil {
+		if target, err := fsys.Readlink("/usr/local/cuda"); err == nil {
+			if !path.IsAbs(target) {
+				target = path.Join("/usr/local", target)
+			}
 			if info.CUDAToolkitVersion == "" {
 				// Extract version from path like /usr/local/cuda-12.2
 				re := regexp.MustCompile(`cuda[- ]?([\d.]+)`)
@@ -53,8 +59,8 @@ func collectCUDAToolkit(info *types.AIInfo, errs *[]types.CollectorError, timeou
 				}
 			}
 			if info.NvccPath == "" {
-				nvccPath := filepath.Join(target, "bin", "nvcc")
-				if _, err := os.Stat(nvccPath); err == nil {
+				nvccPath := path.Join(target, "bin", "nvcc")
+				if _, err := fsys.Stat(nvccPath); err == nil {
 					info.NvccPath = nvccPath
 				}
 			}
@@ -70,7 +76,7 @@ func collectCUDAToolkit(info *types.AIInfo, errs *[]types.CollectorError, timeou
 				if info.NvccPath == "" {
 					info.NvccPath = nvccPath
 				}
-				r := util.RunCommand(timeout, nvccPath, "--version")
+				r := util.RunCommandContext(ctx, timeout, nvccPath, "--version")
 				if r.Err == nil && info.CUDAToolkitVersion == "" {
 					re := regexp.MustCompile(`release\s+([\d.]+)`)
 					if m := re.FindStringSubmatch(r.Stdout); m != nil {
@@ -82,19 +88,19 @@ func collectCUDAToolkit(info *types.AIInfo, errs *[]types.CollectorError, timeou
 	}
 }
 
-func collectCuDNN(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+func collectCuDNN(ctx context.Context, fsys sysroot.FS, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
 	if runtime.GOOS == "linux" {
 		// Check for cuDNN header
-		for _, path := range []string{
+		for _, header := range []string{
 			"/usr/include/cudnn_version.h",
 			"/usr/local/cuda/include/cudnn_version.h",
 			"/usr/include/cudnn.h",
 			"/usr/local/cuda/include/cudnn.h",
 		} {
-			r := util.RunCommand(timeout, "sh", "-c", `grep -E "CUDNN_MAJOR|CUDNN_MINOR|CUDNN_PATCHLEVEL" `+path+` 2>/dev/null | head -3`)
-			if r.Err == nil && r.Stdout != "" {
+			lines := cudnnVersionLines(fsys, header)
+			if len(lines) > 0 {
 				major, minor, patch := "", "", ""
-				for _, line := range strings.Split(r.Stdout, "\n") {
+				for _, line := range lines {
 					if strings.Contains(line, "CUDNN_MAJOR") && !strings.Contains(line, "MINOR") && !strings.Contains(line, "PATCH") {
 						parts := strings.Fields(line)
 						if len(parts) >= 3 {
@@ -130,7 +136,7 @@ func collectCuDNN(info *types.AIInfo, errs *[]types.CollectorError, timeout int)
 		cudaPath := os.Getenv("CUDA_PATH")
 		if cudaPath != "" {
 			headerPath := filepath.Join(cudaPath, "include", "cudnn_version.h")
-			r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+			r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 				`Select-String -Path "`+headerPath+`" -Pattern "CUDNN_MAJOR|CUDNN_MINOR|CUDNN_PATCHLEVEL" -ErrorAction SilentlyContinue | ForEach-Object { $_.Line }`)
 			if r.Err == nil && r.Stdout != "" {
 				major, minor, patch := "", "", ""
@@ -162,7 +168,26 @@ func collectCuDNN(info *types.AIInfo, errs *[]types.CollectorError, timeout int)
 	}
 }
 
-func collectPythonEnvs(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+// cudnnVersionLines returns the first three CUDNN_MAJOR/MINOR/PATCHLEVEL lines
+// of a cuDNN header, or nil if it cannot be read.
+func cudnnVersionLines(fsys sysroot.FS, header string) []string {
+	data, err := fsys.ReadFile(header)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.Contains(line, "CUDNN_MAJOR") || strings.Contains(line, "CUDNN_MINOR") || strings.Contains(line, "CUDNN_PATCHLEVEL") {
+			lines = append(lines, line)
+			if len(lines) == 3 {
+				break
+			}
+		}
+	}
+	return lines
+}
+
+func collectPythonEnvs(ctx context.Context, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
 	pythonCmds := []string{"python3", "python"}
 	if runtime.GOOS == "windows" {
 		pythonCmds = []string{"python", "python3", "py"}
@@ -173,7 +198,7 @@ func collectPythonEnvs(info *types.AIInfo, errs *[]types.CollectorError, timeout
 		if !util.CommandExists(cmd) {
 			continue
 		}
-		r := util.RunCommand(timeout, cmd, "--version")
+		r := util.RunCommandContext(ctx, timeout, cmd, "--version")
 		if r.Err == nil {
 			version := strings.TrimSpace(r.Stdout + r.Stderr) // Python 2 outputs to stderr
 			version = strings.TrimPrefix(version, "Python ")
@@ -187,7 +212,7 @@ func collectPythonEnvs(info *types.AIInfo, errs *[]types.CollectorError, timeout
 				} else {
 					pathCmd = "which"
 				}
-				rPath := util.RunCommand(timeout, pathCmd, cmd)
+				rPath := util.RunCommandContext(ctx, timeout, pathCmd, cmd)
 				path := strings.TrimSpace(rPath.Stdout)
 				if path != "" {
 					// Take first line only (where on Windows can return multiple)
@@ -203,13 +228,13 @@ func collectPythonEnvs(info *types.AIInfo, errs *[]types.CollectorError, timeout
 	}
 }
 
-func collectConda(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+func collectConda(ctx context.Context, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
 	info.CondaPresent = util.CommandExists("conda")
 }
 
-func collectPyTorch(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+func collectPyTorch(ctx context.Context, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
 	// Find a working python
-	pythonCmd := findPython(timeout)
+	pythonCmd := findPython(ctx, timeout)
 	if pythonCmd == "" {
 		return
 	}
@@ -236,7 +261,7 @@ except Exception as e:
     print(json.dumps({"error": str(e)}))
 `
 
-	r := util.RunCommand(timeout, pythonCmd, "-c", script)
+	r := util.RunCommandContext(ctx, timeout, pythonCmd, "-c", script)
 	if r.Err == nil && r.Stdout != "" {
 		ptInfo := &types.PyTorchInfo{}
 		stdout := strings.TrimSpace(r.Stdout)
@@ -258,8 +283,8 @@ except Exception as e:
 	}
 }
 
-func collectTensorFlow(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
-	pythonCmd := findPython(timeout)
+func collectTensorFlow(ctx context.Context, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+	pythonCmd := findPython(ctx, timeout)
 	if pythonCmd == "" {
 		return
 	}
@@ -281,7 +306,7 @@ except Exception as e:
     print(json.dumps({"error": str(e)}))
 `
 
-	r := util.RunCommand(timeout+10, pythonCmd, "-c", script) // TF import can be slow
+	r := util.RunCommandContext(ctx, timeout+10, pythonCmd, "-c", script) // TF import can be slow
 	if r.Err == nil && r.Stdout != "" {
 		tfInfo := &types.TFInfo{}
 		stdout := strings.TrimSpace(r.Stdout)
@@ -306,8 +331,8 @@ except Exception as e:
 	}
 }
 
-func collectKeyPackages(info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
-	pythonCmd := findPython(timeout)
+func collectKeyPackages(ctx context.Context, info *types.AIInfo, errs *[]types.CollectorError, timeout int) {
+	pythonCmd := findPython(ctx, timeout)
 	if pythonCmd == "" {
 		return
 	}
@@ -324,7 +349,7 @@ for pkg in ["torch", "tensorflow", "jax", "onnxruntime", "transformers", "numpy"
 print(json.dumps(packages))
 `
 
-	r := util.RunCommand(timeout, pythonCmd, "-c", script)
+	r := util.RunCommandContext(ctx, timeout, pythonCmd, "-c", script)
 	if r.Err == nil && r.Stdout != "" {
 		// Parse key=value pairs from JSON
 		stdout := strings.TrimSpace(r.Stdout)
@@ -339,7 +364,7 @@ print(json.dumps(packages))
 	}
 }
 
-func findPython(timeout int) string {
+func findPython(ctx context.Context, timeout int) string {
 	candidates := []string{"python3", "python"}
 	if runtime.GOOS == "windows" {
 		candidates = []string{"python", "python3", "py"}
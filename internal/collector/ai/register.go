@@ -0,0 +1,20 @@
+package ai
+
+import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func init() {
+	collector.Register(collector.Spec{
+		ID:       "ai",
+		RunModes: []types.RunMode{types.ModeAI, types.ModeCreator, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := CollectAIInfo(ctx, env.FS, env.Config.Timeout)
+			r.AI = &info
+			return errs
+		},
+	})
+}
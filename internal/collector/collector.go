@@ -0,0 +1,279 @@
+// Package collector defines the Collector interface and the registry that the
+// core pipeline iterates. Collector packages (common, linux, windows, ai, wsl)
+// register themselves from init functions, so adding a collector does not
+// require editing the orchestrator.
+package collector
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// Privilege describes the access level a check needs to produce useful data.
+type Privilege int
+
+const (
+	// PrivilegeNone means the check works as an unprivileged user.
+	PrivilegeNone Privilege = iota
+	// PrivilegeAdmin means the check needs root/Administrator.
+	PrivilegeAdmin
+)
+
+// String returns the privilege name used in flags and reports.
+func (p Privilege) String() string {
+	if p == PrivilegeAdmin {
+		return "admin"
+	}
+	return "none"
+}
+
+// Env carries run-wide inputs to collectors.
+type Env struct {
+	Config    types.RunConfig
+	Knowledge *knowledge.Pack
+	FS        sysroot.FS   // files of the system being diagnosed (--sysroot)
+	Gate      *Gate        // privileged checks allowed under --no-admin
+	SMI       *nvsmi.Cache // the run's single nvidia-smi -q -x query
+}
+
+// Collector gathers one area of diagnostic data into the report.
+type Collector interface {
+	// Name is the unique identifier used by --only/--skip.
+	Name() string
+	// Platforms lists the GOOS values the collector supports; empty means all.
+	Platforms() []string
+	// Modes lists the run modes the collector applies to; empty means all.
+	Modes() []types.RunMode
+	// Privileges is the access level the collector needs for complete data.
+	// Its privileged checks go through the Gate individually, so under
+	// --no-admin the collector still runs without them.
+	Privileges() Privilege
+	// Collect fills its part of the report. Failures are returned as
+	// CollectorErrors rather than aborting the run. ctx is cancelled on
+	// Ctrl-C or when the run budget expires.
+	Collect(ctx context.Context, env *Env, r *types.Report) []types.CollectorError
+}
+
+// Dependent is implemented by collectors that must run after others, e.g.
+// because they add to a section another collector creates.
+type Dependent interface {
+	After() []string
+}
+
+// Spec is a Collector assembled from plain values, which covers every
+// built-in collector.
+type Spec struct {
+	ID       string
+	OS       []string
+	RunModes []types.RunMode
+	Deps     []string
+	Checks   []Check // privileged checks the collector gates
+	Fn       func(ctx context.Context, env *Env, r *types.Report) []types.CollectorError
+}
+
+func (s Spec) Name() string           { return s.ID }
+func (s Spec) Platforms() []string    { return s.OS }
+func (s Spec) Modes() []types.RunMode { return s.RunModes }
+func (s Spec) After() []string        { return s.Deps }
+
+// Privileges is the highest privilege any of the collector's checks needs.
+func (s Spec) Privileges() Privilege {
+	p := PrivilegeNone
+	for _, c := range s.Checks {
+		if c.Needs > p {
+			p = c.Needs
+		}
+	}
+	return p
+}
+
+func (s Spec) Collect(ctx context.Context, env *Env, r *types.Report) []types.CollectorError {
+	return s.Fn(ctx, env, r)
+}
+
+var (
+	mu       sync.Mutex
+	registry []Collector
+)
+
+// Register adds a collector to the registry. It panics on a duplicate name,
+// which is a programming error caught at startup.
+func Register(c Collector) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, existing := range registry {
+		if existing.Name() == c.Name() {
+			panic("collector: duplicate registration of " + c.Name())
+		}
+	}
+	registry = append(registry, c)
+}
+
+// All returns every registered collector in dependency order.
+func All() []Collector {
+	mu.Lock()
+	defer mu.Unlock()
+	return sortByDeps(registry)
+}
+
+// Lookup returns the registered collector with the given name.
+func Lookup(name string) (Collector, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, c := range registry {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Names returns the names of all registered collectors.
+func Names() []string {
+	var names []string
+	for _, c := range All() {
+		names = append(names, c.Name())
+	}
+	return names
+}
+
+// Select returns the collectors to run for cfg, in dependency order.
+// --only overrides mode gating and pulls in dependencies; --skip always wins. Unknown names in either
+// list are an error so typos do not silently change a run.
+func Select(cfg types.RunConfig) ([]Collector, error) {
+	for _, name := range append(append([]string{}, cfg.Only...), cfg.Skip...) {
+		if _, ok := Lookup(name); !ok {
+			return nil, fmt.Errorf("unknown collector %q (available: %s)", name, strings.Join(Names(), ", "))
+		}
+	}
+
+	only := toSet(cfg.Only)
+	skip := toSet(cfg.Skip)
+
+	// Pull in dependencies of --only collectors so their data has somewhere to go.
+	for changed := true; changed; {
+		changed = false
+		for name := range only {
+			c, _ := Lookup(name)
+			for _, dep := range DependsOn(c) {
+				if _, ok := Lookup(dep); ok && !only[dep] {
+					only[dep] = true
+					changed = true
+				}
+			}
+		}
+	}
+
+	var selected []Collector
+	for _, c := range All() {
+		if skip[c.Name()] || !SupportsPlatform(c, runtime.GOOS) {
+			continue
+		}
+		if len(only) > 0 {
+			if only[c.Name()] {
+				selected = append(selected, c)
+			}
+			continue
+		}
+		if AppliesToMode(c, cfg.Mode) || (cfg.NetworkTest && c.Name() == "network") {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// SupportsPlatform reports whether c runs on the given GOOS.
+func SupportsPlatform(c Collector, goos string) bool {
+	platforms := c.Platforms()
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		if p == goos {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliesToMode reports whether c is enabled for the given run mode.
+func AppliesToMode(c Collector, mode types.RunMode) bool {
+	modes := c.Modes()
+	if len(modes) == 0 {
+		return true
+	}
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// DependsOn returns the names c must run after, if any.
+func DependsOn(c Collector) []string {
+	if d, ok := c.(Dependent); ok {
+		return d.After()
+	}
+	return nil
+}
+
+// sortByDeps orders collectors so each one follows its dependencies while
+// otherwise keeping registration order.
+func sortByDeps(cs []Collector) []Collector {
+	placed := make(map[string]bool, len(cs))
+	var out []Collector
+	remaining := append([]Collector{}, cs...)
+	for len(remaining) > 0 {
+		progress := false
+		next := remaining[:0]
+		for _, c := range remaining {
+			ready := true
+			for _, dep := range DependsOn(c) {
+				if !placed[dep] && registered(cs, dep) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				out = append(out, c)
+				placed[c.Name()] = true
+				progress = true
+			} else {
+				next = append(next, c)
+			}
+		}
+		remaining = next
+		if !progress {
+			// Dependency cycle: keep the rest in registration order.
+			out = append(out, remaining...)
+			break
+		}
+	}
+	return out
+}
+
+func registered(cs []Collector, name string) bool {
+	for _, c := range cs {
+		if c.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, s := range list {
+		set[s] = true
+	}
+	return set
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"context"
+
+	"runtime"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func noop(ctx context.Context, env *Env, r *types.Report) []types.CollectorError { return nil }
+
+func withRegistry(t *testing.T, cs ...Collector) {
+	t.Helper()
+	saved := registry
+	registry = nil
+	for _, c := range cs {
+		Register(c)
+	}
+	t.Cleanup(func() { registry = saved })
+}
+
+func names(cs []Collector) []string {
+	var out []string
+	for _, c := range cs {
+		out = append(out, c.Name())
+	}
+	return out
+}
+
+func TestAll_DependencyOrder(t *testing.T) {
+	withRegistry(t,
+		Spec{ID: "child", Deps: []string{"parent"}, Fn: noop},
+		Spec{ID: "other", Fn: noop},
+		Spec{ID: "parent", Fn: noop},
+	)
+	got := names(All())
+	want := []string{"other", "parent", "child"}
+	if len(got) != len(want) {
+		t.Fatalf("All() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("All() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSelect_ModeAndPlatform(t *testing.T) {
+	withRegistry(t,
+		Spec{ID: "always", Fn: noop},
+		Spec{ID: "ai-only", RunModes: []types.RunMode{types.ModeAI}, Fn: noop},
+		Spec{ID: "elsewhere", OS: []string{"plan9-" + runtime.GOOS}, Fn: noop},
+	)
+	got, err := Select(types.RunConfig{Mode: types.ModeGaming})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := names(got); len(n) != 1 || n[0] != "always" {
+		t.Errorf("Select(gaming) = %v, want [always]", n)
+	}
+}
+
+func TestSelect_OnlyAndSkip(t *testing.T) {
+	withRegistry(t,
+		Spec{ID: "base", Fn: noop},
+		Spec{ID: "extra", Deps: []string{"base"}, RunModes: []types.RunMode{types.ModeAI}, Fn: noop},
+		Spec{ID: "net", Fn: noop},
+	)
+
+	// --only ignores mode gating and pulls in dependencies
+	got, err := Select(types.RunConfig{Mode: types.ModeGaming, Only: []string{"extra"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := names(got); len(n) != 2 || n[0] != "base" || n[1] != "extra" {
+		t.Errorf("Select(--only extra) = %v, want [base extra]", n)
+	}
+
+	got, err = Select(types.RunConfig{Mode: types.ModeGaming, Skip: []string{"net"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := names(got); len(n) != 1 || n[0] != "base" {
+		t.Errorf("Select(--skip net) = %v, want [base]", n)
+	}
+
+	if _, err := Select(types.RunConfig{Only: []string{"nope"}}); err == nil {
+		t.Error("expected error for unknown collector name")
+	}
+}
+
+func TestSpec_PrivilegesFromChecks(t *testing.T) {
+	if p := (Spec{ID: "plain"}).Privileges(); p != PrivilegeNone {
+		t.Errorf("collector without checks: got %s, want none", p)
+	}
+	s := Spec{ID: "logs", Checks: []Check{{ID: "logs.journal"}, {ID: "logs.dmesg", Needs: PrivilegeAdmin}}}
+	if p := s.Privileges(); p != PrivilegeAdmin {
+		t.Errorf("collector with an admin check: got %s, want admin", p)
+	}
+}
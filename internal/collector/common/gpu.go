@@ -1,15 +1,21 @@
 package common
 
 import (
+	"context"
+	"fmt"
 	"regexp"
 	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
-// CollectGPUInfo gathers GPU and NVIDIA driver information.
-func CollectGPUInfo(timeout int) ([]types.GPUInfo, types.DriverInfo, []types.CollectorError) {
+// CollectGPUInfo gathers GPU and NVIDIA driver information. The nvidia-smi
+// data comes from smi, the run's shared nvidia-smi -q -x query. NVIDIA GPUs
+// are described from pack's PCI ID table when pack is not nil.
+func CollectGPUInfo(ctx context.Context, smi *nvsmi.Cache, timeout int, pack *knowledge.Pack) ([]types.GPUInfo, types.DriverInfo, []types.CollectorError) {
 	var gpus []types.GPUInfo
 	var driver types.DriverInfo
 	var errs []types.CollectorError
@@ -17,7 +23,7 @@ func CollectGPUInfo(timeout int) ([]types.GPUInfo, types.DriverInfo, []types.Col
 	// Try nvidia-smi first (cross-platform)
 	if util.CommandExists("nvidia-smi") {
 		driver.NvidiaSmiPath = "nvidia-smi"
-		collectFromNvidiaSmi(&gpus, &driver, &errs, timeout)
+		collectFromNvidiaSmi(ctx, smi, &gpus, &driver, &errs, timeout)
 	} else {
 		errs = append(errs, types.CollectorError{
 			Collector: "gpu.nvidia-smi",
@@ -27,92 +33,119 @@ func CollectGPUInfo(timeout int) ([]types.GPUInfo, types.DriverInfo, []types.Col
 
 	// Platform-specific GPU enumeration
 	if util.IsWindows() {
-		collectGPUsWindows(&gpus, &driver, &errs, timeout)
+		collectGPUsWindows(ctx, &gpus, &driver, &errs, timeout)
 	} else if util.IsLinux() {
-		collectGPUsLinux(&gpus, &errs, timeout)
+		collectGPUsLinux(ctx, &gpus, &errs, timeout)
+	}
+
+	if pack != nil {
+		for i := range gpus {
+			pack.DescribeGPU(&gpus[i])
+		}
 	}
 
 	return gpus, driver, errs
 }
 
-func collectFromNvidiaSmi(gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[]types.CollectorError, timeout int) {
-	// nvidia-smi -L for GPU list
-	r := util.RunCommand(timeout, "nvidia-smi", "-L")
-	if r.Err != nil {
+func collectFromNvidiaSmi(ctx context.Context, smi *nvsmi.Cache, gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[]types.CollectorError, timeout int) {
+	log, err := smi.Get(ctx, timeout)
+	if err != nil {
 		*errs = append(*errs, types.CollectorError{
-			Collector: "gpu.nvidia-smi-L",
-			Error:     "nvidia-smi -L failed: " + r.Err.Error(),
+			Collector: "gpu.nvidia-smi",
+			Error:     err.Error(),
 		})
 		return
 	}
 
-	// Parse GPU list: "GPU 0: NVIDIA GeForce RTX 4090 (UUID: GPU-xxxxx)"
-	gpuRe := regexp.MustCompile(`GPU (\d+): (.+?)(?:\s*\(UUID:.*\))?$`)
-	for _, line := range strings.Split(r.Stdout, "\n") {
-		line = strings.TrimSpace(line)
-		if m := gpuRe.FindStringSubmatch(line); m != nil {
-			idx := int(parseIntSafe(m[1]))
-			gpu := types.GPUInfo{
-				Index:    idx,
-				Name:     strings.TrimSpace(m[2]),
-				Vendor:   "NVIDIA",
-				IsNVIDIA: true,
-			}
-			*gpus = append(*gpus, gpu)
-		}
-	}
-
-	// nvidia-smi summary for driver version, CUDA version, memory
-	r = util.RunCommand(timeout, "nvidia-smi",
-		"--query-gpu=driver_version,pci.bus_id,memory.total,memory.free,memory.used,temperature.gpu,power.draw",
-		"--format=csv,noheader,nounits")
-	if r.Err == nil {
-		lines := strings.Split(r.Stdout, "\n")
-		for i, line := range lines {
-			fields := strings.Split(line, ", ")
-			if len(fields) >= 2 {
-				if i == 0 && len(fields) >= 1 {
-					driver.Version = strings.TrimSpace(fields[0])
-				}
-				if i < len(*gpus) {
-					if len(fields) >= 2 {
-						(*gpus)[i].PCIBusID = strings.TrimSpace(fields[1])
-						(*gpus)[i].DriverVersion = driver.Version
-					}
-					if len(fields) >= 3 {
-						(*gpus)[i].VRAMTotalMB = parseIntSafe(fields[2])
-					}
-					if len(fields) >= 4 {
-						(*gpus)[i].VRAMFreeMB = parseIntSafe(fields[3])
-					}
-					if len(fields) >= 5 {
-						(*gpus)[i].VRAMUsedMB = parseIntSafe(fields[4])
-					}
-					if len(fields) >= 6 {
-						(*gpus)[i].Temperature = int(parseIntSafe(fields[5]))
-					}
-					if len(fields) >= 7 {
-						(*gpus)[i].PowerDraw = strings.TrimSpace(fields[6])
-					}
-				}
-			}
+	driver.Version = log.DriverVersion
+	driver.CUDAVersion = log.CUDAVersion
+	driver.Sources = map[string]string{"version": "nvidia-smi", "cuda_version": "nvidia-smi"}
+
+	// MIG profile names and UUIDs are only in nvidia-smi -L
+	var listing map[int][]nvsmi.MIGListing
+	if log.MIGEnabled() {
+		r := util.RunCommandContext(ctx, timeout, "nvidia-smi", "-L")
+		if r.Err != nil {
+			*errs = append(*errs, types.CollectorError{
+				Collector: "gpu.mig",
+				Error:     "nvidia-smi -L failed; MIG profiles and UUIDs unavailable: " + r.Err.Error(),
+			})
 		}
+		listing = nvsmi.ParseMIGListing([]byte(r.Stdout))
 	}
 
-	// Get CUDA version from nvidia-smi header
-	r = util.RunCommand(timeout, "nvidia-smi")
-	if r.Err == nil {
-		driver.NvidiaSmiOutput = r.Stdout
-		cudaRe := regexp.MustCompile(`CUDA Version:\s*([\d.]+)`)
-		if m := cudaRe.FindStringSubmatch(r.Stdout); m != nil {
-			driver.CUDAVersion = m[1]
+	for _, g := range log.GPUs {
+		gpu := types.GPUInfo{
+			Index:         g.Index,
+			Name:          g.Name,
+			Vendor:        "NVIDIA",
+			PCIVendorID:   g.PCIVendorID,
+			PCIDeviceID:   g.PCIDeviceID,
+			PCIBusID:      g.BusID,
+			DriverVersion: log.DriverVersion,
+			UUID:          g.UUID,
+			VBIOSVersion:  g.VBIOSVersion,
+			VRAMTotalMB:   g.MemoryTotalMiB,
+			VRAMFreeMB:    g.MemoryFreeMiB,
+			VRAMUsedMB:    g.MemoryUsedMiB,
+			Temperature:   g.TemperatureC,
+			IsNVIDIA:      true,
+			Sources:       gpuSources("nvidia-smi", "name", "pci_bus_id", "driver_version", "uuid", "vbios_version"),
 		}
+		if g.PowerDrawW > 0 {
+			gpu.PowerDraw = fmt.Sprintf("%.2f", g.PowerDrawW)
+		}
+		if g.PCIeGenCurrent > 0 {
+			gpu.PCIeLinkSpeed = formatPCIeGen(g.PCIeGenCurrent)
+		}
+		if g.PCIeWidthCurrent > 0 {
+			gpu.PCIeLinkWidth = formatPCIeWidth(g.PCIeWidthCurrent)
+		}
+		gpu.MIG = migFromSMI(g, listing[g.Index])
+		*gpus = append(*gpus, gpu)
+	}
+}
+
+// gpuSources records source as where each of the named GPUInfo fields (by
+// JSON name) came from.
+func gpuSources(source string, fields ...string) map[string]string {
+	sources := make(map[string]string, len(fields))
+	for _, f := range fields {
+		sources[f] = source
 	}
+	return sources
 }
 
-func collectGPUsWindows(gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[]types.CollectorError, timeout int) {
+// migFromSMI builds MIGInfo from one GPU of the nvidia-smi query and its
+// nvidia-smi -L MIG lines. It returns nil for GPUs without MIG support.
+func migFromSMI(g nvsmi.GPU, listing []nvsmi.MIGListing) *types.MIGInfo {
+	if !g.MIG.Supported {
+		return nil
+	}
+	info := &types.MIGInfo{Enabled: g.MIG.Enabled, PendingEnabled: g.MIG.PendingEnabled}
+	for _, d := range g.MIGDevices {
+		inst := types.MIGInstance{
+			DeviceIndex:       d.Index,
+			GPUInstanceID:     d.GPUInstanceID,
+			ComputeInstanceID: d.ComputeInstanceID,
+			SMCount:           d.SMCount,
+			MemoryTotalMB:     d.MemoryTotalMiB,
+			MemoryUsedMB:      d.MemoryUsedMiB,
+		}
+		for _, l := range listing {
+			if l.Device == d.Index {
+				inst.Profile = l.Profile
+				inst.UUID = l.UUID
+			}
+		}
+		info.Instances = append(info.Instances, inst)
+	}
+	return info
+}
+
+func collectGPUsWindows(ctx context.Context, gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[]types.CollectorError, timeout int) {
 	// Use WMI to enumerate all display adapters (includes iGPU)
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-CimInstance Win32_VideoController | ForEach-Object { "$($_.Name)|$($_.DriverVersion)|$($_.AdapterRAM)|$($_.PNPDeviceID)" }`)
 	if r.Err != nil {
 		*errs = append(*errs, types.CollectorError{
@@ -145,6 +178,7 @@ func collectGPUsWindows(gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[
 			Index:         len(*gpus),
 			Name:          name,
 			DriverVersion: strings.TrimSpace(parts[1]),
+			Sources:       gpuSources("wmi", "name", "driver_version"),
 		}
 
 		if strings.Contains(strings.ToLower(name), "nvidia") {
@@ -172,7 +206,7 @@ func collectGPUsWindows(gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[
 	}
 
 	// Try to get WDDM version
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`(Get-ItemProperty "HKLM:\SOFTWARE\Microsoft\DirectX").Version`)
 	if r.Err == nil && r.Stdout != "" {
 		for i := range *gpus {
@@ -183,13 +217,13 @@ func collectGPUsWindows(gpus *[]types.GPUInfo, driver *types.DriverInfo, errs *[
 	}
 }
 
-func collectGPUsLinux(gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeout int) {
+func collectGPUsLinux(ctx context.Context, gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeout int) {
 	// Use lspci for GPU enumeration if available
 	if !util.CommandExists("lspci") {
 		return
 	}
 
-	r := util.RunCommand(timeout, "lspci", "-nn")
+	r := util.RunCommandContext(ctx, timeout, "lspci", "-nn")
 	if r.Err != nil {
 		*errs = append(*errs, types.CollectorError{
 			Collector: "gpu.lspci",
@@ -198,9 +232,13 @@ func collectGPUsLinux(gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeo
 		return
 	}
 
-	existingBusIDs := make(map[string]bool)
-	for _, g := range *gpus {
-		existingBusIDs[g.PCIBusID] = true
+	known := func(busID string) bool {
+		for _, g := range *gpus {
+			if sameBusID(g.PCIBusID, busID) {
+				return true
+			}
+		}
+		return false
 	}
 
 	vgaRe := regexp.MustCompile(`^([0-9a-f:.]+)\s+(?:VGA|3D|Display).*?:\s+(.+?)\s*\[([0-9a-f]{4}):([0-9a-f]{4})\]`)
@@ -215,8 +253,8 @@ func collectGPUsLinux(gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeo
 		vendorID := m[3]
 		deviceID := m[4]
 
-		if existingBusIDs[busID] {
-			continue
+		if known(busID) {
+			continue // Already have from nvidia-smi
 		}
 
 		gpu := types.GPUInfo{
@@ -225,6 +263,7 @@ func collectGPUsLinux(gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeo
 			PCIBusID:    busID,
 			PCIVendorID: vendorID,
 			PCIDeviceID: deviceID,
+			Sources:     gpuSources("lspci", "name", "pci_bus_id"),
 		}
 
 		switch strings.ToLower(vendorID) {
@@ -242,3 +281,46 @@ func collectGPUsLinux(gpus *[]types.GPUInfo, errs *[]types.CollectorError, timeo
 		*gpus = append(*gpus, gpu)
 	}
 }
+
+// shortBusID reduces a PCI address to lspci's bus:device.function form, so
+// nvidia-smi's "00000000:01:00.0" and lspci's "01:00.0" compare equal.
+func shortBusID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if parts := strings.Split(id, ":"); len(parts) == 3 {
+		return parts[1] + ":" + parts[2]
+	}
+	return id
+}
+
+// sameBusID reports whether two PCI addresses name the same device. Hosts
+// with several PCI domains, such as Azure NC/ND VMs, reuse bus:device.function
+// in every domain, so the domain decides whenever both addresses carry one;
+// only lspci's domain-less "01:00.0" is matched on bus:device.function alone.
+func sameBusID(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if strings.Count(a, ":") == 2 && strings.Count(b, ":") == 2 {
+		return PCIAddress(a) == PCIAddress(b)
+	}
+	return shortBusID(a) == shortBusID(b)
+}
+
+// PCIAddress expands nvidia-smi's "00000000:01:00.0" or lspci's "01:00.0"
+// to the "0000:01:00.0" form sysfs and lspci -s use.
+func PCIAddress(id string) string {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(id)), ":")
+	switch len(parts) {
+	case 2:
+		return "0000:" + parts[0] + ":" + parts[1]
+	case 3:
+		// Keep domains wider than 16 bits intact rather than cutting them
+		// to four digits, which would fold distinct domains together.
+		domain := strings.TrimLeft(parts[0], "0")
+		if len(domain) < 4 {
+			domain = strings.Repeat("0", 4-len(domain)) + domain
+		}
+		return domain + ":" + parts[1] + ":" + parts[2]
+	}
+	return id
+}
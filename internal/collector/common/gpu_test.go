@@ -0,0 +1,87 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func TestMIGFromSMI(t *testing.T) {
+	g := nvsmi.GPU{
+		Index: 0,
+		MIG:   nvsmi.MIGMode{Supported: true, Enabled: true, PendingEnabled: true},
+		MIGDevices: []nvsmi.MIGDevice{
+			{Index: 0, GPUInstanceID: 2, SMCount: 60, MemoryTotalMiB: 40192},
+			{Index: 1, GPUInstanceID: 9, SMCount: 16, MemoryTotalMiB: 9984, MemoryUsedMiB: 5},
+		},
+	}
+	listing := []nvsmi.MIGListing{
+		{Device: 0, Profile: "3g.40gb", UUID: "MIG-4e5b21c8-9d07-5a3f-b6e2-18c0d94f7a31"},
+		{Device: 1, Profile: "1g.10gb", UUID: "MIG-a27f0e63-48bd-5c19-8e75-3f91b6d0c2e4"},
+	}
+
+	info := migFromSMI(g, listing)
+	if info == nil || !info.Enabled || len(info.Instances) != 2 {
+		t.Fatalf("info = %+v", info)
+	}
+	inst := info.Instances[1]
+	if inst.GPUInstanceID != 9 || inst.Profile != "1g.10gb" || inst.UUID != "MIG-a27f0e63-48bd-5c19-8e75-3f91b6d0c2e4" || inst.MemoryTotalMB != 9984 {
+		t.Errorf("instance 1 = %+v", inst)
+	}
+
+	// Without nvidia-smi -L the instances are still reported
+	if info := migFromSMI(g, nil); len(info.Instances) != 2 || info.Instances[0].UUID != "" {
+		t.Errorf("instances without a listing = %+v", info.Instances)
+	}
+
+	if migFromSMI(nvsmi.GPU{}, nil) != nil {
+		t.Error("expected nil MIG info for a GPU without MIG support")
+	}
+}
+
+func TestPCIAddress(t *testing.T) {
+	tests := map[string]string{
+		"00000000:01:00.0": "0000:01:00.0",
+		"0000:41:00.0":     "0000:41:00.0",
+		"01:00.0":          "0000:01:00.0",
+		"00000001:C1:00.0": "0001:c1:00.0",
+		"00010000:00:00.0": "10000:00:00.0",
+	}
+	for in, want := range tests {
+		if got := PCIAddress(in); got != want {
+			t.Errorf("PCIAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSameBusID(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"00000000:01:00.0", "0000:01:00.0", true},
+		{"00000000:01:00.0", "01:00.0", true},
+		{"00000001:00:00.0", "00000002:00:00.0", false},
+		{"00000001:00:00.0", "0000:00:00.0", false},
+		{"00000000:01:00.0", "", false},
+	}
+	for _, tt := range tests {
+		if got := sameBusID(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameBusID(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestGPUByBusID_MultiDomain(t *testing.T) {
+	gpus := []types.GPUInfo{
+		{Index: 0, PCIBusID: "00000001:00:00.0"},
+		{Index: 1, PCIBusID: "00000002:00:00.0"},
+	}
+	if g := gpuByBusID(gpus, "0002:00:00.0"); g == nil || g.Index != 1 {
+		t.Errorf("gpuByBusID(0002:00:00.0) = %+v, want GPU 1", g)
+	}
+	if g := gpuByBusID(gpus, "0003:00:00.0"); g != nil {
+		t.Errorf("gpuByBusID(0003:00:00.0) = %+v, want nil", g)
+	}
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// CollectMemoryHealth gathers ECC error counts, retired pages and row
+// remapping state for every GPU in the run's shared nvidia-smi -q -x query.
+// GPUs that support none of them (most GeForce cards) are left out.
+func CollectMemoryHealth(ctx context.Context, smi *nvsmi.Cache, timeout int) ([]types.MemoryHealthInfo, []types.CollectorError) {
+	var infos []types.MemoryHealthInfo
+	var errs []types.CollectorError
+
+	if !util.CommandExists("nvidia-smi") {
+		errs = append(errs, types.CollectorError{
+			Collector: "memory-health",
+			Error:     "nvidia-smi not found in PATH",
+			Fatal:     true,
+		})
+		return infos, errs
+	}
+
+	log, err := smi.Get(ctx, timeout)
+	if err != nil {
+		errs = append(errs, types.CollectorError{
+			Collector: "memory-health.query",
+			Error:     fmt.Sprintf("nvidia-smi memory health query failed: %v", err),
+			Fatal:     true,
+		})
+		return infos, errs
+	}
+
+	for _, g := range log.GPUs {
+		if info, ok := memoryHealthFromSMI(g); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos, errs
+}
+
+// memoryHealthFromSMI fills MemoryHealthInfo from one GPU of the nvidia-smi
+// query. ok is false when the GPU reports no ECC, retirement or remap data.
+func memoryHealthFromSMI(g nvsmi.GPU) (types.MemoryHealthInfo, bool) {
+	m := g.Memory
+	info := types.MemoryHealthInfo{
+		GPUIndex: g.Index,
+		PCIBusID: g.BusID,
+
+		ECCSupported:           m.ECCSupported,
+		ECCEnabled:             m.ECCEnabled,
+		ECCPendingEnabled:      m.ECCPendingEnabled,
+		VolatileCorrectable:    m.ECCVolatile.Correctable,
+		VolatileUncorrectable:  m.ECCVolatile.Uncorrectable,
+		AggregateCorrectable:   m.ECCAggregate.Correctable,
+		AggregateUncorrectable: m.ECCAggregate.Uncorrectable,
+		SRAMThresholdExceeded:  m.SRAMThresholdExceeded,
+
+		RetiredPagesSupported: m.RetiredPagesSupported,
+		RetiredSingleBit:      m.RetiredSingleBit,
+		RetiredDoubleBit:      m.RetiredDoubleBit,
+		RetirementPending:     m.RetirementPending,
+
+		RowRemapSupported: m.RowRemapSupported,
+	}
+	if m.RowRemapSupported {
+		r := m.RowRemap
+		info.RemappedCorrectable = r.Correctable
+		info.RemappedUncorrectable = r.Uncorrectable
+		info.RemapPending = r.Pending
+		info.RemapFailed = r.Failed
+		info.RemapHistogram = []int{r.HistogramMax, r.HistogramHigh, r.HistogramPartial, r.HistogramLow, r.HistogramNone}
+	}
+	return info, m.ECCSupported || m.RetiredPagesSupported || m.RowRemapSupported
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+)
+
+func TestMemoryHealthFromSMI(t *testing.T) {
+	g := nvsmi.GPU{Index: 1, BusID: "00000000:0F:00.0"}
+	g.Memory.ECCSupported = true
+	g.Memory.ECCEnabled = true
+	g.Memory.ECCVolatile = nvsmi.ECCCounts{Uncorrectable: 2}
+	g.Memory.RowRemapSupported = true
+	g.Memory.RowRemap = nvsmi.RowRemap{Uncorrectable: 640, Failed: true, HistogramLow: 2, HistogramNone: 638}
+
+	info, ok := memoryHealthFromSMI(g)
+	if !ok {
+		t.Fatal("expected memory health for an ECC-capable GPU")
+	}
+	if info.GPUIndex != 1 || info.VolatileUncorrectable != 2 || !info.RemapFailed || info.RemappedUncorrectable != 640 {
+		t.Errorf("info = %+v", info)
+	}
+	if len(info.RemapHistogram) != 5 || info.RemapHistogram[3] != 2 || info.RemapHistogram[4] != 638 {
+		t.Errorf("RemapHistogram = %v", info.RemapHistogram)
+	}
+
+	if _, ok := memoryHealthFromSMI(nvsmi.GPU{Index: 0}); ok {
+		t.Error("expected nothing for a GPU without ECC, retirement or row remapping")
+	}
+}
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"regexp"
@@ -9,46 +10,47 @@ import (
 	"strings"
 	"time"
 
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // CollectNetworkInfo gathers network diagnostic data including interface detection,
 // latency, jitter, packet loss, DNS resolution time, and traceroute hops.
-func CollectNetworkInfo(timeout int) (types.NetworkInfo, []types.CollectorError) {
+func CollectNetworkInfo(ctx context.Context, fsys sysroot.FS, timeout int) (types.NetworkInfo, []types.CollectorError) {
 	var info types.NetworkInfo
 	var errs []types.CollectorError
 
 	// Step 1: Detect active network interface
-	detectActiveInterface(&info, &errs, timeout)
+	detectActiveInterface(ctx, &info, &errs, timeout)
 
 	// Step 2: Detect wifi vs ethernet and gather wifi details
-	detectInterfaceType(&info, &errs, timeout)
+	detectInterfaceType(ctx, fsys, &info, &errs, timeout)
 
 	// Step 3: Latency, jitter, and packet loss via ping
-	collectPingStats(&info, &errs, timeout)
+	collectPingStats(ctx, &info, &errs, timeout)
 
 	// Step 4: DNS resolution time
-	collectDNSTime(&info, &errs, timeout)
+	collectDNSTime(ctx, &info, &errs, timeout)
 
 	// Step 5: Traceroute
-	collectTraceroute(&info, &errs, timeout)
+	collectTraceroute(ctx, &info, &errs, timeout)
 
 	return info, errs
 }
 
 // detectActiveInterface finds the primary active network interface.
-func detectActiveInterface(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func detectActiveInterface(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	if runtime.GOOS == "windows" {
-		detectActiveInterfaceWindows(info, errs, timeout)
+		detectActiveInterfaceWindows(ctx, info, errs, timeout)
 	} else {
-		detectActiveInterfaceLinux(info, errs, timeout)
+		detectActiveInterfaceLinux(ctx, info, errs, timeout)
 	}
 }
 
 // detectActiveInterfaceWindows uses netsh to find connected interfaces.
-func detectActiveInterfaceWindows(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "netsh", "interface", "show", "interface")
+func detectActiveInterfaceWindows(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "netsh", "interface", "show", "interface")
 	if r.Err != nil {
 		*errs = append(*errs, types.CollectorError{
 			Collector: "network.interface",
@@ -76,8 +78,8 @@ func detectActiveInterfaceWindows(info *types.NetworkInfo, errs *[]types.Collect
 }
 
 // detectActiveInterfaceLinux uses ip route to find the default interface.
-func detectActiveInterfaceLinux(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "ip", "route", "show", "default")
+func detectActiveInterfaceLinux(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "ip", "route", "show", "default")
 	if r.Err != nil {
 		*errs = append(*errs, types.CollectorError{
 			Collector: "network.interface",
@@ -98,17 +100,17 @@ func detectActiveInterfaceLinux(info *types.NetworkInfo, errs *[]types.Collector
 }
 
 // detectInterfaceType determines if the active interface is wifi or ethernet.
-func detectInterfaceType(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func detectInterfaceType(ctx context.Context, fsys sysroot.FS, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	if runtime.GOOS == "windows" {
-		detectInterfaceTypeWindows(info, errs, timeout)
+		detectInterfaceTypeWindows(ctx, info, errs, timeout)
 	} else {
-		detectInterfaceTypeLinux(info, errs, timeout)
+		detectInterfaceTypeLinux(ctx, fsys, info, errs, timeout)
 	}
 }
 
 // detectInterfaceTypeWindows uses netsh wlan to check for wifi.
-func detectInterfaceTypeWindows(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "netsh", "wlan", "show", "interfaces")
+func detectInterfaceTypeWindows(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "netsh", "wlan", "show", "interfaces")
 	if r.Err != nil {
 		// netsh wlan may fail if no wifi adapter exists; that means ethernet
 		info.InterfaceType = "ethernet"
@@ -145,21 +147,19 @@ func detectInterfaceTypeWindows(info *types.NetworkInfo, errs *[]types.Collector
 }
 
 // detectInterfaceTypeLinux checks /sys/class/net and iwconfig for wifi.
-func detectInterfaceTypeLinux(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func detectInterfaceTypeLinux(ctx context.Context, fsys sysroot.FS, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	if info.InterfaceName == "" {
 		info.InterfaceType = "unknown"
 		return
 	}
 
 	// Check if the interface has a wireless directory
-	r := util.RunCommand(timeout, "test", "-d",
-		fmt.Sprintf("/sys/class/net/%s/wireless", info.InterfaceName))
-	if r.ExitCode == 0 {
+	if fi, err := fsys.Stat(fmt.Sprintf("/sys/class/net/%s/wireless", info.InterfaceName)); err == nil && fi.IsDir() {
 		info.InterfaceType = "wifi"
 
 		// Try iwconfig for signal strength
 		if util.CommandExists("iwconfig") {
-			r = util.RunCommand(timeout, "iwconfig", info.InterfaceName)
+			r := util.RunCommandContext(ctx, timeout, "iwconfig", info.InterfaceName)
 			if r.Err == nil {
 				// Parse signal level: "Signal level=-55 dBm"
 				sigRe := regexp.MustCompile(`Signal level[=:](-?\d+)\s*dBm`)
@@ -182,14 +182,14 @@ func detectInterfaceTypeLinux(info *types.NetworkInfo, errs *[]types.CollectorEr
 }
 
 // collectPingStats runs ping to 1.1.1.1 and computes latency, jitter, packet loss.
-func collectPingStats(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func collectPingStats(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	pingTimeout := timeout * 2
 
 	var r util.CommandResult
 	if runtime.GOOS == "windows" {
-		r = util.RunCommand(pingTimeout, "ping", "-n", "10", "1.1.1.1")
+		r = util.RunCommandContext(ctx, pingTimeout, "ping", "-n", "10", "1.1.1.1")
 	} else {
-		r = util.RunCommand(pingTimeout, "ping", "-c", "10", "-i", "0.5", "1.1.1.1")
+		r = util.RunCommandContext(ctx, pingTimeout, "ping", "-c", "10", "-i", "0.5", "1.1.1.1")
 	}
 
 	if r.Err != nil {
@@ -288,9 +288,9 @@ func parsePingLoss(output string) float64 {
 }
 
 // collectDNSTime measures DNS resolution time using nslookup.
-func collectDNSTime(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func collectDNSTime(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	start := time.Now()
-	r := util.RunCommand(timeout, "nslookup", "google.com")
+	r := util.RunCommandContext(ctx, timeout, "nslookup", "google.com")
 	elapsed := time.Since(start)
 
 	if r.Err != nil {
@@ -305,13 +305,13 @@ func collectDNSTime(info *types.NetworkInfo, errs *[]types.CollectorError, timeo
 }
 
 // collectTraceroute runs traceroute/tracert and parses hop data.
-func collectTraceroute(info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
+func collectTraceroute(ctx context.Context, info *types.NetworkInfo, errs *[]types.CollectorError, timeout int) {
 	var r util.CommandResult
 	if runtime.GOOS == "windows" {
-		r = util.RunCommand(timeout*2, "tracert", "-d", "-h", "15", "-w", "2000", "1.1.1.1")
+		r = util.RunCommandContext(ctx, timeout*2, "tracert", "-d", "-h", "15", "-w", "2000", "1.1.1.1")
 	} else {
 		if util.CommandExists("traceroute") {
-			r = util.RunCommand(timeout*2, "traceroute", "-n", "-m", "15", "-w", "2", "1.1.1.1")
+			r = util.RunCommandContext(ctx, timeout*2, "traceroute", "-n", "-m", "15", "-w", "2", "1.1.1.1")
 		} else {
 			*errs = append(*errs, types.CollectorError{
 				Collector: "network.traceroute",
@@ -1,17 +1,18 @@
 package common
 
 import (
+	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
-// CollectPCIeInfo gathers PCIe link state data via nvidia-smi.
-func CollectPCIeInfo(timeout int) (types.PCIeInfo, []types.CollectorError) {
-	var info types.PCIeInfo
+// CollectPCIeInfo gathers PCIe link state for every GPU in the run's shared
+// nvidia-smi -q -x query, in nvidia-smi order.
+func CollectPCIeInfo(ctx context.Context, smi *nvsmi.Cache, timeout int) ([]types.PCIeInfo, []types.CollectorError) {
+	var infos []types.PCIeInfo
 	var errs []types.CollectorError
 
 	if !util.CommandExists("nvidia-smi") {
@@ -20,88 +21,62 @@ func CollectPCIeInfo(timeout int) (types.PCIeInfo, []types.CollectorError) {
 			Error:     "nvidia-smi not found in PATH",
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
 
-	r := util.RunCommand(timeout, "nvidia-smi",
-		"--query-gpu=pcie.link.gen.current,pcie.link.gen.max,pcie.link.width.current,pcie.link.width.max",
-		"--format=csv,noheader,nounits")
-	if r.Err != nil {
+	log, err := smi.Get(ctx, timeout)
+	if err != nil {
 		errs = append(errs, types.CollectorError{
 			Collector: "pcie.query",
-			Error:     fmt.Sprintf("nvidia-smi PCIe query failed: %v", r.Err),
+			Error:     fmt.Sprintf("nvidia-smi PCIe query failed: %v", err),
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
-
-	line := strings.TrimSpace(r.Stdout)
-	if line == "" {
+	if len(log.GPUs) == 0 {
 		errs = append(errs, types.CollectorError{
 			Collector: "pcie.parse",
-			Error:     "nvidia-smi PCIe query returned empty output",
+			Error:     "nvidia-smi reported no GPUs",
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
 
-	// Take only the first GPU line if there are multiple
-	lines := strings.Split(line, "\n")
-	fields := strings.Split(lines[0], ", ")
+	for _, g := range log.GPUs {
+		info, gpuErrs := pcieFromSMI(g)
+		infos = append(infos, info)
+		errs = append(errs, gpuErrs...)
+	}
+	return infos, errs
+}
 
-	var currentGen, maxGen, currentWidth, maxWidth int
+// pcieFromSMI fills PCIeInfo from one GPU of the nvidia-smi query.
+func pcieFromSMI(g nvsmi.GPU) (types.PCIeInfo, []types.CollectorError) {
+	info := types.PCIeInfo{GPUIndex: g.Index, PCIBusID: g.BusID, Source: "nvidia-smi"}
+	var errs []types.CollectorError
 
-	if len(fields) >= 1 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[0])); err == nil {
-			currentGen = v
-			info.CurrentSpeed = formatPCIeGen(v)
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "pcie.current_gen",
-				Error:     fmt.Sprintf("failed to parse current PCIe gen: %s", fields[0]),
-			})
-		}
+	if g.PCIeGenCurrent > 0 {
+		info.CurrentSpeed = formatPCIeGen(g.PCIeGenCurrent)
 	}
-
-	if len(fields) >= 2 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[1])); err == nil {
-			maxGen = v
-			info.MaxSpeed = formatPCIeGen(v)
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "pcie.max_gen",
-				Error:     fmt.Sprintf("failed to parse max PCIe gen: %s", fields[1]),
-			})
-		}
+	if g.PCIeGenMax > 0 {
+		info.MaxSpeed = formatPCIeGen(g.PCIeGenMax)
 	}
-
-	if len(fields) >= 3 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[2])); err == nil {
-			currentWidth = v
-			info.CurrentWidth = formatPCIeWidth(v)
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "pcie.current_width",
-				Error:     fmt.Sprintf("failed to parse current PCIe width: %s", fields[2]),
-			})
-		}
+	if g.PCIeWidthCurrent > 0 {
+		info.CurrentWidth = formatPCIeWidth(g.PCIeWidthCurrent)
 	}
-
-	if len(fields) >= 4 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[3])); err == nil {
-			maxWidth = v
-			info.MaxWidth = formatPCIeWidth(v)
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "pcie.max_width",
-				Error:     fmt.Sprintf("failed to parse max PCIe width: %s", fields[3]),
-			})
-		}
+	if g.PCIeWidthMax > 0 {
+		info.MaxWidth = formatPCIeWidth(g.PCIeWidthMax)
+	}
+	if info.CurrentSpeed == "" && info.MaxSpeed == "" {
+		errs = append(errs, types.CollectorError{
+			Collector: "pcie.parse",
+			Error:     fmt.Sprintf("GPU %d: nvidia-smi did not report a PCIe link generation", g.Index),
+		})
 	}
 
 	// Downshifted if current < max on either speed or width
-	if (currentGen > 0 && maxGen > 0 && currentGen < maxGen) ||
-		(currentWidth > 0 && maxWidth > 0 && currentWidth < maxWidth) {
+	if (g.PCIeGenCurrent > 0 && g.PCIeGenMax > 0 && g.PCIeGenCurrent < g.PCIeGenMax) ||
+		(g.PCIeWidthCurrent > 0 && g.PCIeWidthMax > 0 && g.PCIeWidthCurrent < g.PCIeWidthMax) {
 		info.Downshifted = true
 	}
 
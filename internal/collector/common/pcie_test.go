@@ -2,6 +2,8 @@ package common
 
 import (
 	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
 )
 
 func TestFormatPCIeGen(t *testing.T) {
@@ -41,3 +43,27 @@ func TestFormatPCIeWidth(t *testing.T) {
 		}
 	}
 }
+
+func TestPCIeFromSMI(t *testing.T) {
+	info, errs := pcieFromSMI(nvsmi.GPU{PCIeGenCurrent: 1, PCIeGenMax: 4, PCIeWidthCurrent: 16, PCIeWidthMax: 16})
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if info.CurrentSpeed != "Gen1" || info.MaxSpeed != "Gen4" || info.CurrentWidth != "x16" || !info.Downshifted {
+		t.Errorf("info = %+v", info)
+	}
+
+	info, _ = pcieFromSMI(nvsmi.GPU{PCIeGenCurrent: 4, PCIeGenMax: 4, PCIeWidthCurrent: 16, PCIeWidthMax: 16})
+	if info.Downshifted {
+		t.Error("full-speed link reported as downshifted")
+	}
+}
+
+func TestShortBusID(t *testing.T) {
+	if got := shortBusID("00000000:01:00.0"); got != "01:00.0" {
+		t.Errorf("shortBusID(nvidia-smi form) = %q", got)
+	}
+	if got := shortBusID("01:00.0"); got != "01:00.0" {
+		t.Errorf("shortBusID(lspci form) = %q", got)
+	}
+}
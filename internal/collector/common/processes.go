@@ -0,0 +1,114 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// CollectGPUProcesses lists the processes on every GPU, keyed by nvidia-smi
+// bus ID. Compute processes come from nvidia-smi --query-compute-apps;
+// graphics processes, which that query leaves out, from the run's shared
+// nvidia-smi -q -x query. On Linux each process is looked up in /proc.
+//
+// The process lookup always reads the live /proc, even under --sysroot:
+// nvidia-smi reports this host's PIDs, which a captured tree does not hold.
+func CollectGPUProcesses(ctx context.Context, smi *nvsmi.Cache, timeout int) (map[string][]types.GPUProcess, []types.CollectorError) {
+	var errs []types.CollectorError
+
+	if !util.CommandExists("nvidia-smi") {
+		errs = append(errs, types.CollectorError{
+			Collector: "processes",
+			Error:     "nvidia-smi not found in PATH",
+			Fatal:     true,
+		})
+		return nil, errs
+	}
+
+	log, err := smi.Get(ctx, timeout)
+	if err != nil {
+		errs = append(errs, types.CollectorError{
+			Collector: "processes.query",
+			Error:     fmt.Sprintf("nvidia-smi query failed: %v", err),
+			Fatal:     true,
+		})
+		return nil, errs
+	}
+
+	apps, err := nvsmi.QueryComputeApps(ctx, timeout)
+	if err != nil {
+		// -q -x still lists compute processes, just without the dedicated query
+		errs = append(errs, types.CollectorError{
+			Collector: "processes.compute-apps",
+			Error:     err.Error(),
+		})
+	}
+
+	procs := mergeGPUProcesses(log, apps)
+	if util.IsLinux() {
+		host := sysroot.Host()
+		for _, list := range procs {
+			for i := range list {
+				list[i].State = processState(host, list[i].PID)
+			}
+		}
+	}
+	return procs, errs
+}
+
+// mergeGPUProcesses combines the compute-apps query with the -q -x process
+// lists, keyed by bus ID. A process in both keeps the compute-apps memory
+// figure and the -q -x type.
+func mergeGPUProcesses(log *nvsmi.Log, apps []nvsmi.ComputeApp) map[string][]types.GPUProcess {
+	procs := map[string][]types.GPUProcess{}
+	for _, g := range log.GPUs {
+		procType := map[int]string{}
+		for _, p := range g.Processes {
+			procType[p.PID] = p.Type
+		}
+		seen := map[int]bool{}
+		for _, a := range apps {
+			if !sameBusID(a.BusID, g.BusID) {
+				continue
+			}
+			procs[g.BusID] = append(procs[g.BusID], types.GPUProcess{
+				PID: a.PID, Name: a.Name, Type: util.FirstNonEmpty(procType[a.PID], "C"), UsedMemoryMB: a.UsedMemoryMiB,
+			})
+			seen[a.PID] = true
+		}
+		for _, p := range g.Processes {
+			if !seen[p.PID] {
+				procs[g.BusID] = append(procs[g.BusID], types.GPUProcess{
+					PID: p.PID, Name: p.Name, Type: p.Type, UsedMemoryMB: p.UsedMemoryMiB,
+				})
+			}
+		}
+	}
+	return procs
+}
+
+// processState returns the state letter from /proc/<pid>/stat ("R", "S",
+// "D", "Z", ...), or "missing" when the PID is not in /proc: the process has
+// exited, or it lives in another PID namespace.
+func processState(fsys sysroot.FS, pid int) string {
+	data, err := fsys.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "missing"
+	}
+	// "pid (comm) S ppid ..."; comm may itself contain spaces and parentheses
+	stat := string(data)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return ""
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
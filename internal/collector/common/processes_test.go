@@ -0,0 +1,76 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+)
+
+func TestMergeGPUProcesses(t *testing.T) {
+	log := &nvsmi.Log{GPUs: []nvsmi.GPU{
+		{Index: 0, BusID: "00000000:01:00.0", Processes: []nvsmi.Process{
+			{PID: 2214, Name: "/usr/lib/xorg/Xorg", Type: "G", UsedMemoryMiB: 312},
+			{PID: 48311, Name: "python3", Type: "C+G", UsedMemoryMiB: 17000},
+		}},
+		{Index: 1, BusID: "00000000:41:00.0"},
+	}}
+	apps := []nvsmi.ComputeApp{
+		{BusID: "00000000:01:00.0", PID: 48311, Name: "/home/alice/venv/bin/python3", UsedMemoryMiB: 17850},
+		{BusID: "00000000:41:00.0", PID: 51002, Name: "python3", UsedMemoryMiB: 9000},
+	}
+
+	procs := mergeGPUProcesses(log, apps)
+	first := procs["00000000:01:00.0"]
+	if len(first) != 2 {
+		t.Fatalf("GPU 0 processes = %+v", first)
+	}
+	// compute-apps memory and name, -q -x type
+	if first[0].PID != 48311 || first[0].UsedMemoryMB != 17850 || first[0].Type != "C+G" || first[0].Name != "/home/alice/venv/bin/python3" {
+		t.Errorf("merged compute process = %+v", first[0])
+	}
+	if first[1].PID != 2214 || first[1].Type != "G" {
+		t.Errorf("graphics process = %+v", first[1])
+	}
+	if second := procs["00000000:41:00.0"]; len(second) != 1 || second[0].Type != "C" {
+		t.Errorf("GPU 1 processes = %+v", second)
+	}
+}
+
+func TestMergeGPUProcesses_MultiDomain(t *testing.T) {
+	log := &nvsmi.Log{GPUs: []nvsmi.GPU{
+		{Index: 0, BusID: "00000001:00:00.0"},
+		{Index: 1, BusID: "00000002:00:00.0"},
+	}}
+	apps := []nvsmi.ComputeApp{
+		{BusID: "00000002:00:00.0", PID: 7710, Name: "python3", UsedMemoryMiB: 4096},
+	}
+
+	procs := mergeGPUProcesses(log, apps)
+	if first := procs["00000001:00:00.0"]; len(first) != 0 {
+		t.Errorf("GPU 0 processes = %+v, want none", first)
+	}
+	if second := procs["00000002:00:00.0"]; len(second) != 1 || second[0].PID != 7710 {
+		t.Errorf("GPU 1 processes = %+v", second)
+	}
+}
+
+func TestProcessState(t *testing.T) {
+	fsys, err := sysroot.New("testdata/sysroot/gpu-procs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pid  int
+		want string
+	}{
+		{48311, "S"},
+		{2214, "Z"}, // comm with a space and parentheses
+		{60001, "missing"},
+	}
+	for _, tt := range tests {
+		if got := processState(fsys, tt.pid); got != tt.want {
+			t.Errorf("processState(%d) = %q, want %q", tt.pid, got, tt.want)
+		}
+	}
+}
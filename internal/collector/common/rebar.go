@@ -0,0 +1,210 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// legacyBAR1MB is the BAR1 size GPUs get without Resizable BAR.
+const legacyBAR1MB = 256
+
+// lspci only shows PCI capabilities to root.
+var checkReBARLspci = collector.Check{ID: "rebar.lspci", Needs: collector.PrivilegeAdmin, Affects: []string{"rebar-disabled"}}
+
+// CollectReBAR gathers the Resizable BAR state of every NVIDIA GPU, keyed by
+// PCI address ("0000:01:00.0"). The BAR1 size comes from the run's shared
+// nvidia-smi -q -x query; on Linux the sysfs resource file fills it in when
+// the driver is down and tells whether BAR1 sits above 4 GB, and the
+// capability from sysfs resource1_resize or lspci -vv tells whether the GPU
+// can resize it at all.
+//
+// Enabled is only set when the capability was seen with BAR1's current size.
+// BAR1 size alone does not tell: data-center GPUs such as the V100, T4 and
+// A100 have a large fixed BAR1 and no Resizable BAR capability.
+func CollectReBAR(ctx context.Context, fsys sysroot.FS, gate *collector.Gate, smi *nvsmi.Cache, gpus []types.GPUInfo, timeout int) (map[string]*types.ReBARInfo, []types.CollectorError) {
+	var errs []types.CollectorError
+	infos := map[string]*types.ReBARInfo{}
+	currentMB := map[string]int64{} // BAR1 size of GPUs with the capability
+	get := func(busID string) *types.ReBARInfo {
+		addr := PCIAddress(busID)
+		if infos[addr] == nil {
+			infos[addr] = &types.ReBARInfo{}
+		}
+		return infos[addr]
+	}
+
+	if util.CommandExists("nvidia-smi") {
+		if log, err := smi.Get(ctx, timeout); err != nil {
+			errs = append(errs, types.CollectorError{
+				Collector: "rebar.nvidia-smi",
+				Error:     fmt.Sprintf("nvidia-smi query failed: %v", err),
+			})
+		} else {
+			for _, g := range log.GPUs {
+				if g.BAR1TotalMiB > 0 {
+					info := get(g.BusID)
+					info.BAR1SizeMB = g.BAR1TotalMiB
+					info.BAR1UsedMB = g.BAR1UsedMiB
+					info.Sources = append(info.Sources, "nvidia-smi")
+				}
+			}
+		}
+	}
+
+	if util.IsLinux() {
+		lspci := util.CommandExists("lspci") && gate.Allow(checkReBARLspci)
+		for _, g := range gpus {
+			if !g.IsNVIDIA || g.PCIBusID == "" {
+				continue
+			}
+			addr := PCIAddress(g.PCIBusID)
+			dir := "/sys/bus/pci/devices/" + addr
+			var barMB int64
+			if data, err := fsys.ReadFile(dir + "/resource"); err == nil {
+				if sizeMB, start, ok := bar1FromResource(string(data)); ok {
+					info := get(addr)
+					if info.BAR1SizeMB == 0 {
+						info.BAR1SizeMB = sizeMB
+					}
+					info.Above4GDecoding = "disabled"
+					if start >= 1<<32 {
+						info.Above4GDecoding = "enabled"
+					}
+					info.Sources = append(info.Sources, "sysfs")
+					barMB = sizeMB
+				}
+			}
+			// The kernel only offers resizing for BARs the device can resize
+			if data, err := fsys.ReadFile(dir + "/resource1_resize"); err == nil {
+				info := get(addr)
+				info.Supported = true
+				info.BAR1MaxMB = maxResizeMB(string(data))
+				if barMB > 0 {
+					currentMB[addr] = barMB
+				}
+			}
+
+			if !lspci {
+				continue
+			}
+			r := util.RunCommandContext(ctx, timeout, "lspci", "-vv", "-s", addr)
+			if r.Err != nil {
+				errs = append(errs, types.CollectorError{
+					Collector: "rebar.lspci",
+					Error:     fmt.Sprintf("lspci -vv -s %s failed: %v", addr, r.Err),
+				})
+				continue
+			}
+			if strings.Contains(r.Stdout, "<access denied>") {
+				if gate.Denied(checkReBARLspci) {
+					lspci = false
+				}
+				continue
+			}
+			if supported, curMB, maxMB := parseReBARCapability(r.Stdout); supported {
+				info := get(addr)
+				info.Supported = true
+				if maxMB > 0 {
+					info.BAR1MaxMB = maxMB
+				}
+				if curMB > 0 {
+					currentMB[addr] = curMB
+				}
+				info.Sources = append(info.Sources, "lspci")
+			}
+		}
+	}
+
+	for addr, mb := range currentMB {
+		enabled := mb > legacyBAR1MB
+		infos[addr].Enabled = &enabled
+	}
+	return infos, errs
+}
+
+// maxResizeMB returns the largest BAR1 size in a sysfs resource1_resize
+// file: a hex bitmask of the sizes the device supports, bit n for 1 MB << n.
+func maxResizeMB(data string) int64 {
+	mask, err := strconv.ParseUint(strings.TrimSpace(data), 16, 64)
+	if err != nil || mask == 0 {
+		return 0
+	}
+	n := 63
+	for mask&(1<<uint(n)) == 0 {
+		n--
+	}
+	return 1 << uint(n)
+}
+
+// bar1FromResource reads BAR1's size in MB and start address from a sysfs
+// resource file, one "start end flags" line per BAR in hex. An unassigned
+// BAR is all zeros.
+func bar1FromResource(data string) (sizeMB int64, start uint64, ok bool) {
+	lines := strings.Split(data, "\n")
+	if len(lines) < 2 {
+		return 0, 0, false
+	}
+	fields := strings.Fields(lines[1])
+	if len(fields) < 2 {
+		return 0, 0, false
+	}
+	start, errStart := strconv.ParseUint(fields[0], 0, 64)
+	end, errEnd := strconv.ParseUint(fields[1], 0, 64)
+	if errStart != nil || errEnd != nil || end <= start {
+		return 0, 0, false
+	}
+	return int64((end - start + 1) >> 20), start, true
+}
+
+// rebarSizeRe matches a size in lspci's Resizable BAR lines, e.g. "256MB".
+var rebarSizeRe = regexp.MustCompile(`(\d+)([MGT]B)`)
+
+// parseReBARCapability looks for the Resizable BAR capability in lspci -vv
+// output for one device and returns BAR1's current size and the largest size
+// it supports:
+//
+//	Capabilities: [bb0 v1] Physical Resizable BAR
+//		BAR 1: current size: 256MB, supported: 64MB 128MB 256MB ... 32GB
+func parseReBARCapability(out string) (supported bool, currentMB, maxMB int64) {
+	inCap := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Capabilities:"):
+			inCap = strings.Contains(line, "Resizable BAR")
+			supported = supported || inCap
+		case inCap && strings.HasPrefix(line, "BAR 1:"):
+			current, sizes, _ := strings.Cut(line, "supported:")
+			if m := rebarSizeRe.FindStringSubmatch(current); m != nil {
+				currentMB = rebarSizeMB(m)
+			}
+			for _, m := range rebarSizeRe.FindAllStringSubmatch(sizes, -1) {
+				if n := rebarSizeMB(m); n > maxMB {
+					maxMB = n
+				}
+			}
+		}
+	}
+	return supported, currentMB, maxMB
+}
+
+// rebarSizeMB converts a rebarSizeRe match to MB.
+func rebarSizeMB(m []string) int64 {
+	n, _ := strconv.ParseInt(m[1], 10, 64)
+	switch m[2] {
+	case "GB":
+		n <<= 10
+	case "TB":
+		n <<= 20
+	}
+	return n
+}
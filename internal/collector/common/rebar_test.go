@@ -0,0 +1,63 @@
+package common
+
+import "testing"
+
+func TestBar1FromResource(t *testing.T) {
+	// BAR0 registers, BAR1 resized to 32 GB above 4 GB, BAR3 32 MB
+	resource := "0x00000000fb000000 0x00000000fbffffff 0x0000000000040200\n" +
+		"0x0000006000000000 0x00000067ffffffff 0x000000000014220c\n" +
+		"0x0000000000000000 0x0000000000000000 0x0000000000000000\n" +
+		"0x0000006800000000 0x0000006801ffffff 0x000000000014220c\n"
+	size, start, ok := bar1FromResource(resource)
+	if !ok || size != 32768 || start < 1<<32 {
+		t.Errorf("got size %d start %#x ok %v", size, start, ok)
+	}
+
+	legacy := "0x00000000de000000 0x00000000deffffff 0x0000000000040200\n" +
+		"0x00000000c0000000 0x00000000cfffffff 0x000000000014220c\n"
+	if size, start, ok := bar1FromResource(legacy); !ok || size != 256 || start >= 1<<32 {
+		t.Errorf("got size %d start %#x ok %v", size, start, ok)
+	}
+
+	unassigned := "0x00000000de000000 0x00000000deffffff 0x0000000000040200\n" +
+		"0x0000000000000000 0x0000000000000000 0x0000000000000000\n"
+	if _, _, ok := bar1FromResource(unassigned); ok {
+		t.Error("expected no BAR1 when it is unassigned")
+	}
+}
+
+func TestParseReBARCapability(t *testing.T) {
+	out := `01:00.0 VGA compatible controller: NVIDIA Corporation AD102 [GeForce RTX 4090] (rev a1) (prog-if 00 [VGA controller])
+	Region 0: Memory at fb000000 (32-bit, non-prefetchable) [size=16M]
+	Region 1: Memory at c0000000 (64-bit, prefetchable) [size=256M]
+	Capabilities: [bb0 v1] Physical Resizable BAR
+		BAR 0: current size: 16MB, supported: 16MB
+		BAR 1: current size: 256MB, supported: 64MB 128MB 256MB 512MB 1GB 2GB 4GB 8GB 16GB 32GB
+		BAR 3: current size: 32MB, supported: 32MB
+	Capabilities: [c1c v1] Physical Layer 16.0 GT/s <?>
+	Kernel driver in use: nvidia
+`
+	supported, currentMB, maxMB := parseReBARCapability(out)
+	if !supported || currentMB != 256 || maxMB != 32768 {
+		t.Errorf("got supported %v current %d MB max %d MB", supported, currentMB, maxMB)
+	}
+
+	old := `01:00.0 VGA compatible controller: NVIDIA Corporation GP104 [GeForce GTX 1080] (rev a1)
+	Capabilities: [250 v1] Latency Tolerance Reporting
+	Capabilities: [900 v1] Secondary PCI Express
+	Kernel driver in use: nvidia
+`
+	if supported, _, maxMB := parseReBARCapability(old); supported || maxMB != 0 {
+		t.Errorf("expected no ReBAR capability, got %v %d", supported, maxMB)
+	}
+}
+
+func TestMaxResizeMB(t *testing.T) {
+	// 64 MB to 32 GB: bits 6 to 15
+	if got := maxResizeMB("000000000000ffc0\n"); got != 32768 {
+		t.Errorf("maxResizeMB = %d, want 32768", got)
+	}
+	if got := maxResizeMB("0000000000000000\n"); got != 0 {
+		t.Errorf("maxResizeMB of an empty mask = %d, want 0", got)
+	}
+}
@@ -0,0 +1,144 @@
+package common
+
+import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func init() {
+	collector.Register(collector.Spec{
+		ID: "system",
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := CollectSystemInfo(ctx, env.FS, env.Config.Timeout)
+			r.System = info
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID: "gpu",
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			gpus, driver, errs := CollectGPUInfo(ctx, env.SMI, env.Config.Timeout, env.Knowledge)
+			r.GPUs = gpus
+			r.Driver = driver
+			return errs
+		},
+	})
+
+	// thermal, pcie, memory-health, processes and rebar attach their data to
+	// the GPUs the gpu collector found
+	collector.Register(collector.Spec{
+		ID:   "thermal",
+		Deps: []string{"gpu"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			infos, errs := CollectThermalInfo(ctx, env.SMI, env.Config.Timeout)
+			for _, info := range infos {
+				if info.TemperatureC == 0 && info.PowerState == "" {
+					continue
+				}
+				if r.Thermal == nil {
+					first := info
+					r.Thermal = &first
+				}
+				if gpu := gpuByBusID(r.GPUs, info.PCIBusID); gpu != nil {
+					t := info
+					gpu.Thermal = &t
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:   "pcie",
+		Deps: []string{"gpu"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			infos, errs := CollectPCIeInfo(ctx, env.SMI, env.Config.Timeout)
+			for _, info := range infos {
+				if info.CurrentSpeed == "" && info.MaxSpeed == "" {
+					continue
+				}
+				if r.PCIe == nil {
+					first := info
+					r.PCIe = &first
+				}
+				if gpu := gpuByBusID(r.GPUs, info.PCIBusID); gpu != nil {
+					p := info
+					gpu.PCIe = &p
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:   "memory-health",
+		Deps: []string{"gpu"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			infos, errs := CollectMemoryHealth(ctx, env.SMI, env.Config.Timeout)
+			for _, info := range infos {
+				if gpu := gpuByBusID(r.GPUs, info.PCIBusID); gpu != nil {
+					m := info
+					gpu.MemoryHealth = &m
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:   "processes",
+		Deps: []string{"gpu"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			procs, errs := CollectGPUProcesses(ctx, env.SMI, env.Config.Timeout)
+			for busID, list := range procs {
+				if gpu := gpuByBusID(r.GPUs, busID); gpu != nil {
+					gpu.Processes = list
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:     "rebar",
+		Deps:   []string{"gpu"},
+		Checks: []collector.Check{checkReBARLspci},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			infos, errs := CollectReBAR(ctx, env.FS, env.Gate, env.SMI, r.GPUs, env.Config.Timeout)
+			for addr, info := range infos {
+				if gpu := gpuByBusID(r.GPUs, addr); gpu != nil {
+					gpu.ReBAR = info
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "network",
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeStreaming, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := CollectNetworkInfo(ctx, env.FS, env.Config.Timeout)
+			if info.InterfaceName != "" {
+				r.Network = &info
+			}
+			return errs
+		},
+	})
+}
+
+// gpuByBusID returns the GPU at the given PCI bus ID, if any.
+func gpuByBusID(gpus []types.GPUInfo, busID string) *types.GPUInfo {
+	if busID == "" {
+		return nil
+	}
+	for i := range gpus {
+		if sameBusID(gpus[i].PCIBusID, busID) {
+			return &gpus[i]
+		}
+	}
+	return nil
+}
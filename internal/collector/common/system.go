@@ -2,17 +2,19 @@
 package common
 
 import (
+	"context"
 	"os"
 	"runtime"
 	"strings"
 	"time"
 
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // CollectSystemInfo gathers universal system snapshot data.
-func CollectSystemInfo(timeout int) (types.SystemInfo, []types.CollectorError) {
+func CollectSystemInfo(ctx context.Context, fsys sysroot.FS, timeout int) (types.SystemInfo, []types.CollectorError) {
 	var info types.SystemInfo
 	var errs []types.CollectorError
 
@@ -27,66 +29,66 @@ func CollectSystemInfo(timeout int) (types.SystemInfo, []types.CollectorError) {
 	info.Timezone = time.Now().Location().String()
 
 	if util.IsWindows() {
-		collectWindowsSystem(&info, &errs, timeout)
+		collectWindowsSystem(ctx, &info, &errs, timeout)
 	} else if util.IsLinux() {
-		collectLinuxSystem(&info, &errs, timeout)
+		collectLinuxSystem(ctx, fsys, &info, &errs, timeout)
 	}
 
 	return info, errs
 }
 
-func collectWindowsSystem(info *types.SystemInfo, errs *[]types.CollectorError, timeout int) {
+func collectWindowsSystem(ctx context.Context, info *types.SystemInfo, errs *[]types.CollectorError, timeout int) {
 	info.OSName = "Windows"
 
 	// Get OS version via PowerShell
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"(Get-CimInstance Win32_OperatingSystem).Caption")
 	if r.Err == nil && r.Stdout != "" {
 		info.OSName = strings.TrimSpace(r.Stdout)
 	}
 
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"(Get-CimInstance Win32_OperatingSystem).Version")
 	if r.Err == nil {
 		info.OSVersion = strings.TrimSpace(r.Stdout)
 	}
 
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"(Get-CimInstance Win32_OperatingSystem).BuildNumber")
 	if r.Err == nil {
 		info.OSBuild = strings.TrimSpace(r.Stdout)
 	}
 
 	// CPU
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"(Get-CimInstance Win32_Processor).Name")
 	if r.Err == nil {
 		info.CPUModel = strings.TrimSpace(r.Stdout)
 	}
 
 	// RAM (total in MB)
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"[math]::Round((Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory / 1MB)")
 	if r.Err == nil {
 		info.RAMTotalMB = parseIntSafe(r.Stdout)
 	}
 
 	// Storage free on system drive
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"[math]::Round((Get-PSDrive C).Free / 1MB)")
 	if r.Err == nil {
 		info.StorageFreeMB = parseIntSafe(r.Stdout)
 	}
 
 	// Uptime
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"$up = (Get-Date) - (Get-CimInstance Win32_OperatingSystem).LastBootUpTime; \"$($up.Days)d $($up.Hours)h $($up.Minutes)m\"")
 	if r.Err == nil {
 		info.Uptime = strings.TrimSpace(r.Stdout)
 	}
 
 	// Boot mode / Secure Boot
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		"try { Confirm-SecureBootUEFI } catch { 'Unknown' }")
 	if r.Err == nil {
 		val := strings.TrimSpace(r.Stdout)
@@ -103,11 +105,10 @@ func collectWindowsSystem(info *types.SystemInfo, errs *[]types.CollectorError,
 	}
 }
 
-func collectLinuxSystem(info *types.SystemInfo, errs *[]types.CollectorError, timeout int) {
+func collectLinuxSystem(ctx context.Context, fsys sysroot.FS, info *types.SystemInfo, errs *[]types.CollectorError, timeout int) {
 	// Parse /etc/os-release
-	r := util.RunCommand(timeout, "cat", "/etc/os-release")
-	if r.Err == nil {
-		for _, line := range strings.Split(r.Stdout, "\n") {
+	if data, err := fsys.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
 			k, v := util.ParseKeyValue(line, "=")
 			v = strings.Trim(v, "\"")
 			switch k {
@@ -122,61 +123,74 @@ func collectLinuxSystem(info *types.SystemInfo, errs *[]types.CollectorError, ti
 			}
 		}
 	} else {
-		*errs = append(*errs, types.CollectorError{Collector: "system.os-release", Error: r.Err.Error()})
+		*errs = append(*errs, types.CollectorError{Collector: "system.os-release", Error: err.Error()})
 	}
 
 	// Kernel version
-	r = util.RunCommand(timeout, "uname", "-r")
+	r := util.RunCommandContext(ctx, timeout, "uname", "-r")
 	if r.Err == nil {
 		info.KernelVersion = strings.TrimSpace(r.Stdout)
 	}
 
 	// CPU
-	r = util.RunCommand(timeout, "sh", "-c", `grep -m1 "model name" /proc/cpuinfo | cut -d: -f2`)
-	if r.Err == nil {
-		info.CPUModel = strings.TrimSpace(r.Stdout)
+	if data, err := fsys.ReadFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if k, v := util.ParseKeyValue(line, ":"); k == "model name" {
+				info.CPUModel = v
+				break
+			}
+		}
 	}
 
 	// RAM
-	r = util.RunCommand(timeout, "sh", "-c", `grep MemTotal /proc/meminfo | awk '{print int($2/1024)}'`)
-	if r.Err == nil {
-		info.RAMTotalMB = parseIntSafe(r.Stdout)
+	if data, err := fsys.ReadFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if k, v := util.ParseKeyValue(line, ":"); k == "MemTotal" {
+				info.RAMTotalMB = parseIntSafe(v) / 1024
+				break
+			}
+		}
 	}
 
 	// Storage
-	r = util.RunCommand(timeout, "sh", "-c", `df -m / | tail -1 | awk '{print $4}'`)
+	r = util.RunCommandContext(ctx, timeout, "sh", "-c", `df -m / | tail -1 | awk '{print $4}'`)
 	if r.Err == nil {
 		info.StorageFreeMB = parseIntSafe(r.Stdout)
 	}
 
 	// Uptime
-	r = util.RunCommand(timeout, "uptime", "-p")
+	r = util.RunCommandContext(ctx, timeout, "uptime", "-p")
 	if r.Err == nil {
 		info.Uptime = strings.TrimSpace(r.Stdout)
 	}
 
 	// Boot mode
-	if _, err := os.Stat("/sys/firmware/efi"); err == nil {
+	if _, err := fsys.Stat("/sys/firmware/efi"); err == nil {
 		info.BootMode = "UEFI"
-		// Secure Boot
-		r = util.RunCommand(timeout, "sh", "-c",
-			`mokutil --sb-state 2>/dev/null || echo "Unknown"`)
-		if r.Err == nil {
-			out := strings.TrimSpace(r.Stdout)
-			if strings.Contains(out, "enabled") {
-				info.SecureBoot = "Enabled"
-			} else if strings.Contains(out, "disabled") {
-				info.SecureBoot = "Disabled"
-			} else {
-				info.SecureBoot = "Unknown"
-			}
-		}
+		info.SecureBoot = secureBootState(fsys)
 	} else {
 		info.BootMode = "Legacy/BIOS"
 		info.SecureBoot = "N/A"
 	}
 }
 
+// secureBootVar is the EFI global variable holding the Secure Boot state. Like
+// all of efivarfs it is world-readable, so no mokutil or root is needed.
+const secureBootVar = "/sys/firmware/efi/efivars/SecureBoot-8be4df61-93ca-11d2-aa0d-e39834ca3d01"
+
+// secureBootState reads the Secure Boot state from its EFI variable: four
+// bytes of attributes followed by the value, 1 when enabled.
+func secureBootState(fsys sysroot.FS) string {
+	data, err := fsys.ReadFile(secureBootVar)
+	if err != nil || len(data) < 5 {
+		return "Unknown"
+	}
+	if data[4] == 1 {
+		return "Enabled"
+	}
+	return "Disabled"
+}
+
 func parseIntSafe(s string) int64 {
 	s = strings.TrimSpace(s)
 	var n int64
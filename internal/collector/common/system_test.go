@@ -0,0 +1,41 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+)
+
+func TestSecureBootState(t *testing.T) {
+	tests := []struct {
+		name  string
+		value []byte
+		want  string
+	}{
+		{"enabled", []byte{0x06, 0x00, 0x00, 0x00, 0x01}, "Enabled"},
+		{"disabled", []byte{0x06, 0x00, 0x00, 0x00, 0x00}, "Disabled"},
+		{"truncated", []byte{0x06, 0x00}, "Unknown"},
+		{"missing", nil, "Unknown"},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		if tt.value != nil {
+			path := filepath.Join(root, secureBootVar)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.value, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		fsys, err := sysroot.New(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := secureBootState(fsys); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
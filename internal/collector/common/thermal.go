@@ -1,17 +1,18 @@
 package common
 
 import (
+	"context"
 	"fmt"
-	"strconv"
-	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
-// CollectThermalInfo gathers GPU thermal, power state, and clock data via nvidia-smi.
-func CollectThermalInfo(timeout int) (types.ThermalInfo, []types.CollectorError) {
-	var info types.ThermalInfo
+// CollectThermalInfo gathers thermal, power state, and clock data for every
+// GPU in the run's shared nvidia-smi -q -x query, in nvidia-smi order.
+func CollectThermalInfo(ctx context.Context, smi *nvsmi.Cache, timeout int) ([]types.ThermalInfo, []types.CollectorError) {
+	var infos []types.ThermalInfo
 	var errs []types.CollectorError
 
 	if !util.CommandExists("nvidia-smi") {
@@ -20,129 +21,121 @@ func CollectThermalInfo(timeout int) (types.ThermalInfo, []types.CollectorError)
 			Error:     "nvidia-smi not found in PATH",
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
 
-	// Query thermal, power state, clocks, power limits, fan speed
-	r := util.RunCommand(timeout, "nvidia-smi",
-		"--query-gpu=temperature.gpu,power.state,clocks.current.graphics,clocks.max.graphics,power.limit,power.draw,fan.speed",
-		"--format=csv,noheader,nounits")
-	if r.Err != nil {
+	log, err := smi.Get(ctx, timeout)
+	if err != nil {
 		errs = append(errs, types.CollectorError{
 			Collector: "thermal.query",
-			Error:     fmt.Sprintf("nvidia-smi thermal query failed: %v", r.Err),
+			Error:     fmt.Sprintf("nvidia-smi thermal query failed: %v", err),
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
-
-	// Parse first line of CSV output (first GPU)
-	line := strings.TrimSpace(r.Stdout)
-	if line == "" {
+	if len(log.GPUs) == 0 {
 		errs = append(errs, types.CollectorError{
 			Collector: "thermal.parse",
-			Error:     "nvidia-smi thermal query returned empty output",
+			Error:     "nvidia-smi reported no GPUs",
 			Fatal:     true,
 		})
-		return info, errs
+		return infos, errs
 	}
 
-	// Take only the first GPU line if there are multiple
-	lines := strings.Split(line, "\n")
-	fields := strings.Split(lines[0], ", ")
-
-	if len(fields) >= 1 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[0])); err == nil {
-			info.TemperatureC = v
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "thermal.temperature",
-				Error:     fmt.Sprintf("failed to parse temperature: %s", fields[0]),
-			})
-		}
+	for _, g := range log.GPUs {
+		info, gpuErrs := thermalFromSMI(g)
+		infos = append(infos, info)
+		errs = append(errs, gpuErrs...)
 	}
+	return infos, errs
+}
 
-	if len(fields) >= 2 {
-		info.PowerState = strings.TrimSpace(fields[1])
+// thermalFromSMI fills ThermalInfo from one GPU of the nvidia-smi query.
+func thermalFromSMI(g nvsmi.GPU) (types.ThermalInfo, []types.CollectorError) {
+	var errs []types.CollectorError
+	info := types.ThermalInfo{
+		GPUIndex:        g.Index,
+		PCIBusID:        g.BusID,
+		TemperatureC:    g.TemperatureC,
+		PowerState:      g.PerformanceState,
+		CurrentClockMHz: g.GraphicsClockMHz,
+		MaxClockMHz:     g.MaxGraphicsClockMHz,
+
+		SlowdownTempC:     g.SlowdownTempC,
+		ShutdownTempC:     g.ShutdownTempC,
+		MaxOperatingTempC: g.MaxOperatingTempC,
 	}
-
-	if len(fields) >= 3 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[2])); err == nil {
-			info.CurrentClockMHz = v
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "thermal.current_clock",
-				Error:     fmt.Sprintf("failed to parse current clock: %s", fields[2]),
-			})
-		}
+	if g.TLimitKnown {
+		margin := g.TLimitMarginC
+		info.TLimitMarginC = &margin
 	}
 
-	if len(fields) >= 4 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[3])); err == nil {
-			info.MaxClockMHz = v
-		} else {
-			errs = append(errs, types.CollectorError{
-				Collector: "thermal.max_clock",
-				Error:     fmt.Sprintf("failed to parse max clock: %s", fields[3]),
-			})
-		}
+	if g.TemperatureC == 0 {
+		errs = append(errs, types.CollectorError{
+			Collector: "thermal.temperature",
+			Error:     fmt.Sprintf("GPU %d: nvidia-smi did not report a temperature", g.Index),
+		})
 	}
-
-	if len(fields) >= 5 {
-		info.PowerLimitW = strings.TrimSpace(fields[4])
+	if g.PowerLimitW > 0 {
+		info.PowerLimitW = fmt.Sprintf("%.2f", g.PowerLimitW)
 	}
-
-	if len(fields) >= 6 {
-		info.PowerDrawW = strings.TrimSpace(fields[5])
+	if g.DefaultPowerLimitW > 0 {
+		info.DefaultPowerLimitW = fmt.Sprintf("%.2f", g.DefaultPowerLimitW)
+	}
+	if g.PowerDrawW > 0 {
+		info.PowerDrawW = fmt.Sprintf("%.2f", g.PowerDrawW)
 	}
 
-	if len(fields) >= 7 {
-		if v, err := strconv.Atoi(strings.TrimSpace(fields[6])); err == nil {
-			info.FanSpeedPct = v
-		} else {
-			// Fan speed may report "[Not Supported]" on some GPUs (e.g., laptop)
-			errs = append(errs, types.CollectorError{
-				Collector: "thermal.fan_speed",
-				Error:     fmt.Sprintf("failed to parse fan speed: %s", fields[6]),
-			})
-		}
+	info.FanSpeedPct = g.FanSpeedPct
+	if g.FanSpeedPct < 0 {
+		// Fan speed is N/A on some GPUs (e.g., laptop)
+		errs = append(errs, types.CollectorError{
+			Collector: "thermal.fan_speed",
+			Error:     fmt.Sprintf("GPU %d: nvidia-smi did not report a fan speed", g.Index),
+		})
 	}
 
-	// Query clock event reasons for slowdown detection
-	r = util.RunCommand(timeout, "nvidia-smi",
-		"--query-gpu=clocks_event_reasons.active",
-		"--format=csv,noheader")
-	if r.Err != nil {
+	if !g.ClockEventReasonsKnown {
 		errs = append(errs, types.CollectorError{
 			Collector: "thermal.slowdown",
-			Error:     fmt.Sprintf("nvidia-smi clocks_event_reasons query failed: %v", r.Err),
+			Error:     fmt.Sprintf("GPU %d: nvidia-smi did not report clock event reasons", g.Index),
 		})
 	} else {
-		reason := strings.TrimSpace(r.Stdout)
-		// Take only first GPU line
-		if idx := strings.Index(reason, "\n"); idx >= 0 {
-			reason = strings.TrimSpace(reason[:idx])
-		}
-		info.SlowdownReason = reason
+		// Same format as the clocks_event_reasons.active query field
+		info.SlowdownReason = fmt.Sprintf("0x%016X", g.ClockEventReasons)
+		info.SlowdownReasons = nvsmi.ReasonNames(g.ClockEventReasons)
 
-		// Any non-zero / non-"Not Active" / non-empty value indicates throttling
-		reasonLower := strings.ToLower(reason)
-		if reason != "" && reason != "0" && reason != "0x0000000000000000" &&
-			!strings.Contains(reasonLower, "not active") &&
-			reasonLower != "none" {
+		// An idle GPU running at low clocks is not slowed down
+		if g.ClockEventReasons&^nvsmi.ReasonGPUIdle != 0 {
 			info.SlowdownActive = true
 		}
-
-		// Determine thermal throttle: temp >= 85 or slowdown reason mentions thermal
-		if info.TemperatureC >= 85 || strings.Contains(reasonLower, "thermal") {
+		if g.ClockEventReasons&(nvsmi.ReasonSWThermalSlowdown|nvsmi.ReasonHWThermalSlowdown) != 0 {
 			info.ThermalThrottle = true
 		}
 	}
 
-	// If we could not query slowdown reasons, still check temperature threshold
-	if info.TemperatureC >= 85 {
+	// Thermal throttle at this GPU's own limit even without a thermal reason
+	if g.TLimitKnown && g.TLimitMarginC <= 0 {
+		info.ThermalThrottle = true
+	}
+	if info.TemperatureC > 0 && info.TemperatureC >= throttleTempC(g) {
 		info.ThermalThrottle = true
 	}
 
 	return info, errs
 }
+
+// defaultThrottleTempC is used for GPUs that report no temperature limits.
+const defaultThrottleTempC = 85
+
+// throttleTempC returns the temperature at which g starts lowering clocks:
+// its max operating temperature, else its slowdown threshold.
+func throttleTempC(g nvsmi.GPU) int {
+	switch {
+	case g.MaxOperatingTempC > 0:
+		return g.MaxOperatingTempC
+	case g.SlowdownTempC > 0:
+		return g.SlowdownTempC
+	}
+	return defaultThrottleTempC
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+)
+
+func TestThermalFromSMI(t *testing.T) {
+	g := nvsmi.GPU{
+		TemperatureC:           71,
+		PerformanceState:       "P2",
+		GraphicsClockMHz:       2610,
+		MaxGraphicsClockMHz:    3120,
+		PowerDrawW:             410.55,
+		PowerLimitW:            450,
+		DefaultPowerLimitW:     450,
+		FanSpeedPct:            42,
+		ClockEventReasons:      nvsmi.ReasonSWPowerCap,
+		ClockEventReasonsKnown: true,
+	}
+	info, errs := thermalFromSMI(g)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if info.PowerState != "P2" || info.PowerDrawW != "410.55" || info.PowerLimitW != "450.00" || info.DefaultPowerLimitW != "450.00" || info.FanSpeedPct != 42 {
+		t.Errorf("info = %+v", info)
+	}
+	if info.SlowdownReason != "0x0000000000000004" || !info.SlowdownActive || info.ThermalThrottle {
+		t.Errorf("slowdown = %q active=%v thermal=%v", info.SlowdownReason, info.SlowdownActive, info.ThermalThrottle)
+	}
+	if len(info.SlowdownReasons) != 1 || info.SlowdownReasons[0] != "sw_power_cap" {
+		t.Errorf("SlowdownReasons = %v, want [sw_power_cap]", info.SlowdownReasons)
+	}
+}
+
+func TestThermalFromSMI_IdleIsNotSlowdown(t *testing.T) {
+	g := nvsmi.GPU{TemperatureC: 38, FanSpeedPct: 30, ClockEventReasons: nvsmi.ReasonGPUIdle, ClockEventReasonsKnown: true}
+	info, _ := thermalFromSMI(g)
+	if info.SlowdownActive {
+		t.Error("an idle GPU should not be reported as slowed down")
+	}
+}
+
+func TestThermalFromSMI_ThermalReasonAndMissingFan(t *testing.T) {
+	g := nvsmi.GPU{
+		TemperatureC:           80,
+		FanSpeedPct:            -1,
+		ClockEventReasons:      nvsmi.ReasonHWSlowdown | nvsmi.ReasonHWThermalSlowdown,
+		ClockEventReasonsKnown: true,
+	}
+	info, errs := thermalFromSMI(g)
+	if !info.ThermalThrottle || !info.SlowdownActive {
+		t.Errorf("thermal=%v active=%v, want both set", info.ThermalThrottle, info.SlowdownActive)
+	}
+	if len(errs) != 1 || errs[0].Collector != "thermal.fan_speed" {
+		t.Errorf("errs = %v, want one thermal.fan_speed error", errs)
+	}
+}
+
+func TestThermalFromSMI_OwnLimits(t *testing.T) {
+	// A laptop GPU at 87°C with a 102°C slowdown threshold is not throttling
+	g := nvsmi.GPU{TemperatureC: 87, FanSpeedPct: -1, SlowdownTempC: 102, ShutdownTempC: 105, ClockEventReasonsKnown: true}
+	info, _ := thermalFromSMI(g)
+	if info.ThermalThrottle {
+		t.Error("87°C is below this GPU's limits and should not count as throttling")
+	}
+	if info.SlowdownTempC != 102 || info.ShutdownTempC != 105 || info.TLimitMarginC != nil {
+		t.Errorf("limits = %+v", info)
+	}
+
+	// A desktop GPU past its max operating temperature is
+	g = nvsmi.GPU{TemperatureC: 84, FanSpeedPct: 80, MaxOperatingTempC: 83, SlowdownTempC: 89, TLimitMarginC: -1, TLimitKnown: true, ClockEventReasonsKnown: true}
+	info, _ = thermalFromSMI(g)
+	if !info.ThermalThrottle || info.TLimitMarginC == nil || *info.TLimitMarginC != -1 {
+		t.Errorf("expected throttling past the max operating temperature, got %+v", info)
+	}
+}
@@ -3,6 +3,7 @@
 package linux
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,20 +14,20 @@ import (
 
 // CollectDisplayInfo gathers display/monitor information on Linux by parsing
 // xrandr output. Falls back to wlr-randr for Wayland sessions.
-func CollectDisplayInfo(timeout int) ([]types.DisplayInfo, []types.CollectorError) {
+func CollectDisplayInfo(ctx context.Context, timeout int) ([]types.DisplayInfo, []types.CollectorError) {
 	var displays []types.DisplayInfo
 	var errs []types.CollectorError
 
 	// Try xrandr first (works on X11 and some Wayland compositors via XWayland)
 	if util.CommandExists("xrandr") {
-		d, e := parseXrandr(timeout)
+		d, e := parseXrandr(ctx, timeout)
 		displays = append(displays, d...)
 		errs = append(errs, e...)
 	}
 
 	// If xrandr yielded nothing, try wlr-randr as a Wayland fallback
 	if len(displays) == 0 && util.CommandExists("wlr-randr") {
-		d, e := parseWlrRandr(timeout)
+		d, e := parseWlrRandr(ctx, timeout)
 		displays = append(displays, d...)
 		errs = append(errs, e...)
 	}
@@ -55,11 +56,11 @@ func CollectDisplayInfo(timeout int) ([]types.DisplayInfo, []types.CollectorErro
 }
 
 // parseXrandr runs xrandr --query and parses connected outputs.
-func parseXrandr(timeout int) ([]types.DisplayInfo, []types.CollectorError) {
+func parseXrandr(ctx context.Context, timeout int) ([]types.DisplayInfo, []types.CollectorError) {
 	var displays []types.DisplayInfo
 	var errs []types.CollectorError
 
-	r := util.RunCommand(timeout, "xrandr", "--query")
+	r := util.RunCommandContext(ctx, timeout, "xrandr", "--query")
 	if r.Err != nil {
 		errs = append(errs, types.CollectorError{
 			Collector: "linux.display.xrandr",
@@ -142,11 +143,11 @@ func parseXrandr(timeout int) ([]types.DisplayInfo, []types.CollectorError) {
 
 // parseWlrRandr runs wlr-randr and parses its output as a fallback for
 // Wayland compositors that support the wlr-output-management protocol.
-func parseWlrRandr(timeout int) ([]types.DisplayInfo, []types.CollectorError) {
+func parseWlrRandr(ctx context.Context, timeout int) ([]types.DisplayInfo, []types.CollectorError) {
 	var displays []types.DisplayInfo
 	var errs []types.CollectorError
 
-	r := util.RunCommand(timeout, "wlr-randr")
+	r := util.RunCommandContext(ctx, timeout, "wlr-randr")
 	if r.Err != nil {
 		errs = append(errs, types.CollectorError{
 			Collector: "linux.display.wlr-randr",
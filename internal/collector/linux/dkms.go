@@ -0,0 +1,222 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// parseDKMSStatus parses dkms status output into one entry per module,
+// version and kernel. It reads both the DKMS 3 format and the older one:
+//
+//	nvidia/550.54.14, 6.5.0-27-generic, x86_64: installed
+//	nvidia, 470.82.00, 5.4.0-90-generic, x86_64: installed (WARNING! ...)
+//	nvidia/550.54.14: added
+func parseDKMSStatus(out string) []types.DKMSModule {
+	var mods []types.DKMSModule
+	for _, line := range strings.Split(out, "\n") {
+		i := strings.LastIndex(line, ": ")
+		if i < 0 {
+			continue
+		}
+		var fields []string
+		for _, f := range strings.Split(line[:i], ",") {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+		// DKMS 3 joins module and version with a slash
+		if name, version, ok := strings.Cut(fields[0], "/"); ok {
+			fields = append([]string{name, version}, fields[1:]...)
+		}
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			continue
+		}
+		m := types.DKMSModule{Module: fields[0], Version: fields[1]}
+		if len(fields) > 2 {
+			m.Kernel = fields[2]
+		}
+		if len(fields) > 3 {
+			m.Arch = fields[3]
+		}
+		state := strings.TrimSpace(line[i+2:])
+		if before, note, ok := strings.Cut(state, "("); ok {
+			state = strings.TrimSpace(before)
+			m.Note = strings.TrimSuffix(strings.TrimSpace(note), ")")
+		}
+		m.State = strings.TrimSuffix(state, ",")
+		mods = append(mods, m)
+	}
+	return mods
+}
+
+// nvidiaModulePaths are where packages and DKMS put nvidia.ko under
+// /lib/modules/<kernel>: DKMS, akmods and kmod packages, Ubuntu's prebuilt
+// linux-modules-nvidia, and Arch's extramodules.
+var nvidiaModulePaths = []string{
+	"updates/dkms/nvidia.ko*",
+	"updates/nvidia.ko*",
+	"extra/nvidia.ko*",
+	"extra/nvidia/nvidia.ko*",
+	"kernel/nvidia*/nvidia.ko*",
+	"kernel/drivers/video/nvidia.ko*",
+	"extramodules/nvidia.ko*",
+	"video/nvidia.ko*",
+}
+
+// collectKernels lists the kernels under /lib/modules with the nvidia module
+// built for each, and works out which kernel the bootloader starts next.
+func collectKernels(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	base := "/lib/modules"
+	entries, err := fsys.ReadDir(base)
+	if err != nil {
+		base = "/usr/lib/modules"
+		if entries, err = fsys.ReadDir(base); err != nil {
+			return
+		}
+	}
+
+	var versions []string
+	for _, e := range entries {
+		// Arch keeps extramodules-* directories next to the kernels
+		if e.IsDir() && !strings.HasPrefix(e.Name(), "extramodules") {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return kernelLess(versions[i], versions[j]) })
+
+	for _, v := range versions {
+		k := types.KernelModules{Version: v}
+		for _, pattern := range nvidiaModulePaths {
+			if matches, _ := fsys.Glob(base + "/" + v + "/" + pattern); len(matches) > 0 {
+				k.NVIDIAModule = matches[0]
+				break
+			}
+		}
+		info.Kernels = append(info.Kernels, k)
+	}
+
+	info.NextBootKernel, info.NextBootSource = nextBootKernel(fsys, versions)
+}
+
+// grubLinuxRe matches the kernel image line of a GRUB menu entry.
+var grubLinuxRe = regexp.MustCompile(`^\s*linux(?:efi)?\s+\S*vmlinuz-(\S+)`)
+
+// nextBootKernel works out which of the installed kernels the bootloader
+// starts by default, and says how it found out. GRUB's saved entry is tried
+// first, then the first entry in grub.cfg, then systemd-boot's default. When
+// none of those name a kernel, distributions boot the newest one.
+func nextBootKernel(fsys sysroot.FS, kernels []string) (kernel, source string) {
+	if len(kernels) == 0 {
+		return "", ""
+	}
+	for _, dir := range []string{"/boot/grub", "/boot/grub2"} {
+		if data, err := fsys.ReadFile(dir + "/grubenv"); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if entry, ok := strings.CutPrefix(line, "saved_entry="); ok && entry != "" {
+					if k := kernelIn(entry, kernels); k != "" {
+						return k, dir + "/grubenv saved_entry"
+					}
+					// Fedora names BLS entries "<machine-id>-<kernel>"; the
+					// entry file says which kernel it boots
+					if k := blsEntryKernel(fsys, "/boot/loader/entries/"+entry+".conf", kernels); k != "" {
+						return k, dir + "/grubenv saved_entry"
+					}
+				}
+			}
+		}
+		if data, err := fsys.ReadFile(dir + "/grub.cfg"); err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if m := grubLinuxRe.FindStringSubmatch(line); m != nil {
+					if k := kernelIn(m[1], kernels); k != "" {
+						return k, dir + "/grub.cfg first entry"
+					}
+					break
+				}
+			}
+		}
+	}
+
+	for _, esp := range []string{"/boot", "/efi", "/boot/efi"} {
+		data, err := fsys.ReadFile(esp + "/loader/loader.conf")
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 || fields[0] != "default" {
+				continue
+			}
+			matches, _ := fsys.Glob(esp + "/loader/entries/" + fields[1])
+			if !strings.HasSuffix(fields[1], ".conf") {
+				more, _ := fsys.Glob(esp + "/loader/entries/" + fields[1] + ".conf")
+				matches = append(matches, more...)
+			}
+			// systemd-boot picks the highest-sorting entry matching the pattern
+			sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+			for _, m := range matches {
+				if k := blsEntryKernel(fsys, m, kernels); k != "" {
+					return k, esp + "/loader/loader.conf default"
+				}
+			}
+		}
+	}
+
+	return kernels[len(kernels)-1], "newest installed kernel"
+}
+
+// blsEntryKernel reads the kernel a Boot Loader Specification entry starts
+// from its version or linux line.
+func blsEntryKernel(fsys sysroot.FS, name string, kernels []string) string {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && (fields[0] == "version" || fields[0] == "linux") {
+			if k := kernelIn(fields[1], kernels); k != "" {
+				return k
+			}
+		}
+	}
+	return ""
+}
+
+// kernelIn returns the longest installed kernel version s contains, so a
+// kernel whose version is a prefix of another ("6.8.9" and "6.8.9-rt") is
+// not mistaken for it.
+func kernelIn(s string, kernels []string) string {
+	best := ""
+	for _, k := range kernels {
+		if strings.Contains(s, k) && len(k) > len(best) {
+			best = k
+		}
+	}
+	return best
+}
+
+// kernelPartRe splits a kernel version into runs of digits and non-digits.
+var kernelPartRe = regexp.MustCompile(`\d+|\D+`)
+
+// kernelLess orders kernel versions numerically, so 6.5.0-27 follows 6.5.0-9.
+func kernelLess(a, b string) bool {
+	pa, pb := kernelPartRe.FindAllString(a, -1), kernelPartRe.FindAllString(b, -1)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA == nil && errB == nil {
+			return na < nb
+		}
+		return pa[i] < pb[i]
+	}
+	return len(pa) < len(pb)
+}
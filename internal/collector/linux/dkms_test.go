@@ -0,0 +1,117 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func TestParseDKMSStatus(t *testing.T) {
+	out := `nvidia/550.54.14, 6.5.0-26-generic, x86_64: installed
+nvidia/550.54.14, 6.5.0-27-generic, x86_64: installed (WARNING! Diff between built and installed module!)
+nvidia/550.67: added
+nvidia, 470.82.00, 5.4.0-90-generic, x86_64: built
+Deprecated feature: REMAKE_INITRD (/var/lib/dkms/nvidia/550.54.14/source/dkms.conf)
+`
+	mods := parseDKMSStatus(out)
+	if len(mods) != 4 {
+		t.Fatalf("expected 4 entries, got %+v", mods)
+	}
+	want := []types.DKMSModule{
+		{Module: "nvidia", Version: "550.54.14", Kernel: "6.5.0-26-generic", Arch: "x86_64", State: "installed"},
+		{Module: "nvidia", Version: "550.54.14", Kernel: "6.5.0-27-generic", Arch: "x86_64", State: "installed", Note: "WARNING! Diff between built and installed module!"},
+		{Module: "nvidia", Version: "550.67", State: "added"},
+		{Module: "nvidia", Version: "470.82.00", Kernel: "5.4.0-90-generic", Arch: "x86_64", State: "built"},
+	}
+	for i, w := range want {
+		if mods[i] != w {
+			t.Errorf("entry %d = %+v, want %+v", i, mods[i], w)
+		}
+	}
+}
+
+func TestKernelLess(t *testing.T) {
+	kernels := []string{"6.5.0-27-generic", "6.5.0-9-generic", "6.8.0-31-generic", "6.5.0-26-generic"}
+	sort.Slice(kernels, func(i, j int) bool { return kernelLess(kernels[i], kernels[j]) })
+	want := []string{"6.5.0-9-generic", "6.5.0-26-generic", "6.5.0-27-generic", "6.8.0-31-generic"}
+	for i := range want {
+		if kernels[i] != want[i] {
+			t.Fatalf("sorted = %v, want %v", kernels, want)
+		}
+	}
+}
+
+func TestCollectKernels(t *testing.T) {
+	root := t.TempDir()
+	writeRootFile(t, root, "lib/modules/6.5.0-27-generic/updates/dkms/nvidia.ko.zst", "")
+	writeRootFile(t, root, "lib/modules/6.5.0-27-generic/updates/dkms/nvidia-drm.ko.zst", "")
+	writeRootFile(t, root, "lib/modules/6.8.0-31-generic/kernel/drivers/gpu/drm/i915/i915.ko.zst", "")
+	writeRootFile(t, root, "lib/modules/6.5.0-9-generic/kernel/nvidia-535/nvidia.ko", "")
+	// Ubuntu: GRUB_DEFAULT=0 boots the first menu entry
+	writeRootFile(t, root, "boot/grub/grub.cfg", "set default=\"0\"\nmenuentry 'Ubuntu' --class ubuntu {\n"+
+		"\tlinux\t/boot/vmlinuz-6.8.0-31-generic root=UUID=3f1c ro quiet splash\n\tinitrd\t/boot/initrd.img-6.8.0-31-generic\n}\n")
+
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var info types.LinuxInfo
+	var errs []types.CollectorError
+	collectKernels(context.Background(), fsys, &info, &errs, 10)
+
+	if len(info.Kernels) != 3 {
+		t.Fatalf("expected 3 kernels, got %+v", info.Kernels)
+	}
+	if k := info.Kernels[0]; k.Version != "6.5.0-9-generic" || k.NVIDIAModule != "/lib/modules/6.5.0-9-generic/kernel/nvidia-535/nvidia.ko" {
+		t.Errorf("unexpected kernel 0: %+v", k)
+	}
+	if k := info.Kernels[1]; k.NVIDIAModule != "/lib/modules/6.5.0-27-generic/updates/dkms/nvidia.ko.zst" {
+		t.Errorf("unexpected kernel 1: %+v", k)
+	}
+	if k := info.Kernels[2]; k.Version != "6.8.0-31-generic" || k.NVIDIAModule != "" {
+		t.Errorf("unexpected kernel 2: %+v", k)
+	}
+	if info.NextBootKernel != "6.8.0-31-generic" || info.NextBootSource != "/boot/grub/grub.cfg first entry" {
+		t.Errorf("next boot = %q from %q", info.NextBootKernel, info.NextBootSource)
+	}
+}
+
+func TestNextBootKernel(t *testing.T) {
+	kernels := []string{"6.7.9-200.fc39.x86_64", "6.8.4-200.fc39.x86_64"}
+
+	// Fedora: grubenv names a BLS entry
+	root := t.TempDir()
+	writeRootFile(t, root, "boot/grub2/grubenv", "# GRUB Environment Block\nsaved_entry=4d2e8f0a9b1c4e7f8a3d6c5b2e1f0a9d-6.7.9-200.fc39.x86_64\nboot_success=1\n")
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k, source := nextBootKernel(fsys, kernels); k != "6.7.9-200.fc39.x86_64" || source != "/boot/grub2/grubenv saved_entry" {
+		t.Errorf("grubenv: got %q from %q", k, source)
+	}
+
+	// systemd-boot: the default entry's linux line
+	root = t.TempDir()
+	writeRootFile(t, root, "efi/loader/loader.conf", "timeout 3\ndefault fedora-*\n")
+	writeRootFile(t, root, "efi/loader/entries/fedora-old.conf", "title Fedora\nlinux /vmlinuz-6.7.9-200.fc39.x86_64\n")
+	writeRootFile(t, root, "efi/loader/entries/arch.conf", "title Arch\nlinux /vmlinuz-6.8.4-200.fc39.x86_64\n")
+	if fsys, err = sysroot.New(root); err != nil {
+		t.Fatal(err)
+	}
+	if k, source := nextBootKernel(fsys, kernels); k != "6.7.9-200.fc39.x86_64" || source != "/efi/loader/loader.conf default" {
+		t.Errorf("systemd-boot: got %q from %q", k, source)
+	}
+
+	// Nothing to go on: the newest kernel
+	if fsys, err = sysroot.New(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if k, source := nextBootKernel(fsys, kernels); k != "6.8.4-200.fc39.x86_64" || source != "newest installed kernel" {
+		t.Errorf("fallback: got %q from %q", k, source)
+	}
+}
@@ -3,43 +3,51 @@
 package linux
 
 import (
+	"context"
 	"os"
-	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
+// checkDmesg reads the kernel ring buffer, which is commonly restricted to
+// root.
+var checkDmesg = collector.Check{ID: "linux.logs.dmesg", Needs: collector.PrivilegeAdmin}
+
 // CollectLinuxInfo gathers Linux-specific diagnostic data.
-func CollectLinuxInfo(timeout int, includeLogs bool) (types.LinuxInfo, []types.CollectorError) {
+func CollectLinuxInfo(ctx context.Context, fsys sysroot.FS, gate *collector.Gate, timeout int, includeLogs bool) (types.LinuxInfo, []types.CollectorError) {
 	var info types.LinuxInfo
 	var errs []types.CollectorError
 
-	collectDistroInfo(&info, &errs, timeout)
-	collectPackageManager(&info, &errs, timeout)
-	collectNVIDIAPackages(&info, &errs, timeout)
-	collectKernelModules(&info, &errs, timeout)
-	collectDevNodes(&info, &errs, timeout)
-	collectLibCuda(&info, &errs, timeout)
-	collectDKMS(&info, &errs, timeout)
-	collectSecureBoot(&info, &errs, timeout)
-	collectSessionType(&info, &errs, timeout)
-	collectPRIME(&info, &errs, timeout)
-	collectContainerRuntime(&info, &errs, timeout)
+	collectDistroInfo(ctx, fsys, &info, &errs, timeout)
+	collectPackageManager(ctx, &info, &errs, timeout)
+	collectNVIDIAPackages(ctx, &info, &errs, timeout)
+	collectKernelModules(ctx, fsys, &info, &errs, timeout)
+	collectDevNodes(ctx, fsys, &info, &errs, timeout)
+	collectLibCuda(ctx, fsys, &info, &errs, timeout)
+	collectDriverVersions(ctx, fsys, &info, &errs, timeout)
+	collectDKMS(ctx, &info, &errs, timeout)
+	collectKernels(ctx, fsys, &info, &errs, timeout)
+	collectSecureBoot(ctx, fsys, &info, &errs, timeout)
+	collectSessionType(ctx, &info, &errs, timeout)
+	collectPRIME(ctx, &info, &errs, timeout)
+	collectContainerRuntime(ctx, &info, &errs, timeout)
 
 	if includeLogs {
-		collectJournalSnippets(&info, &errs, timeout)
-		collectDmesgSnippets(&info, &errs, timeout)
+		collectJournalSnippets(ctx, &info, &errs, timeout)
+		collectDmesgSnippets(ctx, gate, &info, &errs, timeout)
 	}
 
 	return info, errs
 }
 
-func collectDistroInfo(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "cat", "/etc/os-release")
-	if r.Err == nil {
-		for _, line := range strings.Split(r.Stdout, "\n") {
+func collectDistroInfo(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	if data, err := fsys.ReadFile("/etc/os-release"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
 			k, v := util.ParseKeyValue(line, "=")
 			v = strings.Trim(v, "\"")
 			switch k {
@@ -52,7 +60,7 @@ func collectDistroInfo(info *types.LinuxInfo, errs *[]types.CollectorError, time
 	}
 }
 
-func collectPackageManager(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectPackageManager(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	if util.CommandExists("apt") {
 		info.PackageManager = "apt"
 	} else if util.CommandExists("dnf") {
@@ -68,15 +76,19 @@ func collectPackageManager(info *types.LinuxInfo, errs *[]types.CollectorError,
 	}
 }
 
-func collectNVIDIAPackages(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectNVIDIAPackages(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	var r util.CommandResult
 	switch info.PackageManager {
 	case "apt":
-		r = util.RunCommand(timeout, "sh", "-c", `dpkg -l | grep -i nvidia | awk '{print $2 " " $3}'`)
+		r = util.RunCommandContext(ctx, timeout, "dpkg-query", "-W", `-f=${Status}\t${binary:Package}\t${Version}\n`)
+		if r.Err == nil {
+			info.NVIDIAPackages = installedDebPackages(r.Stdout)
+		}
+		return
 	case "dnf", "yum":
-		r = util.RunCommand(timeout, "sh", "-c", `rpm -qa | grep -i nvidia`)
+		r = util.RunCommandContext(ctx, timeout, "sh", "-c", `rpm -qa | grep -i nvidia`)
 	case "pacman":
-		r = util.RunCommand(timeout, "sh", "-c", `pacman -Q | grep -i nvidia`)
+		r = util.RunCommandContext(ctx, timeout, "sh", "-c", `pacman -Q | grep -i nvidia`)
 	default:
 		return
 	}
@@ -91,21 +103,42 @@ func collectNVIDIAPackages(info *types.LinuxInfo, errs *[]types.CollectorError,
 	}
 }
 
-func collectKernelModules(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+// installedDebPackages returns "name version" for each installed NVIDIA
+// package in dpkg-query output. Removed packages whose configuration files
+// are still on disk ("deinstall ok config-files") are left out, as dpkg -l
+// marks them rc rather than ii.
+func installedDebPackages(out string) []string {
+	var pkgs []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 3 || fields[0] != "install ok installed" {
+			continue
+		}
+		if strings.Contains(strings.ToLower(fields[1]), "nvidia") {
+			pkgs = append(pkgs, fields[1]+" "+fields[2])
+		}
+	}
+	return pkgs
+}
+
+func collectKernelModules(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	info.LoadedModules = make(map[string]bool)
 
-	r := util.RunCommand(timeout, "sh", "-c", `lsmod | grep -E "^(nvidia|nouveau)" | awk '{print $1}'`)
-	if r.Err == nil {
-		for _, line := range strings.Split(r.Stdout, "\n") {
-			mod := strings.TrimSpace(line)
-			if mod != "" {
+	// /proc/modules is what lsmod prints: one "name size refcount ..." line per module
+	if data, err := fsys.ReadFile("/proc/modules"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			if mod := fields[0]; strings.HasPrefix(mod, "nvidia") || strings.HasPrefix(mod, "nouveau") {
 				info.LoadedModules[mod] = true
 			}
 		}
 	} else {
 		*errs = append(*errs, types.CollectorError{
 			Collector: "linux.modules",
-			Error:     "Could not list kernel modules: " + r.Err.Error(),
+			Error:     "Could not list kernel modules: " + err.Error(),
 		})
 	}
 
@@ -113,7 +146,7 @@ func collectKernelModules(info *types.LinuxInfo, errs *[]types.CollectorError, t
 	for _, mod := range []string{"nvidia", "nvidia_drm", "nvidia_modeset", "nvidia_uvm", "nouveau"} {
 		if _, found := info.LoadedModules[mod]; !found {
 			// Check if module exists but isn't loaded
-			r = util.RunCommand(timeout, "modinfo", mod)
+			r := util.RunCommandContext(ctx, timeout, "modinfo", mod)
 			if r.Err == nil {
 				info.LoadedModules[mod] = false // exists but not loaded
 			}
@@ -122,15 +155,15 @@ func collectKernelModules(info *types.LinuxInfo, errs *[]types.CollectorError, t
 	}
 }
 
-func collectDevNodes(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
-	matches, err := filepath.Glob("/dev/nvidia*")
+func collectDevNodes(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	matches, err := fsys.Glob("/dev/nvidia*")
 	if err == nil {
 		info.DevNvidiaNodes = matches
 	}
 }
 
-func collectLibCuda(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "sh", "-c", `ldconfig -p 2>/dev/null | grep libcuda.so | head -1 | awk '{print $NF}'`)
+func collectLibCuda(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "sh", "-c", `ldconfig -p 2>/dev/null | grep libcuda.so | head -1 | awk '{print $NF}'`)
 	if r.Err == nil && r.Stdout != "" {
 		info.LibCudaPath = strings.TrimSpace(r.Stdout)
 	}
@@ -143,7 +176,7 @@ func collectLibCuda(info *types.LinuxInfo, errs *[]types.CollectorError, timeout
 			"/usr/lib/aarch64-linux-gnu/libcuda.so",
 			"/usr/local/cuda/lib64/libcuda.so",
 		} {
-			if _, err := os.Stat(path); err == nil {
+			if _, err := fsys.Stat(path); err == nil {
 				info.LibCudaPath = path
 				break
 			}
@@ -151,15 +184,16 @@ func collectLibCuda(info *types.LinuxInfo, errs *[]types.CollectorError, timeout
 	}
 }
 
-func collectDKMS(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectDKMS(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	if !util.CommandExists("dkms") {
 		info.DKMSStatus = "DKMS not installed"
 		return
 	}
 
-	r := util.RunCommand(timeout, "dkms", "status")
+	r := util.RunCommandContext(ctx, timeout, "dkms", "status")
 	if r.Err == nil {
 		info.DKMSStatus = r.Stdout
+		info.DKMSModules = parseDKMSStatus(r.Stdout)
 		// Check for failures
 		if strings.Contains(strings.ToLower(r.Stdout), "error") || strings.Contains(strings.ToLower(r.Stdout), "bad") {
 			info.DKMSErrors = r.Stdout
@@ -170,14 +204,14 @@ func collectDKMS(info *types.LinuxInfo, errs *[]types.CollectorError, timeout in
 	}
 }
 
-func collectSecureBoot(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectSecureBoot(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	// Check if UEFI
-	if _, err := os.Stat("/sys/firmware/efi"); err != nil {
+	if _, err := fsys.Stat("/sys/firmware/efi"); err != nil {
 		info.SecureBootState = "N/A (Legacy BIOS)"
 		return
 	}
 
-	r := util.RunCommand(timeout, "mokutil", "--sb-state")
+	r := util.RunCommandContext(ctx, timeout, "mokutil", "--sb-state")
 	if r.Err == nil {
 		out := strings.TrimSpace(r.Stdout)
 		if strings.Contains(strings.ToLower(out), "enabled") {
@@ -192,7 +226,7 @@ func collectSecureBoot(info *types.LinuxInfo, errs *[]types.CollectorError, time
 	}
 
 	// Check MOK status
-	r = util.RunCommand(timeout, "mokutil", "--list-enrolled")
+	r = util.RunCommandContext(ctx, timeout, "mokutil", "--list-enrolled")
 	if r.Err == nil {
 		if strings.Contains(r.Stdout, "NVIDIA") || strings.Contains(r.Stdout, "nvidia") {
 			info.MOKStatus = "NVIDIA key enrolled"
@@ -213,7 +247,7 @@ func collectSecureBoot(info *types.LinuxInfo, errs *[]types.CollectorError, time
 	}
 }
 
-func collectSessionType(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectSessionType(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	// Check XDG_SESSION_TYPE
 	sessionType := os.Getenv("XDG_SESSION_TYPE")
 	if sessionType != "" {
@@ -222,7 +256,7 @@ func collectSessionType(info *types.LinuxInfo, errs *[]types.CollectorError, tim
 	}
 
 	// Fallback: check loginctl
-	r := util.RunCommand(timeout, "sh", "-c", `loginctl show-session $(loginctl | grep $(whoami) | awk '{print $1}') -p Type 2>/dev/null | cut -d= -f2`)
+	r := util.RunCommandContext(ctx, timeout, "sh", "-c", `loginctl show-session $(loginctl | grep $(whoami) | awk '{print $1}') -p Type 2>/dev/null | cut -d= -f2`)
 	if r.Err == nil && r.Stdout != "" {
 		info.SessionType = strings.TrimSpace(r.Stdout)
 	} else {
@@ -230,9 +264,9 @@ func collectSessionType(info *types.LinuxInfo, errs *[]types.CollectorError, tim
 	}
 }
 
-func collectPRIME(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectPRIME(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	// Check PRIME offloading status
-	r := util.RunCommand(timeout, "sh", "-c", `prime-select query 2>/dev/null || echo "not available"`)
+	r := util.RunCommandContext(ctx, timeout, "sh", "-c", `prime-select query 2>/dev/null || echo "not available"`)
 	if r.Err == nil {
 		info.PRIMEStatus = strings.TrimSpace(r.Stdout)
 	}
@@ -245,7 +279,7 @@ func collectPRIME(info *types.LinuxInfo, errs *[]types.CollectorError, timeout i
 	}
 }
 
-func collectContainerRuntime(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectContainerRuntime(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	if util.CommandExists("docker") {
 		info.ContainerRuntime = "docker"
 	} else if util.CommandExists("podman") {
@@ -254,33 +288,53 @@ func collectContainerRuntime(info *types.LinuxInfo, errs *[]types.CollectorError
 
 	// Check nvidia-container-toolkit
 	if util.CommandExists("nvidia-container-cli") {
-		r := util.RunCommand(timeout, "nvidia-container-cli", "--version")
+		r := util.RunCommandContext(ctx, timeout, "nvidia-container-cli", "--version")
 		if r.Err == nil {
 			info.NVContainerToolkit = strings.TrimSpace(r.Stdout)
 		} else {
 			info.NVContainerToolkit = "installed (version unknown)"
 		}
 	} else {
-		r := util.RunCommand(timeout, "sh", "-c", `dpkg -l nvidia-container-toolkit 2>/dev/null | grep ^ii | awk '{print $3}' || rpm -q nvidia-container-toolkit 2>/dev/null`)
+		r := util.RunCommandContext(ctx, timeout, "sh", "-c", `dpkg -l nvidia-container-toolkit 2>/dev/null | grep ^ii | awk '{print $3}' || rpm -q nvidia-container-toolkit 2>/dev/null`)
 		if r.Err == nil && r.Stdout != "" {
 			info.NVContainerToolkit = strings.TrimSpace(r.Stdout)
 		}
 	}
 }
 
-func collectJournalSnippets(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+func collectJournalSnippets(ctx context.Context, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
 	if !util.CommandExists("journalctl") {
 		return
 	}
-	r := util.RunCommand(timeout, "journalctl", "-k", "--no-pager", "-b", "-g", "nvidia|NVRM|gpu", "--lines=100")
+	r := util.RunCommandContext(ctx, timeout, "journalctl", "-k", "--no-pager", "-b", "-g", "nvidia|NVRM|gpu", "--lines=100")
 	if r.Err == nil {
 		info.JournalSnippets = r.Stdout
 	}
 }
 
-func collectDmesgSnippets(info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "sh", "-c", `dmesg 2>/dev/null | grep -i "nvidia\|NVRM\|gpu\|nouveau" | tail -50`)
-	if r.Err == nil {
-		info.DmesgSnippets = r.Stdout
+func collectDmesgSnippets(ctx context.Context, gate *collector.Gate, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	if !gate.Allow(checkDmesg) {
+		return
+	}
+	r := util.RunCommandContext(ctx, timeout, "dmesg")
+	if util.AccessDenied(r) && gate.Denied(checkDmesg) {
+		return
 	}
+	if r.Err != nil {
+		*errs = append(*errs, types.CollectorError{Collector: "linux.dmesg", Error: r.Err.Error()})
+		return
+	}
+	var lines []string
+	for _, line := range strings.Split(r.Stdout, "\n") {
+		if dmesgSnippetRe.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > 50 {
+		lines = lines[len(lines)-50:]
+	}
+	info.DmesgSnippets = strings.Join(lines, "\n")
 }
+
+// dmesgSnippetRe matches the kernel log lines kept in DmesgSnippets.
+var dmesgSnippetRe = regexp.MustCompile(`(?i)nvidia|NVRM|gpu|nouveau`)
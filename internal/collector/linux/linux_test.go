@@ -0,0 +1,64 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func TestCollectFromSysroot_UbuntuNouveau(t *testing.T) {
+	fsys, err := sysroot.New("testdata/sysroot/ubuntu-nouveau")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var info types.LinuxInfo
+	var errs []types.CollectorError
+
+	collectDistroInfo(ctx, fsys, &info, &errs, 5)
+	if info.Distro != "Ubuntu" || info.DistroVersion != "22.04" {
+		t.Errorf("expected Ubuntu 22.04, got %q %q", info.Distro, info.DistroVersion)
+	}
+
+	collectKernelModules(ctx, fsys, &info, &errs, 5)
+	if !info.LoadedModules["nouveau"] {
+		t.Error("expected nouveau to be loaded")
+	}
+	if info.LoadedModules["nvidia"] {
+		t.Error("nvidia should not be loaded")
+	}
+	if _, ok := info.LoadedModules["ttm"]; ok {
+		t.Error("only nvidia/nouveau modules should be recorded")
+	}
+
+	collectDevNodes(ctx, fsys, &info, &errs, 5)
+	if len(info.DevNvidiaNodes) != 0 {
+		t.Errorf("expected no /dev/nvidia* nodes, got %v", info.DevNvidiaNodes)
+	}
+
+	if len(errs) != 0 {
+		t.Errorf("unexpected collector errors: %+v", errs)
+	}
+}
+
+func TestInstalledDebPackages(t *testing.T) {
+	out := "install ok installed\tnvidia-driver-550\t550.67-0ubuntu0.22.04.1\n" +
+		"deinstall ok config-files\tnvidia-driver-535\t535.161.07-0ubuntu0.22.04.1\n" +
+		"install ok installed\tlibnvidia-compute-550:amd64\t550.67-0ubuntu0.22.04.1\n" +
+		"install ok installed\tlibc6:amd64\t2.35-0ubuntu3.6\n" +
+		"install ok unpacked\tlibnvidia-gl-550:amd64\t550.67-0ubuntu0.22.04.1\n"
+	pkgs := installedDebPackages(out)
+	want := []string{"nvidia-driver-550 550.67-0ubuntu0.22.04.1", "libnvidia-compute-550:amd64 550.67-0ubuntu0.22.04.1"}
+	if len(pkgs) != len(want) {
+		t.Fatalf("got %q, want %q", pkgs, want)
+	}
+	for i := range want {
+		if pkgs[i] != want[i] {
+			t.Errorf("package %d = %q, want %q", i, pkgs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,163 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector/common"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// bdfRe matches a full PCI address as sysfs names devices, "0000:01:00.0".
+var bdfRe = regexp.MustCompile(`^[0-9a-f]{4}:[0-9a-f]{2}:[0-9a-f]{2}\.[0-7]$`)
+
+// CollectSysfsPCIe reads the PCIe link state and AER counters of every NVIDIA
+// GPU, and of the bridge or root port above it, from /sys/bus/pci/devices.
+// Unlike the pcie collector it does not need a working driver. Results follow
+// the order of gpus and carry their index and bus ID.
+func CollectSysfsPCIe(fsys sysroot.FS, gpus []types.GPUInfo) ([]types.PCIeInfo, []types.CollectorError) {
+	var infos []types.PCIeInfo
+	var errs []types.CollectorError
+
+	for _, g := range gpus {
+		if !g.IsNVIDIA || g.PCIBusID == "" {
+			continue
+		}
+		bdf := common.PCIAddress(g.PCIBusID)
+		dir := "/sys/bus/pci/devices/" + bdf
+		if _, err := fsys.Stat(dir); err != nil {
+			errs = append(errs, types.CollectorError{
+				Collector: "linux.pcie",
+				Error:     fmt.Sprintf("GPU %d: %s not in sysfs: %v", g.Index, bdf, err),
+			})
+			continue
+		}
+
+		link := readPCIeLink(fsys, dir)
+		info := types.PCIeInfo{
+			GPUIndex:     g.Index,
+			PCIBusID:     g.PCIBusID,
+			CurrentSpeed: link.CurrentSpeed,
+			MaxSpeed:     link.MaxSpeed,
+			CurrentWidth: link.CurrentWidth,
+			MaxWidth:     link.MaxWidth,
+			Source:       "sysfs",
+			AER:          link.AER,
+		}
+		info.Downshifted = below(info.CurrentSpeed, info.MaxSpeed) || below(info.CurrentWidth, info.MaxWidth)
+
+		if up := upstreamBridge(fsys, dir); up != "" {
+			u := readPCIeLink(fsys, "/sys/bus/pci/devices/"+up)
+			u.BDF = up
+			info.Upstream = &u
+		}
+		infos = append(infos, info)
+	}
+	return infos, errs
+}
+
+// MergeSysfsPCIe combines the nvidia-smi view of a GPU's link with the sysfs
+// one. nvidia-smi's link state is kept when it has any; sysfs always adds the
+// AER counters and the upstream port.
+func MergeSysfsPCIe(smi *types.PCIeInfo, sys types.PCIeInfo) *types.PCIeInfo {
+	if smi == nil || (smi.CurrentSpeed == "" && smi.MaxSpeed == "") {
+		return &sys
+	}
+	merged := *smi
+	merged.AER = sys.AER
+	merged.Upstream = sys.Upstream
+	return &merged
+}
+
+// upstreamBridge returns the BDF of the device above dir in the PCI
+// hierarchy, or "" when dir sits directly on the root complex.
+func upstreamBridge(fsys sysroot.FS, dir string) string {
+	target, err := fsys.Readlink(dir)
+	if err != nil {
+		return ""
+	}
+	parent := path.Base(path.Dir(target))
+	if !bdfRe.MatchString(parent) {
+		return ""
+	}
+	return parent
+}
+
+// readPCIeLink reads one device's link attributes and AER totals.
+func readPCIeLink(fsys sysroot.FS, dir string) types.PCIeLink {
+	read := func(name string) string {
+		data, err := fsys.ReadFile(dir + "/" + name)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	}
+	link := types.PCIeLink{
+		CurrentSpeed: sysfsLinkGen(read("current_link_speed")),
+		MaxSpeed:     sysfsLinkGen(read("max_link_speed")),
+		CurrentWidth: sysfsLinkWidth(read("current_link_width")),
+		MaxWidth:     sysfsLinkWidth(read("max_link_width")),
+	}
+
+	cor, okCor := aerTotal(read("aer_dev_correctable"), "TOTAL_ERR_COR")
+	nonFatal, okNonFatal := aerTotal(read("aer_dev_nonfatal"), "TOTAL_ERR_NONFATAL")
+	fatal, okFatal := aerTotal(read("aer_dev_fatal"), "TOTAL_ERR_FATAL")
+	if okCor || okNonFatal || okFatal {
+		link.AER = &types.PCIeAER{Correctable: cor, NonFatal: nonFatal, Fatal: fatal}
+	}
+	return link
+}
+
+// sysfsLinkGen maps a sysfs link speed such as "16.0 GT/s PCIe" (or "8 GT/s"
+// on older kernels) to its PCIe generation, "Gen4".
+func sysfsLinkGen(speed string) string {
+	fields := strings.Fields(speed)
+	if len(fields) < 2 || fields[1] != "GT/s" {
+		return ""
+	}
+	gts, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return ""
+	}
+	for gen, rate := range []float64{2.5, 5, 8, 16, 32, 64} {
+		if gts == rate {
+			return fmt.Sprintf("Gen%d", gen+1)
+		}
+	}
+	return ""
+}
+
+// sysfsLinkWidth formats a sysfs link width ("16") as "x16". Zero means the
+// link is down or unknown.
+func sysfsLinkWidth(width string) string {
+	if n, err := strconv.Atoi(width); err == nil && n > 0 {
+		return fmt.Sprintf("x%d", n)
+	}
+	return ""
+}
+
+// aerTotal finds the "<key> <n>" total line in an aer_dev_* file.
+func aerTotal(data, key string) (int64, bool) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			n, err := strconv.ParseInt(fields[1], 10, 64)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+// below reports whether a current link generation or width ("Gen3", "x8") is
+// lower than the maximum. Unknown values never count.
+func below(current, max string) bool {
+	c, errC := strconv.Atoi(strings.TrimLeft(current, "Genx"))
+	m, errM := strconv.Atoi(strings.TrimLeft(max, "Genx"))
+	return errC == nil && errM == nil && c < m
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// writeSysfsDevice creates a PCI device directory under sys/devices with its
+// link attributes and the /sys/bus/pci/devices symlink pointing at it.
+func writeSysfsDevice(t *testing.T, root, devPath string, attrs map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, "sys/devices", devPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range attrs {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus := filepath.Join(root, "sys/bus/pci/devices")
+	if err := os.MkdirAll(bus, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../../../devices/"+devPath, filepath.Join(bus, filepath.Base(devPath))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectSysfsPCIe(t *testing.T) {
+	root := t.TempDir()
+	writeSysfsDevice(t, root, "pci0000:00/0000:00:01.0", map[string]string{
+		"current_link_speed": "8.0 GT/s PCIe\n",
+		"max_link_speed":     "8.0 GT/s PCIe\n",
+		"current_link_width": "16\n",
+		"max_link_width":     "16\n",
+	})
+	writeSysfsDevice(t, root, "pci0000:00/0000:00:01.0/0000:01:00.0", map[string]string{
+		"current_link_speed":  "8.0 GT/s PCIe\n",
+		"max_link_speed":      "16.0 GT/s PCIe\n",
+		"current_link_width":  "16\n",
+		"max_link_width":      "16\n",
+		"aer_dev_correctable": "RxErr 12\nBadTLP 3\nBadDLLP 0\nRollover 0\nTimeout 0\nNonFatalErr 0\nCorrIntErr 0\nHeaderOF 0\nTOTAL_ERR_COR 15\n",
+		"aer_dev_nonfatal":    "Undefined 0\nDLP 0\nSDES 0\nTLP 0\nFCP 0\nCmpltTO 2\nTOTAL_ERR_NONFATAL 2\n",
+		"aer_dev_fatal":       "Undefined 0\nDLP 0\nTOTAL_ERR_FATAL 0\n",
+	})
+	// Directly on the root complex, with the link down
+	writeSysfsDevice(t, root, "pci0000:80/0000:81:00.0", map[string]string{
+		"current_link_speed": "Unknown\n",
+		"max_link_speed":     "32.0 GT/s PCIe\n",
+		"current_link_width": "0\n",
+		"max_link_width":     "16\n",
+	})
+
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gpus := []types.GPUInfo{
+		{Index: 0, PCIBusID: "00000000:01:00.0", IsNVIDIA: true},
+		{Index: 1, PCIBusID: "81:00.0", IsNVIDIA: true},
+		{Index: 2, PCIBusID: "00000000:02:00.0", IsNVIDIA: true},
+		{Index: 3, PCIBusID: "00:02.0", Vendor: "Intel"},
+	}
+	infos, errs := CollectSysfsPCIe(fsys, gpus)
+	if len(errs) != 1 || errs[0].Collector != "linux.pcie" {
+		t.Errorf("expected one error for the GPU missing from sysfs, got %+v", errs)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 GPUs, got %+v", infos)
+	}
+
+	g := infos[0]
+	if g.CurrentSpeed != "Gen3" || g.MaxSpeed != "Gen4" || g.CurrentWidth != "x16" || !g.Downshifted || g.Source != "sysfs" {
+		t.Errorf("unexpected GPU 0 link: %+v", g)
+	}
+	if g.AER == nil || g.AER.Correctable != 15 || g.AER.NonFatal != 2 || g.AER.Fatal != 0 {
+		t.Errorf("unexpected AER counts: %+v", g.AER)
+	}
+	if u := g.Upstream; u == nil || u.BDF != "0000:00:01.0" || u.MaxSpeed != "Gen3" || u.MaxWidth != "x16" || u.AER != nil {
+		t.Errorf("unexpected upstream port: %+v", u)
+	}
+
+	g = infos[1]
+	if g.CurrentSpeed != "" || g.MaxSpeed != "Gen5" || g.Downshifted || g.Upstream != nil || g.AER != nil {
+		t.Errorf("unexpected GPU 1 link: %+v", g)
+	}
+}
+
+func TestMergeSysfsPCIe(t *testing.T) {
+	sys := types.PCIeInfo{CurrentSpeed: "Gen3", MaxSpeed: "Gen4", Source: "sysfs",
+		AER: &types.PCIeAER{Correctable: 1}, Upstream: &types.PCIeLink{BDF: "0000:00:01.0"}}
+
+	merged := MergeSysfsPCIe(&types.PCIeInfo{CurrentSpeed: "Gen1", MaxSpeed: "Gen4", Source: "nvidia-smi"}, sys)
+	if merged.Source != "nvidia-smi" || merged.CurrentSpeed != "Gen1" || merged.AER == nil || merged.Upstream == nil {
+		t.Errorf("expected nvidia-smi link state with sysfs extras, got %+v", merged)
+	}
+	if merged := MergeSysfsPCIe(nil, sys); merged.Source != "sysfs" || merged.CurrentSpeed != "Gen3" {
+		t.Errorf("expected the sysfs link without nvidia-smi data, got %+v", merged)
+	}
+}
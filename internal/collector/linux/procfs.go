@@ -0,0 +1,198 @@
+//go:build linux
+
+package linux
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector/common"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// ProcNVIDIA is what the loaded nvidia kernel module reports under
+// /proc/driver/nvidia. It is there whenever the module is loaded, including
+// when nvidia-smi cannot talk to it.
+type ProcNVIDIA struct {
+	ModuleVersion string          // "550.54.14"
+	GPUs          []types.GPUInfo // one per gpus/<bus>/information, with Index unset
+}
+
+// moduleVersionRe matches the version in the NVRM line of
+// /proc/driver/nvidia/version for both module flavours:
+//
+//	NVRM version: NVIDIA UNIX x86_64 Kernel Module  550.54.14  Thu Feb 22 01:44:30 UTC 2024
+//	NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  550.54.14  Release Build  (...)
+var moduleVersionRe = regexp.MustCompile(`Kernel Module(?: for \S+)?\s+(\d+\.\d+(?:\.\d+)?)`)
+
+// CollectProcNVIDIA reads the kernel module version and the per-GPU
+// information files from /proc/driver/nvidia. A missing directory means the
+// module is not loaded and is not an error.
+func CollectProcNVIDIA(fsys sysroot.FS) (ProcNVIDIA, []types.CollectorError) {
+	var proc ProcNVIDIA
+	var errs []types.CollectorError
+
+	data, err := fsys.ReadFile("/proc/driver/nvidia/version")
+	if err != nil {
+		return proc, errs
+	}
+	if m := moduleVersionRe.FindStringSubmatch(string(data)); m != nil {
+		proc.ModuleVersion = m[1]
+	} else {
+		line, _, _ := strings.Cut(string(data), "\n")
+		errs = append(errs, types.CollectorError{
+			Collector: "linux.procfs",
+			Error:     "unrecognized /proc/driver/nvidia/version: " + strings.TrimSpace(line),
+		})
+	}
+
+	entries, err := fsys.ReadDir("/proc/driver/nvidia/gpus")
+	if err != nil {
+		return proc, errs
+	}
+	for _, e := range entries {
+		dir := "/proc/driver/nvidia/gpus/" + e.Name()
+		data, err := fsys.ReadFile(dir + "/information")
+		if err != nil {
+			errs = append(errs, types.CollectorError{
+				Collector: "linux.procfs",
+				Error:     fmt.Sprintf("%s: %v", dir, err),
+			})
+			continue
+		}
+		gpu := parseGPUInformation(string(data))
+		if gpu.PCIBusID == "" {
+			gpu.PCIBusID = e.Name()
+		}
+		// The information file has no PCI IDs; sysfs does
+		sysDir := "/sys/bus/pci/devices/" + common.PCIAddress(gpu.PCIBusID)
+		gpu.PCIVendorID = sysfsPCIID(fsys, sysDir+"/vendor")
+		gpu.PCIDeviceID = sysfsPCIID(fsys, sysDir+"/device")
+		proc.GPUs = append(proc.GPUs, gpu)
+	}
+	sort.Slice(proc.GPUs, func(i, j int) bool { return proc.GPUs[i].PCIBusID < proc.GPUs[j].PCIBusID })
+	return proc, errs
+}
+
+// parseGPUInformation parses a /proc/driver/nvidia/gpus/<bus>/information
+// file, "Key: value" lines such as:
+//
+//	Model: 		 NVIDIA GeForce RTX 3080
+//	IRQ:   		 139
+//	GPU UUID: 	 GPU-7b2f9c41-...
+//	Video BIOS: 	 94.02.42.00.a9
+//	Bus Location: 	 0000:01:00.0
+//	GPU Firmware: 	 550.54.14
+func parseGPUInformation(data string) types.GPUInfo {
+	gpu := types.GPUInfo{Vendor: "NVIDIA", IsNVIDIA: true, Sources: map[string]string{}}
+	set := func(field *string, name, value string) {
+		if value != "" && value != "N/A" && !strings.HasPrefix(value, "??") {
+			*field = value
+			gpu.Sources[name] = "procfs"
+		}
+	}
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Model":
+			set(&gpu.Name, "name", value)
+		case "GPU UUID":
+			set(&gpu.UUID, "uuid", value)
+		case "Video BIOS":
+			set(&gpu.VBIOSVersion, "vbios_version", value)
+		case "GPU Firmware":
+			set(&gpu.FirmwareVersion, "firmware_version", value)
+		case "Bus Location":
+			set(&gpu.PCIBusID, "pci_bus_id", value)
+		case "IRQ":
+			if n, err := strconv.Atoi(value); err == nil {
+				gpu.IRQ = n
+				gpu.Sources["irq"] = "procfs"
+			}
+		}
+	}
+	return gpu
+}
+
+// sysfsPCIID reads a sysfs vendor or device file ("0x10de") as "10de".
+func sysfsPCIID(fsys sysroot.FS, name string) string {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(data)), "0x")
+}
+
+// MergeProcNVIDIA fills the driver and GPU fields nvidia-smi did not supply
+// from /proc/driver/nvidia, and records procfs as their source. GPUs the gpu
+// collector did not find at all are added and described from pack when it is
+// not nil. Values nvidia-smi reported are kept; lspci names are replaced by
+// the driver's model name.
+func MergeProcNVIDIA(driver *types.DriverInfo, gpus []types.GPUInfo, proc ProcNVIDIA, pack *knowledge.Pack) []types.GPUInfo {
+	if proc.ModuleVersion == "" {
+		return gpus
+	}
+	driver.KernelModuleVersion = proc.ModuleVersion
+	if driver.Sources == nil {
+		driver.Sources = map[string]string{}
+	}
+	driver.Sources["kernel_module_version"] = "procfs"
+	if driver.Version == "" {
+		driver.Version = proc.ModuleVersion
+		driver.Sources["version"] = "procfs"
+	}
+
+	for _, p := range proc.GPUs {
+		gpu := gpuByPCIAddress(gpus, p.PCIBusID)
+		if gpu == nil {
+			p.Index = len(gpus)
+			p.DriverVersion = proc.ModuleVersion
+			p.Sources["driver_version"] = "procfs"
+			if pack != nil {
+				pack.DescribeGPU(&p)
+			}
+			gpus = append(gpus, p)
+			continue
+		}
+		if gpu.Sources == nil {
+			gpu.Sources = map[string]string{}
+		}
+		fill := func(field *string, value, name string) {
+			if value != "" && (*field == "" || gpu.Sources[name] != "nvidia-smi") {
+				*field = value
+				gpu.Sources[name] = "procfs"
+			}
+		}
+		fill(&gpu.Name, p.Name, "name")
+		fill(&gpu.DriverVersion, proc.ModuleVersion, "driver_version")
+		fill(&gpu.UUID, p.UUID, "uuid")
+		fill(&gpu.VBIOSVersion, p.VBIOSVersion, "vbios_version")
+		fill(&gpu.FirmwareVersion, p.FirmwareVersion, "firmware_version")
+		if gpu.IRQ == 0 && p.IRQ != 0 {
+			gpu.IRQ = p.IRQ
+			gpu.Sources["irq"] = "procfs"
+		}
+	}
+	return gpus
+}
+
+// gpuByPCIAddress finds the GPU at a bus address in any of the notations
+// nvidia-smi, lspci and procfs use.
+func gpuByPCIAddress(gpus []types.GPUInfo, busID string) *types.GPUInfo {
+	addr := common.PCIAddress(busID)
+	for i := range gpus {
+		if gpus[i].PCIBusID != "" && common.PCIAddress(gpus[i].PCIBusID) == addr {
+			return &gpus[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+//go:build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func writeRootFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectProcNVIDIA(t *testing.T) {
+	root := t.TempDir()
+	writeRootFile(t, root, "proc/driver/nvidia/version",
+		"NVRM version: NVIDIA UNIX Open Kernel Module for x86_64  550.54.14  Release Build  (dvs-builder@U16-I3-B03-4-3)  Thu Feb 22 01:25:25 UTC 2024\n"+
+			"GCC version:  gcc version 12.3.0 (Ubuntu 12.3.0-1ubuntu1~22.04)\n")
+	writeRootFile(t, root, "proc/driver/nvidia/gpus/0000:41:00.0/information",
+		"Model: \t\t NVIDIA RTX A6000\nIRQ:   \t\t 187\nGPU UUID: \t GPU-3e8a1f27-94c0-4b6d-a25e-7f0c9d1b4e83\n"+
+			"Video BIOS: \t ??.??.??.??.??\nBus Type: \t PCIe\nDMA Size: \t 47 bits\nDMA Mask: \t 0x7fffffffffff\n"+
+			"Bus Location: \t 0000:41:00.0\nDevice Minor: \t 1\nGPU Firmware: \t 550.54.14\nGPU Excluded:\t No\n")
+	writeRootFile(t, root, "proc/driver/nvidia/gpus/0000:01:00.0/information",
+		"Model: \t\t NVIDIA GeForce RTX 3080\nIRQ:   \t\t 139\nGPU UUID: \t GPU-7b2f9c41-0d35-4e8a-b619-c2f4a07e5d92\n"+
+			"Video BIOS: \t 94.02.42.00.a9\nBus Type: \t PCIe\nBus Location: \t 0000:01:00.0\nDevice Minor: \t 0\nGPU Excluded:\t No\n")
+	writeRootFile(t, root, "sys/bus/pci/devices/0000:01:00.0/vendor", "0x10de\n")
+	writeRootFile(t, root, "sys/bus/pci/devices/0000:01:00.0/device", "0x2206\n")
+
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proc, errs := CollectProcNVIDIA(fsys)
+	if len(errs) != 0 {
+		t.Errorf("unexpected errors: %+v", errs)
+	}
+	if proc.ModuleVersion != "550.54.14" || len(proc.GPUs) != 2 {
+		t.Fatalf("unexpected result: %+v", proc)
+	}
+
+	g := proc.GPUs[0]
+	if g.Name != "NVIDIA GeForce RTX 3080" || g.PCIBusID != "0000:01:00.0" || g.IRQ != 139 || g.VBIOSVersion != "94.02.42.00.a9" ||
+		g.UUID != "GPU-7b2f9c41-0d35-4e8a-b619-c2f4a07e5d92" || g.PCIVendorID != "10de" || g.PCIDeviceID != "2206" {
+		t.Errorf("unexpected GPU 0: %+v", g)
+	}
+	if g.Sources["name"] != "procfs" || g.Sources["irq"] != "procfs" {
+		t.Errorf("unexpected sources: %v", g.Sources)
+	}
+
+	// The VBIOS version is unreadable while the GPU is in a bad state
+	g = proc.GPUs[1]
+	if g.VBIOSVersion != "" || g.FirmwareVersion != "550.54.14" || g.PCIDeviceID != "" {
+		t.Errorf("unexpected GPU 1: %+v", g)
+	}
+
+	// Without the module there is nothing to report
+	empty, err := sysroot.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proc, errs := CollectProcNVIDIA(empty); proc.ModuleVersion != "" || len(proc.GPUs) != 0 || len(errs) != 0 {
+		t.Errorf("expected nothing without /proc/driver/nvidia, got %+v %+v", proc, errs)
+	}
+}
+
+func TestCollectProcNVIDIA_ProprietaryModule(t *testing.T) {
+	root := t.TempDir()
+	writeRootFile(t, root, "proc/driver/nvidia/version",
+		"NVRM version: NVIDIA UNIX x86_64 Kernel Module  470.256.02  Thu May  2 14:37:44 UTC 2024\n")
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proc, _ := CollectProcNVIDIA(fsys); proc.ModuleVersion != "470.256.02" {
+		t.Errorf("ModuleVersion = %q", proc.ModuleVersion)
+	}
+}
+
+func TestMergeProcNVIDIA(t *testing.T) {
+	proc := ProcNVIDIA{
+		ModuleVersion: "550.54.14",
+		GPUs: []types.GPUInfo{
+			{Name: "NVIDIA GeForce RTX 3080", PCIBusID: "0000:01:00.0", UUID: "GPU-7b2f9c41", IRQ: 139, Vendor: "NVIDIA", IsNVIDIA: true,
+				Sources: map[string]string{"name": "procfs", "pci_bus_id": "procfs", "uuid": "procfs", "irq": "procfs"}},
+			{Name: "NVIDIA RTX A6000", PCIBusID: "0000:41:00.0", Vendor: "NVIDIA", IsNVIDIA: true,
+				Sources: map[string]string{"name": "procfs", "pci_bus_id": "procfs"}},
+		},
+	}
+
+	// nvidia-smi failed: lspci found one of the GPUs
+	var driver types.DriverInfo
+	gpus := []types.GPUInfo{
+		{Index: 0, Name: "NVIDIA Corporation GA102 [GeForce RTX 3080]", PCIBusID: "01:00.0", Vendor: "NVIDIA", IsNVIDIA: true,
+			Sources: map[string]string{"name": "lspci", "pci_bus_id": "lspci"}},
+	}
+	gpus = MergeProcNVIDIA(&driver, gpus, proc, nil)
+	if driver.Version != "550.54.14" || driver.KernelModuleVersion != "550.54.14" || driver.Sources["version"] != "procfs" {
+		t.Errorf("unexpected driver: %+v", driver)
+	}
+	if len(gpus) != 2 {
+		t.Fatalf("expected the GPU lspci missed to be added, got %+v", gpus)
+	}
+	g := gpus[0]
+	if g.Name != "NVIDIA GeForce RTX 3080" || g.DriverVersion != "550.54.14" || g.IRQ != 139 || g.Sources["name"] != "procfs" || g.Sources["pci_bus_id"] != "lspci" {
+		t.Errorf("unexpected merged GPU 0: %+v", g)
+	}
+	if g := gpus[1]; g.Index != 1 || g.Name != "NVIDIA RTX A6000" || g.DriverVersion != "550.54.14" || g.Sources["driver_version"] != "procfs" {
+		t.Errorf("unexpected added GPU 1: %+v", g)
+	}
+
+	// nvidia-smi worked: its values stay
+	driver = types.DriverInfo{Version: "550.54.15", Sources: map[string]string{"version": "nvidia-smi"}}
+	gpus = []types.GPUInfo{
+		{Index: 0, Name: "NVIDIA GeForce RTX 3080", PCIBusID: "00000000:01:00.0", DriverVersion: "550.54.15", UUID: "GPU-7b2f9c41",
+			Sources: map[string]string{"name": "nvidia-smi", "driver_version": "nvidia-smi", "uuid": "nvidia-smi"}},
+		{Index: 1, Name: "NVIDIA RTX A6000", PCIBusID: "00000000:41:00.0", DriverVersion: "550.54.15",
+			Sources: map[string]string{"name": "nvidia-smi", "driver_version": "nvidia-smi"}},
+	}
+	gpus = MergeProcNVIDIA(&driver, gpus, proc, nil)
+	if driver.Version != "550.54.15" || driver.Sources["version"] != "nvidia-smi" || driver.KernelModuleVersion != "550.54.14" {
+		t.Errorf("unexpected driver: %+v", driver)
+	}
+	if len(gpus) != 2 || gpus[0].DriverVersion != "550.54.15" || gpus[0].Sources["uuid"] != "nvidia-smi" || gpus[0].IRQ != 139 {
+		t.Errorf("unexpected GPUs: %+v", gpus)
+	}
+}
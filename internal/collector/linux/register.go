@@ -0,0 +1,111 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func init() {
+	collector.Register(collector.Spec{
+		ID:     "linux",
+		OS:     []string{"linux"},
+		Checks: []collector.Check{checkDmesg},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := CollectLinuxInfo(ctx, env.FS, env.Gate, env.Config.Timeout, env.Config.IncludeLogs)
+			r.Linux = &info
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "linux.display",
+		OS:       []string{"linux"},
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			displays, errs := CollectDisplayInfo(ctx, env.Config.Timeout)
+			r.Displays = displays
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "linux.xid",
+		OS:       []string{"linux"},
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeAI, types.ModeFull},
+		Deps:     []string{"linux"},
+		Checks:   []collector.Check{checkXidDmesg},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			xids, errs := CollectXidErrors(ctx, env.Gate, env.Config.Timeout, env.Knowledge)
+			if r.Linux != nil {
+				r.Linux.XidErrors = xids
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "linux.topology",
+		OS:       []string{"linux"},
+		RunModes: []types.RunMode{types.ModeAI, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			topo, errs := CollectTopology(ctx, env.Config.Timeout)
+			r.Topology = topo
+			return errs
+		},
+	})
+
+	// linux.procfs reads what the loaded kernel module reports, so driver and
+	// GPU details survive an nvidia-smi that cannot reach the driver
+	collector.Register(collector.Spec{
+		ID:   "linux.procfs",
+		OS:   []string{"linux"},
+		Deps: []string{"gpu"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			proc, errs := CollectProcNVIDIA(env.FS)
+			r.GPUs = MergeProcNVIDIA(&r.Driver, r.GPUs, proc, env.Knowledge)
+			return errs
+		},
+	})
+
+	// linux.pcie reads sysfs, so GPUs keep their PCIe state when nvidia-smi
+	// is broken; it adds AER counters and the upstream port either way
+	collector.Register(collector.Spec{
+		ID:   "linux.pcie",
+		OS:   []string{"linux"},
+		Deps: []string{"gpu", "pcie", "linux.procfs"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			infos, errs := CollectSysfsPCIe(env.FS, r.GPUs)
+			for _, info := range infos {
+				for i := range r.GPUs {
+					if r.GPUs[i].PCIBusID == info.PCIBusID {
+						r.GPUs[i].PCIe = MergeSysfsPCIe(r.GPUs[i].PCIe, info)
+					}
+				}
+				if r.PCIe == nil {
+					first := info
+					r.PCIe = &first
+				}
+			}
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "linux.renderer",
+		OS:       []string{"linux"},
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeAI, types.ModeFull},
+		Deps:     []string{"linux"},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			fallback, renderer, errs := DetectLlvmpipe(ctx, env.Config.Timeout)
+			if r.Linux != nil {
+				r.Linux.LlvmpipeFallback = fallback
+				r.Linux.GLRenderer = renderer
+			}
+			return errs
+		},
+	})
+}
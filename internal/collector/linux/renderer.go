@@ -3,6 +3,7 @@
 package linux
 
 import (
+	"context"
 	"os"
 	"strings"
 
@@ -14,10 +15,10 @@ import (
 // renderer (llvmpipe or softpipe) instead of hardware-accelerated rendering.
 // It returns whether a software fallback is active, the GL renderer string,
 // and any collector errors encountered.
-func DetectLlvmpipe(timeout int) (fallback bool, glRenderer string, errs []types.CollectorError) {
+func DetectLlvmpipe(ctx context.Context, timeout int) (fallback bool, glRenderer string, errs []types.CollectorError) {
 	// Step 1: Check glxinfo for the OpenGL renderer string
 	if util.CommandExists("glxinfo") {
-		r := util.RunCommand(timeout, "sh", "-c", `glxinfo 2>/dev/null | grep "OpenGL renderer"`)
+		r := util.RunCommandContext(ctx, timeout, "sh", "-c", `glxinfo 2>/dev/null | grep "OpenGL renderer"`)
 		if r.Err != nil {
 			errs = append(errs, types.CollectorError{
 				Collector: "linux.renderer.glxinfo",
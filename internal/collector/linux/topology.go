@@ -0,0 +1,80 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// CollectTopology gathers the GPU-to-GPU link matrix, NVLink state and CPU
+// affinity of each GPU, along with the CPUs and GPUs this process may use.
+// Like nvidia-smi and CUDA_VISIBLE_DEVICES, the allowed CPUs always describe
+// the live system, so they are read outside --sysroot.
+func CollectTopology(ctx context.Context, timeout int) (*types.TopologyInfo, []types.CollectorError) {
+	var errs []types.CollectorError
+
+	if !util.CommandExists("nvidia-smi") {
+		errs = append(errs, types.CollectorError{
+			Collector: "linux.topology",
+			Error:     "nvidia-smi not found in PATH",
+			Fatal:     true,
+		})
+		return nil, errs
+	}
+
+	topo, err := nvsmi.QueryTopology(ctx, timeout)
+	if err != nil {
+		errs = append(errs, types.CollectorError{
+			Collector: "linux.topology",
+			Error:     fmt.Sprintf("GPU topology query failed: %v", err),
+			Fatal:     true,
+		})
+		return nil, errs
+	}
+
+	info := topologyFromSMI(topo)
+	info.AllowedCPUs = allowedCPUs(sysroot.Host())
+	info.VisibleDevices = os.Getenv("CUDA_VISIBLE_DEVICES")
+	return info, errs
+}
+
+// topologyFromSMI converts the parsed nvidia-smi topology to report form.
+func topologyFromSMI(topo *nvsmi.Topology) *types.TopologyInfo {
+	info := &types.TopologyInfo{}
+	for _, g := range topo.GPUs {
+		gt := types.GPUTopology{
+			Index:       g.Index,
+			Paths:       g.Paths,
+			CPUAffinity: g.CPUAffinity,
+			NUMANode:    g.NUMANode,
+		}
+		for _, l := range g.NVLinks {
+			gt.NVLinks = append(gt.NVLinks, types.NVLinkState{Link: l.Link, Up: l.Up, SpeedGBs: l.SpeedGBs})
+		}
+		info.GPUs = append(info.GPUs, gt)
+	}
+	return info
+}
+
+// allowedCPUs returns the Cpus_allowed_list of this process, which a job
+// started from the same shell, cgroup or scheduler allocation inherits.
+func allowedCPUs(fsys sysroot.FS) string {
+	data, err := fsys.ReadFile("/proc/self/status")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if k, v := util.ParseKeyValue(line, ":"); k == "Cpus_allowed_list" {
+			return v
+		}
+	}
+	return ""
+}
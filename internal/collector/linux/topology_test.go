@@ -0,0 +1,36 @@
+//go:build linux
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+)
+
+func TestAllowedCPUs(t *testing.T) {
+	root := t.TempDir()
+	writeRootFile(t, root, "proc/self/status", "Name:\tnvcheckup\nPid:\t48213\nCpus_allowed:\tffff0000\nCpus_allowed_list:\t16-31\nMems_allowed_list:\t1\n")
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := allowedCPUs(fsys); got != "16-31" {
+		t.Errorf("allowedCPUs = %q, want 16-31", got)
+	}
+}
+
+func TestTopologyFromSMI(t *testing.T) {
+	info := topologyFromSMI(&nvsmi.Topology{GPUs: []nvsmi.TopoGPU{
+		{Index: 0, Paths: []string{"X", "NV4"}, CPUAffinity: "0-15", NUMANode: 0,
+			NVLinks: []nvsmi.NVLink{{Link: 0, Up: true, SpeedGBs: 25}, {Link: 1}}},
+		{Index: 1, Paths: []string{"NV4", "X"}, CPUAffinity: "0-15", NUMANode: -1},
+	}})
+	if len(info.GPUs) != 2 || info.GPUs[0].Paths[1] != "NV4" || info.GPUs[1].NUMANode != -1 {
+		t.Errorf("GPUs = %+v", info.GPUs)
+	}
+	if links := info.GPUs[0].NVLinks; len(links) != 2 || !links[0].Up || links[1].Up {
+		t.Errorf("NVLinks = %+v", links)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build linux
+
+package linux
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// libDirs are where distributions and the .run installer put the driver's
+// userspace libraries.
+var libDirs = []string{
+	"/usr/lib/x86_64-linux-gnu",
+	"/usr/lib/aarch64-linux-gnu",
+	"/usr/lib64",
+	"/usr/lib",
+}
+
+// driverPackages are the package names, in order of preference, whose
+// version is the userspace driver's: Debian/Ubuntu, Arch, and Fedora/RHEL
+// (RPM Fusion and NVIDIA's repository).
+var driverPackages = []string{"nvidia-driver", "libnvidia-compute", "nvidia-utils", "xorg-x11-drv-nvidia"}
+
+// libVersionRe matches the driver version in a library file name,
+// "libnvidia-ml.so.550.54.14".
+var libVersionRe = regexp.MustCompile(`\.so\.(\d{3}\.\d+(?:\.\d+)?)$`)
+
+// versionRe matches a driver version in a package version string.
+var versionRe = regexp.MustCompile(`\d{3}\.\d+(?:\.\d+)?`)
+
+// rpmRe splits an rpm -qa line, "xorg-x11-drv-nvidia-550.54.14-1.fc39.x86_64",
+// into name and version.
+var rpmRe = regexp.MustCompile(`^(.+?)-(\d{3}\.\d+(?:\.\d+)?)-`)
+
+// collectDriverVersions reads the driver version from the loaded module, the
+// NVML and CUDA libraries on disk, the package list and modinfo, so a
+// half-finished upgrade or a missing reboot shows as a disagreement.
+func collectDriverVersions(ctx context.Context, fsys sysroot.FS, info *types.LinuxInfo, errs *[]types.CollectorError, timeout int) {
+	v := &types.DriverVersions{}
+
+	if data, err := fsys.ReadFile("/sys/module/nvidia/version"); err == nil {
+		v.LoadedModule = strings.TrimSpace(string(data))
+	}
+	v.NVMLPath, v.NVML = driverLibrary(fsys, "libnvidia-ml.so")
+	v.LibCUDAPath, v.LibCUDA = driverLibrary(fsys, "libcuda.so")
+	v.PackageName, v.Package = driverPackageVersion(info.NVIDIAPackages, v.NVML, v.LibCUDA)
+
+	if util.CommandExists("modinfo") {
+		r := util.RunCommandContext(ctx, timeout, "modinfo", "-F", "version", "nvidia")
+		if r.Err == nil {
+			v.Modinfo = strings.TrimSpace(r.Stdout)
+		}
+		// modinfo fails when no module is built for the running kernel;
+		// dkms-failure and nvidia-module-not-loaded cover that
+	}
+
+	if *v != (types.DriverVersions{}) {
+		info.DriverVersions = v
+	}
+}
+
+// driverLibrary finds a driver library and its version. The .so.1 link is
+// what programs load, so its target wins over other versions left on disk.
+func driverLibrary(fsys sysroot.FS, lib string) (file, version string) {
+	for _, dir := range libDirs {
+		if target, err := fsys.Readlink(dir + "/" + lib + ".1"); err == nil {
+			if m := libVersionRe.FindStringSubmatch(target); m != nil {
+				return path.Join(dir, path.Base(target)), m[1]
+			}
+		}
+		matches, _ := fsys.Glob(dir + "/" + lib + ".*")
+		for _, match := range matches {
+			if m := libVersionRe.FindStringSubmatch(match); m != nil {
+				return match, m[1]
+			}
+		}
+	}
+	return "", ""
+}
+
+// driverPackageVersion picks the driver package out of the NVIDIA package
+// list, as apt and pacman ("nvidia-driver-550 550.54.14-0ubuntu1") or rpm
+// ("xorg-x11-drv-nvidia-550.54.14-1.fc39.x86_64") print it, and returns its
+// name and driver version. When several driver packages are installed, say
+// nvidia-driver-535 next to nvidia-driver-550, the one whose version matches
+// a library in prefer wins; otherwise driverPackages decides.
+func driverPackageVersion(pkgs []string, prefer ...string) (name, version string) {
+	var names, versions []string
+	for _, want := range driverPackages {
+		for _, line := range pkgs {
+			var pkg, ver string
+			if fields := strings.Fields(line); len(fields) >= 2 {
+				pkg, _, _ = strings.Cut(fields[0], ":") // dpkg's "libnvidia-compute-550:amd64"
+				ver = versionRe.FindString(fields[1])
+			} else if m := rpmRe.FindStringSubmatch(line); m != nil {
+				pkg, ver = m[1], m[2]
+			}
+			if ver != "" && (pkg == want || strings.HasPrefix(pkg, want+"-")) {
+				names, versions = append(names, pkg), append(versions, ver)
+			}
+		}
+	}
+	for i, ver := range versions {
+		for _, p := range prefer {
+			if p != "" && ver == p {
+				return names[i], ver
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "", ""
+	}
+	return names[0], versions[0]
+}
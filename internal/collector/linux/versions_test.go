@@ -0,0 +1,71 @@
+//go:build linux
+
+package linux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+)
+
+func TestDriverPackageVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		pkgs      []string
+		prefer    []string
+		wantName  string
+		wantValue string
+	}{
+		{"apt", []string{"libnvidia-cfg1-550:amd64 550.54.14-0ubuntu0.22.04.1", "libnvidia-compute-550:amd64 550.67-0ubuntu0.22.04.1", "nvidia-driver-550 550.67-0ubuntu0.22.04.1"}, nil,
+			"nvidia-driver-550", "550.67"},
+		{"rpm", []string{"nvidia-settings-550.54.14-1.fc39.x86_64", "xorg-x11-drv-nvidia-cuda-libs-550.54.14-1.fc39.x86_64", "xorg-x11-drv-nvidia-550.54.14-1.fc39.x86_64"}, nil,
+			"xorg-x11-drv-nvidia-cuda-libs", "550.54.14"},
+		{"pacman", []string{"nvidia-settings 550.54.14-1", "nvidia-utils 550.67-1", "nvidia 550.67-2"}, nil,
+			"nvidia-utils", "550.67"},
+		// An older branch left installed sorts first; the libraries say which is in use
+		{"two branches", []string{"nvidia-driver-535 535.161.07-0ubuntu0.22.04.1", "nvidia-driver-550 550.67-0ubuntu0.22.04.1"}, []string{"550.67", "550.67"},
+			"nvidia-driver-550", "550.67"},
+		{"no library match", []string{"nvidia-driver-535 535.161.07-0ubuntu0.22.04.1", "nvidia-driver-550 550.67-0ubuntu0.22.04.1"}, []string{"", "550.54.14"},
+			"nvidia-driver-535", "535.161.07"},
+		{"no driver package", []string{"nvidia-container-toolkit 1.14.6-1"}, nil, "", ""},
+	}
+	for _, tt := range tests {
+		name, version := driverPackageVersion(tt.pkgs, tt.prefer...)
+		if name != tt.wantName || version != tt.wantValue {
+			t.Errorf("%s: got %q %q, want %q %q", tt.name, name, version, tt.wantName, tt.wantValue)
+		}
+	}
+}
+
+func TestDriverLibrary(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "usr/lib/x86_64-linux-gnu")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// A library left behind by the old version sorts first
+	for _, name := range []string{"libnvidia-ml.so.535.161.07", "libnvidia-ml.so.550.67", "libcuda.so.550.54.14"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("libnvidia-ml.so.550.67", filepath.Join(dir, "libnvidia-ml.so.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := sysroot.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file, version := driverLibrary(fsys, "libnvidia-ml.so"); file != "/usr/lib/x86_64-linux-gnu/libnvidia-ml.so.550.67" || version != "550.67" {
+		t.Errorf("NVML = %q %q", file, version)
+	}
+	if file, version := driverLibrary(fsys, "libcuda.so"); file != "/usr/lib/x86_64-linux-gnu/libcuda.so.550.54.14" || version != "550.54.14" {
+		t.Errorf("libcuda = %q %q", file, version)
+	}
+	if file, version := driverLibrary(fsys, "libnvidia-encode.so"); file != "" || version != "" {
+		t.Errorf("expected nothing for a missing library, got %q %q", file, version)
+	}
+}
@@ -3,41 +3,34 @@
 package linux
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
-// knownXidDescriptions maps NVIDIA Xid error codes to human-readable descriptions.
-var knownXidDescriptions = map[int]string{
-	13:  "Graphics engine fault",
-	31:  "GPU memory page fault",
-	32:  "Invalid or corrupted push buffer",
-	43:  "GPU stopped processing",
-	48:  "Double-bit ECC error",
-	56:  "Display engine error",
-	57:  "Encoder/decoder error",
-	63:  "Row remapper failure",
-	69:  "Graphics engine exception",
-	79:  "GPU has fallen off the bus",
-	119: "GSP firmware error",
-}
+// checkXidDmesg reads the kernel ring buffer, which needs root when
+// kernel.dmesg_restrict is set (the default on most distributions).
+var checkXidDmesg = collector.Check{ID: "linux.xid.dmesg", Needs: collector.PrivilegeAdmin, Affects: []string{"xid-errors"}}
 
 // CollectXidErrors parses NVIDIA Xid errors from kernel logs using dmesg
-// and journalctl. Errors are grouped by Xid code with occurrence counts.
-func CollectXidErrors(timeout int) ([]types.XidError, []types.CollectorError) {
+// and journalctl. Errors are grouped by Xid code with occurrence counts and
+// described using the Xid table from the knowledge pack.
+func CollectXidErrors(ctx context.Context, gate *collector.Gate, timeout int, pack *knowledge.Pack) ([]types.XidError, []types.CollectorError) {
 	var errs []types.CollectorError
 
 	// Try dmesg first
-	xidLines := collectXidFromDmesg(timeout, &errs)
+	xidLines := collectXidFromDmesg(ctx, gate, timeout, &errs)
 
 	// If dmesg returned nothing, try journalctl as fallback
 	if len(xidLines) == 0 {
-		xidLines = collectXidFromJournalctl(timeout, &errs)
+		xidLines = collectXidFromJournalctl(ctx, timeout, &errs)
 	}
 
 	if len(xidLines) == 0 {
@@ -45,42 +38,45 @@ func CollectXidErrors(timeout int) ([]types.XidError, []types.CollectorError) {
 	}
 
 	// Parse and group the Xid errors
-	xidErrors := parseAndGroupXidErrors(xidLines)
+	xidErrors := parseAndGroupXidErrors(xidLines, pack)
 
 	return xidErrors, errs
 }
 
 // collectXidFromDmesg attempts to extract Xid error lines from dmesg output.
-func collectXidFromDmesg(timeout int, errs *[]types.CollectorError) []string {
-	if !util.CommandExists("dmesg") {
+func collectXidFromDmesg(ctx context.Context, gate *collector.Gate, timeout int, errs *[]types.CollectorError) []string {
+	if !util.CommandExists("dmesg") || !gate.Allow(checkXidDmesg) {
 		return nil
 	}
 
-	r := util.RunCommand(timeout, "sh", "-c", `dmesg 2>/dev/null | grep -i "NVRM: Xid"`)
+	r := util.RunCommandContext(ctx, timeout, "dmesg")
 	if r.Err != nil {
-		// dmesg may require root; this is non-fatal
+		if util.AccessDenied(r) && gate.Denied(checkXidDmesg) {
+			return nil
+		}
 		*errs = append(*errs, types.CollectorError{
 			Collector: "linux.xid.dmesg",
-			Error:     "dmesg Xid grep failed (may need root): " + r.Err.Error(),
+			Error:     "dmesg failed: " + r.Err.Error(),
 		})
 		return nil
 	}
 
-	output := strings.TrimSpace(r.Stdout)
-	if output == "" {
-		return nil
+	var lines []string
+	for _, line := range strings.Split(r.Stdout, "\n") {
+		if strings.Contains(strings.ToLower(line), "nvrm: xid") {
+			lines = append(lines, line)
+		}
 	}
-
-	return strings.Split(output, "\n")
+	return lines
 }
 
 // collectXidFromJournalctl attempts to extract Xid error lines from journalctl.
-func collectXidFromJournalctl(timeout int, errs *[]types.CollectorError) []string {
+func collectXidFromJournalctl(ctx context.Context, timeout int, errs *[]types.CollectorError) []string {
 	if !util.CommandExists("journalctl") {
 		return nil
 	}
 
-	r := util.RunCommand(timeout, "sh", "-c", `journalctl -k -b --no-pager 2>/dev/null | grep -i "NVRM: Xid"`)
+	r := util.RunCommandContext(ctx, timeout, "sh", "-c", `journalctl -k -b --no-pager 2>/dev/null | grep -i "NVRM: Xid"`)
 	if r.Err != nil {
 		*errs = append(*errs, types.CollectorError{
 			Collector: "linux.xid.journalctl",
@@ -99,7 +95,7 @@ func collectXidFromJournalctl(timeout int, errs *[]types.CollectorError) []strin
 
 // parseAndGroupXidErrors parses raw kernel log lines containing Xid errors,
 // extracts the Xid code and timestamp, and groups by code with counts.
-func parseAndGroupXidErrors(lines []string) []types.XidError {
+func parseAndGroupXidErrors(lines []string, pack *knowledge.Pack) []types.XidError {
 	// Pattern matches lines like:
 	//   [ 1234.567890] NVRM: Xid (PCI:0000:01:00): 79, pid=1234, ...
 	//   Jan 15 10:30:45 hostname kernel: NVRM: Xid (PCI:0000:01:00): 79, pid=1234, ...
@@ -160,17 +156,14 @@ func parseAndGroupXidErrors(lines []string) []types.XidError {
 	for _, code := range seenOrder {
 		g := groups[code]
 
-		msg, ok := knownXidDescriptions[code]
-		if !ok {
-			msg = "Unknown Xid error"
-		}
-
-		result = append(result, types.XidError{
+		xe := types.XidError{
 			Code:      g.code,
-			Message:   msg,
 			Timestamp: g.lastSeen,
 			Count:     g.count,
-		})
+		}
+		pack.DescribeXid(&xe)
+
+		result = append(result, xe)
 	}
 
 	return result
@@ -0,0 +1,37 @@
+//go:build linux
+
+package linux
+
+import (
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/knowledge"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func TestParseAndGroupXidErrors(t *testing.T) {
+	lines := []string{
+		"[ 1234.567890] NVRM: Xid (PCI:0000:01:00): 79, pid=1234, GPU has fallen off the bus.",
+		"[ 1240.000000] NVRM: Xid (PCI:0000:01:00): 79, pid=1234, GPU has fallen off the bus.",
+		"[ 1300.000000] NVRM: Xid (PCI:0000:01:00): 8, pid=99, Channel 00000010",
+		"[ 1400.000000] NVRM: Xid (PCI:0000:01:00): 9999, pid=99, something new",
+	}
+
+	xids := parseAndGroupXidErrors(lines, knowledge.Default())
+	if len(xids) != 3 {
+		t.Fatalf("expected 3 grouped codes, got %d", len(xids))
+	}
+
+	if xids[0].Code != 79 || xids[0].Count != 2 {
+		t.Errorf("expected Xid 79 x2 first, got Xid %d x%d", xids[0].Code, xids[0].Count)
+	}
+	if xids[0].Severity != types.SeverityCrit || xids[0].Detail == "" {
+		t.Errorf("expected Xid 79 to carry CRIT severity and detail, got %+v", xids[0])
+	}
+	if xids[1].Severity != types.SeverityWarn {
+		t.Errorf("expected Xid 8 to be WARN, got %s", xids[1].Severity)
+	}
+	if !xids[2].Unrecognized {
+		t.Error("expected Xid 9999 to be marked unrecognized")
+	}
+}
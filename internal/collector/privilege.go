@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// Check is a single probe inside a collector that may need elevated rights,
+// e.g. reading the kernel ring buffer with dmesg.
+type Check struct {
+	ID    string
+	Needs Privilege
+	// Affects lists the rule IDs whose findings rely on this check's data.
+	Affects []string
+}
+
+// Gate decides whether privileged checks may run and records the ones that
+// did not, so the report can say what is missing and the analyzer can lower
+// its confidence. A nil Gate allows everything and records nothing, which
+// suits callers outside the run pipeline (snapshot, doctor).
+type Gate struct {
+	noAdmin  bool
+	elevated bool
+
+	mu      sync.Mutex
+	skipped []types.SkippedCheck
+}
+
+// NewGate returns a gate for a run. noAdmin is the --no-admin flag; elevated
+// is whether the process runs as root/Administrator.
+func NewGate(noAdmin, elevated bool) *Gate {
+	return &Gate{noAdmin: noAdmin, elevated: elevated}
+}
+
+// Allow reports whether c may run. Under --no-admin, checks that need admin
+// are skipped and recorded.
+func (g *Gate) Allow(c Check) bool {
+	if g == nil || c.Needs != PrivilegeAdmin || !g.noAdmin {
+		return true
+	}
+	g.Skip(c, "--no-admin")
+	return false
+}
+
+// Denied is called when c failed. If c needs admin and the process is not
+// elevated, the failure is recorded as a skipped check rather than an error
+// and Denied returns true.
+func (g *Gate) Denied(c Check) bool {
+	if g == nil || c.Needs != PrivilegeAdmin || g.elevated {
+		return false
+	}
+	g.Skip(c, "not running as root/Administrator")
+	return true
+}
+
+// Skip records that c did not run for the given reason.
+func (g *Gate) Skip(c Check, reason string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, s := range g.skipped {
+		if s.Check == c.ID {
+			return
+		}
+	}
+	g.skipped = append(g.skipped, types.SkippedCheck{
+		Check:   c.ID,
+		Needs:   c.Needs.String(),
+		Reason:  reason,
+		Affects: c.Affects,
+	})
+}
+
+// Skipped returns the checks recorded so far, in the order they were skipped.
+func (g *Gate) Skipped() []types.SkippedCheck {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]types.SkippedCheck(nil), g.skipped...)
+}
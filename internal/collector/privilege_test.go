@@ -0,0 +1,41 @@
+package collector
+
+import "testing"
+
+func TestGate(t *testing.T) {
+	admin := Check{ID: "dmesg", Needs: PrivilegeAdmin, Affects: []string{"xid-errors"}}
+	plain := Check{ID: "lspci"}
+
+	g := NewGate(true, false)
+	if !g.Allow(plain) {
+		t.Error("unprivileged checks always run")
+	}
+	if g.Allow(admin) {
+		t.Error("admin checks should be skipped under --no-admin")
+	}
+	g.Allow(admin)
+	if s := g.Skipped(); len(s) != 1 || s[0].Check != "dmesg" || s[0].Reason != "--no-admin" || s[0].Needs != "admin" {
+		t.Errorf("expected one recorded skip, got %+v", s)
+	}
+
+	g = NewGate(false, false)
+	if !g.Allow(admin) {
+		t.Error("admin checks run when --no-admin is not set")
+	}
+	if !g.Denied(admin) || g.Denied(plain) {
+		t.Error("only admin checks that fail without elevation count as denied")
+	}
+
+	g = NewGate(false, true)
+	if g.Denied(admin) {
+		t.Error("failures while elevated are real errors, not missing privileges")
+	}
+	if len(g.Skipped()) != 0 {
+		t.Error("nothing should be recorded while elevated")
+	}
+
+	var none *Gate
+	if !none.Allow(admin) || none.Denied(admin) || none.Skipped() != nil {
+		t.Error("a nil gate allows everything and records nothing")
+	}
+}
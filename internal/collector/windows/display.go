@@ -3,6 +3,7 @@
 package windows
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -22,18 +23,18 @@ type wmiVideoController struct {
 
 // CollectDisplayInfo gathers display/monitor information on Windows via WMI
 // and registry queries for HDR and G-Sync/VRR status.
-func CollectDisplayInfo(timeout int) ([]types.DisplayInfo, []types.CollectorError) {
+func CollectDisplayInfo(ctx context.Context, timeout int) ([]types.DisplayInfo, []types.CollectorError) {
 	var displays []types.DisplayInfo
 	var errs []types.CollectorError
 
 	// Query video controllers via WMI
-	controllers := queryVideoControllers(timeout, &errs)
+	controllers := queryVideoControllers(ctx, timeout, &errs)
 
 	// Query HDR status from registry
-	hdrEnabled := queryHDRStatus(timeout, &errs)
+	hdrEnabled := queryHDRStatus(ctx, timeout, &errs)
 
 	// Query G-Sync/VRR status from registry
-	vrrEnabled := queryGSyncStatus(timeout, &errs)
+	vrrEnabled := queryGSyncStatus(ctx, timeout, &errs)
 
 	// Build DisplayInfo entries from controllers
 	for i, ctl := range controllers {
@@ -64,8 +65,8 @@ func CollectDisplayInfo(timeout int) ([]types.DisplayInfo, []types.CollectorErro
 
 // queryVideoControllers runs a PowerShell WMI query for Win32_VideoController
 // and parses the JSON output into a slice of controller structs.
-func queryVideoControllers(timeout int, errs *[]types.CollectorError) []wmiVideoController {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func queryVideoControllers(ctx context.Context, timeout int, errs *[]types.CollectorError) []wmiVideoController {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-CimInstance -ClassName Win32_VideoController | Select-Object Name, CurrentHorizontalResolution, CurrentVerticalResolution, CurrentRefreshRate, AdapterCompatibility | ConvertTo-Json`)
 
 	if r.Err != nil {
@@ -164,8 +165,8 @@ func extractJSONIntField(obj, key string) int {
 }
 
 // queryHDRStatus checks the Windows registry for HDR enablement.
-func queryHDRStatus(timeout int, errs *[]types.CollectorError) bool {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func queryHDRStatus(ctx context.Context, timeout int, errs *[]types.CollectorError) bool {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`try { (Get-ItemProperty -Path 'HKLM:\SYSTEM\CurrentControlSet\Control\GraphicsDrivers' -Name EnableHDR -ErrorAction Stop).EnableHDR } catch { "NotFound" }`)
 
 	if r.Err != nil {
@@ -181,8 +182,8 @@ func queryHDRStatus(timeout int, errs *[]types.CollectorError) bool {
 }
 
 // queryGSyncStatus checks the Windows registry for G-Sync/VRR enablement.
-func queryGSyncStatus(timeout int, errs *[]types.CollectorError) bool {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func queryGSyncStatus(ctx context.Context, timeout int, errs *[]types.CollectorError) bool {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`try { (Get-ItemProperty -Path 'HKLM:\SYSTEM\CurrentControlSet\Services\nvlddmkm\Global\GSync' -ErrorAction Stop) | Out-String } catch { "NotFound" }`)
 
 	if r.Err != nil {
@@ -0,0 +1,35 @@
+//go:build windows
+
+package windows
+
+import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func init() {
+	collector.Register(collector.Spec{
+		ID:       "windows",
+		OS:       []string{"windows"},
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeStreaming, types.ModeCreator, types.ModeFull},
+		Checks:   []collector.Check{checkWHEA},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := CollectWindowsInfo(ctx, env.Gate, env.Config.Timeout, env.Config.IncludeLogs)
+			r.Windows = &info
+			return errs
+		},
+	})
+
+	collector.Register(collector.Spec{
+		ID:       "windows.display",
+		OS:       []string{"windows"},
+		RunModes: []types.RunMode{types.ModeGaming, types.ModeStreaming, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			displays, errs := CollectDisplayInfo(ctx, env.Config.Timeout)
+			r.Displays = displays
+			return errs
+		},
+	})
+}
@@ -3,35 +3,41 @@
 package windows
 
 import (
+	"context"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
 	"github.com/nicholasgasior/nvcheckup/internal/util"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
+// checkWHEA reads WHEA-Logger events, which are only fully visible to
+// Administrators.
+var checkWHEA = collector.Check{ID: "windows.events.whea", Needs: collector.PrivilegeAdmin, Affects: []string{"whea-errors"}}
+
 // CollectWindowsInfo gathers Windows-specific diagnostic data.
-func CollectWindowsInfo(timeout int, includeLogs bool) (types.WindowsInfo, []types.CollectorError) {
+func CollectWindowsInfo(ctx context.Context, gate *collector.Gate, timeout int, includeLogs bool) (types.WindowsInfo, []types.CollectorError) {
 	var info types.WindowsInfo
 	var errs []types.CollectorError
 
-	collectHAGS(&info, &errs, timeout)
-	collectGameMode(&info, &errs, timeout)
-	collectPowerPlan(&info, &errs, timeout)
-	collectMonitors(&info, &errs, timeout)
-	collectDriverResetEvents(&info, &errs, timeout)
-	collectNvlddmkmErrors(&info, &errs, timeout)
-	collectWHEAErrors(&info, &errs, timeout)
-	collectRecentUpdates(&info, &errs, timeout)
-	collectNVIDIAApp(&info, &errs, timeout)
-	collectOverlaySoftware(&info, &errs, timeout)
+	collectHAGS(ctx, &info, &errs, timeout)
+	collectGameMode(ctx, &info, &errs, timeout)
+	collectPowerPlan(ctx, &info, &errs, timeout)
+	collectMonitors(ctx, &info, &errs, timeout)
+	collectDriverResetEvents(ctx, &info, &errs, timeout)
+	collectNvlddmkmErrors(ctx, &info, &errs, timeout)
+	collectWHEAErrors(ctx, gate, &info, &errs, timeout)
+	collectRecentUpdates(ctx, &info, &errs, timeout)
+	collectNVIDIAApp(ctx, &info, &errs, timeout)
+	collectOverlaySoftware(ctx, &info, &errs, timeout)
 
 	return info, errs
 }
 
-func collectHAGS(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectHAGS(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`try { (Get-ItemProperty -Path "HKLM:\SYSTEM\CurrentControlSet\Control\GraphicsDrivers" -Name HwSchMode -ErrorAction Stop).HwSchMode } catch { "Unknown" }`)
 	if r.Err == nil {
 		val := strings.TrimSpace(r.Stdout)
@@ -49,8 +55,8 @@ func collectHAGS(info *types.WindowsInfo, errs *[]types.CollectorError, timeout
 	}
 }
 
-func collectGameMode(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectGameMode(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`try { (Get-ItemProperty -Path "HKCU:\Software\Microsoft\GameBar" -Name AutoGameModeEnabled -ErrorAction Stop).AutoGameModeEnabled } catch { "Unknown" }`)
 	if r.Err == nil {
 		val := strings.TrimSpace(r.Stdout)
@@ -67,8 +73,8 @@ func collectGameMode(info *types.WindowsInfo, errs *[]types.CollectorError, time
 	}
 }
 
-func collectPowerPlan(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectPowerPlan(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`(Get-CimInstance -Namespace root\cimv2\power -ClassName Win32_PowerPlan | Where-Object { $_.IsActive }).ElementName`)
 	if r.Err == nil {
 		info.PowerPlan = strings.TrimSpace(r.Stdout)
@@ -78,8 +84,8 @@ func collectPowerPlan(info *types.WindowsInfo, errs *[]types.CollectorError, tim
 	}
 }
 
-func collectMonitors(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectMonitors(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-CimInstance -Namespace root\wmi -ClassName WmiMonitorBasicDisplayParams -ErrorAction SilentlyContinue | ForEach-Object { "$($_.InstanceName)|$($_.MaxHorizontalImageSize)x$($_.MaxVerticalImageSize)" }`)
 	if r.Err == nil && r.Stdout != "" {
 		for _, line := range strings.Split(r.Stdout, "\n") {
@@ -99,7 +105,7 @@ func collectMonitors(info *types.WindowsInfo, errs *[]types.CollectorError, time
 	}
 
 	// Get resolution and refresh rate
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-CimInstance Win32_VideoController | ForEach-Object { "$($_.CurrentHorizontalResolution)x$($_.CurrentVerticalResolution)|$($_.CurrentRefreshRate)Hz" }`)
 	if r.Err == nil && r.Stdout != "" {
 		lines := strings.Split(r.Stdout, "\n")
@@ -128,9 +134,9 @@ func collectMonitors(info *types.WindowsInfo, errs *[]types.CollectorError, time
 	}
 }
 
-func collectDriverResetEvents(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+func collectDriverResetEvents(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
 	// Event ID 4101 — Display driver stopped responding and has recovered
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-WinEvent -FilterHashtable @{LogName='System'; Id=4101; StartTime=(Get-Date).AddDays(-30)} -MaxEvents 50 -ErrorAction SilentlyContinue | ForEach-Object { "$($_.TimeCreated)|$($_.Id)|$($_.LevelDisplayName)|$($_.Message.Substring(0, [Math]::Min(200, $_.Message.Length)))" }`)
 	if r.Err == nil && r.Stdout != "" {
 		info.DriverResetEvents = parseEventLines(r.Stdout)
@@ -142,24 +148,39 @@ func collectDriverResetEvents(info *types.WindowsInfo, errs *[]types.CollectorEr
 	}
 }
 
-func collectNvlddmkmErrors(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectNvlddmkmErrors(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-WinEvent -FilterHashtable @{LogName='System'; ProviderName='nvlddmkm'; StartTime=(Get-Date).AddDays(-30)} -MaxEvents 50 -ErrorAction SilentlyContinue | ForEach-Object { "$($_.TimeCreated)|$($_.Id)|$($_.LevelDisplayName)|$($_.Message.Substring(0, [Math]::Min(200, $_.Message.Length)))" }`)
 	if r.Err == nil && r.Stdout != "" {
 		info.NvlddmkmErrors = parseEventLines(r.Stdout)
 	}
 }
 
-func collectWHEAErrors(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
-		`Get-WinEvent -FilterHashtable @{LogName='System'; ProviderName='Microsoft-Windows-WHEA-Logger'; StartTime=(Get-Date).AddDays(-30)} -MaxEvents 20 -ErrorAction SilentlyContinue | ForEach-Object { "$($_.TimeCreated)|$($_.Id)|$($_.LevelDisplayName)|WHEA Error" }`)
-	if r.Err == nil && r.Stdout != "" {
+func collectWHEAErrors(ctx context.Context, gate *collector.Gate, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	if !gate.Allow(checkWHEA) {
+		return
+	}
+	// The error's FullyQualifiedErrorId is the same in every Windows language,
+	// unlike its message
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
+		`try { Get-WinEvent -FilterHashtable @{LogName='System'; ProviderName='Microsoft-Windows-WHEA-Logger'; StartTime=(Get-Date).AddDays(-30)} -MaxEvents 20 -ErrorAction Stop | ForEach-Object { "$($_.TimeCreated)|$($_.Id)|$($_.LevelDisplayName)|WHEA Error" } } catch { [Console]::Error.WriteLine($_.FullyQualifiedErrorId); exit 1 }`)
+	switch {
+	case r.Err == nil:
 		info.WHEAErrors = parseEventLines(r.Stdout)
+	case strings.Contains(r.Stderr, "NoMatchingEventsFound"):
+		// No WHEA errors in the last 30 days
+	case util.AccessDenied(r) && gate.Denied(checkWHEA):
+		// Recorded as a skipped check
+	default:
+		*errs = append(*errs, types.CollectorError{
+			Collector: "windows.events.whea",
+			Error:     "Could not read WHEA events: " + r.Err.Error(),
+		})
 	}
 }
 
-func collectRecentUpdates(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+func collectRecentUpdates(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-HotFix | Where-Object { $_.InstalledOn -gt (Get-Date).AddDays(-60) } | Sort-Object InstalledOn -Descending | ForEach-Object { "$($_.HotFixID)|$($_.Description)|$($_.InstalledOn.ToString('yyyy-MM-dd'))" }`)
 	if r.Err == nil && r.Stdout != "" {
 		for _, line := range strings.Split(r.Stdout, "\n") {
@@ -187,25 +208,25 @@ func collectRecentUpdates(info *types.WindowsInfo, errs *[]types.CollectorError,
 	}
 }
 
-func collectNVIDIAApp(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+func collectNVIDIAApp(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
 	// Check for NVIDIA App
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-ItemProperty "HKLM:\SOFTWARE\NVIDIA Corporation\NVIDIA App" -ErrorAction SilentlyContinue | ForEach-Object { $_.Version }`)
 	if r.Err == nil && strings.TrimSpace(r.Stdout) != "" {
 		info.NVIDIAAppVersion = strings.TrimSpace(r.Stdout)
 	}
 
 	// Check for GeForce Experience
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-ItemProperty "HKLM:\SOFTWARE\NVIDIA Corporation\Global\GFExperience" -ErrorAction SilentlyContinue | ForEach-Object { $_.Version }`)
 	if r.Err == nil && strings.TrimSpace(r.Stdout) != "" {
 		info.GFEVersion = strings.TrimSpace(r.Stdout)
 	}
 }
 
-func collectOverlaySoftware(info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
+func collectOverlaySoftware(ctx context.Context, info *types.WindowsInfo, errs *[]types.CollectorError, timeout int) {
 	// Detect overlay software by checking installed programs
-	r := util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r := util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`$apps = Get-ItemProperty "HKLM:\SOFTWARE\Microsoft\Windows\CurrentVersion\Uninstall\*","HKLM:\SOFTWARE\WOW6432Node\Microsoft\Windows\CurrentVersion\Uninstall\*" -ErrorAction SilentlyContinue | Select-Object -ExpandProperty DisplayName -ErrorAction SilentlyContinue; $apps -join "`+"`n"+`"`)
 	if r.Err == nil {
 		appList := strings.ToLower(r.Stdout)
@@ -228,7 +249,7 @@ func collectOverlaySoftware(info *types.WindowsInfo, errs *[]types.CollectorErro
 	}
 
 	// Check Xbox Game Bar specifically
-	r = util.RunCommand(timeout, "powershell", "-NoProfile", "-Command",
+	r = util.RunCommandContext(ctx, timeout, "powershell", "-NoProfile", "-Command",
 		`Get-AppxPackage -Name Microsoft.XboxGamingOverlay -ErrorAction SilentlyContinue | Select-Object -ExpandProperty Version`)
 	if r.Err == nil && strings.TrimSpace(r.Stdout) != "" {
 		found := false
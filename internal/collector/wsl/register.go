@@ -0,0 +1,22 @@
+package wsl
+
+import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func init() {
+	collector.Register(collector.Spec{
+		ID:       "wsl",
+		RunModes: []types.RunMode{types.ModeAI, types.ModeFull},
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			info, errs := DetectWSL(ctx, env.FS, env.Config.Timeout)
+			if info.IsWSL {
+				r.WSL = &info
+			}
+			return errs
+		},
+	})
+}
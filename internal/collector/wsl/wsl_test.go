@@ -0,0 +1,33 @@
+package wsl
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+)
+
+func TestDetectWSL_WithoutDxg(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("WSL detection only runs on Linux")
+	}
+	fsys, err := sysroot.New("testdata/sysroot/wsl-no-dxg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := DetectWSL(context.Background(), fsys, 5)
+	if !info.IsWSL {
+		t.Fatal("expected WSL to be detected from /proc/version")
+	}
+	if info.WSLVersion != "2" {
+		t.Errorf("expected WSL2, got %q", info.WSLVersion)
+	}
+	if info.Distro != "Ubuntu" {
+		t.Errorf("expected Ubuntu, got %q", info.Distro)
+	}
+	if info.DevDxgExists {
+		t.Error("/dev/dxg is missing from the tree and should not be detected")
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/analyzer"
+	"github.com/nicholasgasior/nvcheckup/internal/redact"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// LoadReport reads a saved report.json, or the report.json inside a bundle zip.
+func LoadReport(path string) (*types.Report, error) {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".zip") {
+		data, err = readReportFromZip(path)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var r types.Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("cannot parse report %s: %w", path, err)
+	}
+	if r.Metadata.ToolVersion == "" {
+		return nil, fmt.Errorf("%s does not look like an NVCheckup report.json", path)
+	}
+	return &r, nil
+}
+
+func readReportFromZip(path string) ([]byte, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open bundle: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if filepath.Base(f.Name) != "report.json" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read report.json from bundle: %w", err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("bundle %s has no report.json (it was created without --json)", path)
+}
+
+// Reanalyze discards the findings in a saved report and analyzes its
+// collected data again with this binary's knowledge pack and the given mode.
+// Collected data is left untouched apart from Xid descriptions and GPU
+// details, which are refreshed from the current xid_codes.json and
+// pci_ids.json.
+func Reanalyze(r *types.Report, mode types.RunMode, knowledgePath string, printFn func(string)) error {
+	pack, err := knowledge.Load(knowledgePath)
+	if err != nil {
+		return err
+	}
+	analyzer.UseKnowledge(pack)
+	if note := fallbackNote(pack); note != "" {
+		printFn(note)
+	}
+
+	for i := range r.GPUs {
+		pack.DescribeGPU(&r.GPUs[i])
+	}
+	if r.Linux != nil {
+		for i := range r.Linux.XidErrors {
+			pack.DescribeXid(&r.Linux.XidErrors[i])
+		}
+	}
+
+	r.Findings = nil
+	r.TopIssues = nil
+	r.NextSteps = nil
+	r.SummaryBlock = ""
+
+	r.Metadata.Mode = mode
+	r.Metadata.ReanalyzedBy = fmt.Sprintf("v%s (rules %s)", types.Version, pack.Version)
+	analyzer.Analyze(r, mode)
+
+	// The saved data was redacted (or not) when it was collected; keep the
+	// regenerated text consistent with that choice.
+	applyRedaction(r, redact.New(r.Metadata.RedactionEnabled))
+	return nil
+}
+
+// fallbackNote says which rules a --knowledge rules.json lacked, so a stale
+// override is noticed instead of silently using the embedded definitions.
+func fallbackNote(pack *knowledge.Pack) string {
+	if len(pack.FallbackRules) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Knowledge pack %s lacks %d rule(s); using the built-in definitions for: %s",
+		pack.Version, len(pack.FallbackRules), strings.Join(pack.FallbackRules, ", "))
+}
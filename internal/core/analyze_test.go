@@ -0,0 +1,122 @@
+package core
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func savedReport() *types.Report {
+	return &types.Report{
+		Metadata: types.ReportMetadata{
+			ToolVersion: "0.0.1",
+			Timestamp:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Mode:        types.ModeFull,
+			Platform:    "linux",
+		},
+		Linux: &types.LinuxInfo{
+			XidErrors: []types.XidError{{Code: 79, Message: "stale text", Count: 1}},
+		},
+		Findings:     []types.Finding{{Title: "Stale finding from an old release", Severity: types.SeverityCrit}},
+		TopIssues:    []string{"stale"},
+		SummaryBlock: "stale summary",
+	}
+}
+
+func writeBundle(t *testing.T, dir string, r *types.Report) string {
+	t.Helper()
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "bundle.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range map[string][]byte{"report.txt": []byte("text"), "report.json": data} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadReport_JSONAndBundle(t *testing.T) {
+	dir := t.TempDir()
+	data, _ := json.Marshal(savedReport())
+	jsonPath := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{jsonPath, writeBundle(t, dir, savedReport())} {
+		r, err := LoadReport(path)
+		if err != nil {
+			t.Fatalf("LoadReport(%s): %v", path, err)
+		}
+		if r.Metadata.ToolVersion != "0.0.1" || len(r.Findings) != 1 {
+			t.Errorf("LoadReport(%s) returned %+v", path, r.Metadata)
+		}
+	}
+}
+
+func TestLoadReport_BundleWithoutJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.zip")
+	f, _ := os.Create(path)
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("report.txt")
+	w.Write([]byte("text only"))
+	zw.Close()
+	f.Close()
+
+	if _, err := LoadReport(path); err == nil || !strings.Contains(err.Error(), "no report.json") {
+		t.Errorf("expected a missing report.json error, got %v", err)
+	}
+}
+
+func TestReanalyze(t *testing.T) {
+	r := savedReport()
+	r.GPUs = []types.GPUInfo{{Name: "AD102 [GeForce RTX 4090]", Vendor: "NVIDIA", PCIDeviceID: "2684", IsNVIDIA: true}}
+	if err := Reanalyze(r, types.ModeAI, "", quiet); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range r.Findings {
+		if f.Title == "Stale finding from an old release" {
+			t.Error("old findings should be discarded")
+		}
+	}
+	if len(r.Findings) == 0 {
+		t.Error("expected fresh findings for a report without an NVIDIA driver")
+	}
+	if r.SummaryBlock == "stale summary" || r.SummaryBlock == "" {
+		t.Errorf("expected a regenerated summary, got %q", r.SummaryBlock)
+	}
+	if r.Metadata.Mode != types.ModeAI {
+		t.Errorf("expected mode ai, got %s", r.Metadata.Mode)
+	}
+	if r.Metadata.ReanalyzedBy == "" {
+		t.Error("expected the report to be marked as re-analyzed")
+	}
+	if g := r.GPUs[0]; g.Architecture != "Ada Lovelace" || g.ComputeCapability != "8.9" {
+		t.Errorf("expected GPU details from the PCI ID table, got %+v", g)
+	}
+	if x := r.Linux.XidErrors[0]; x.Message == "stale text" || x.Severity == "" {
+		t.Errorf("expected the Xid description to be refreshed, got %+v", x)
+	}
+}
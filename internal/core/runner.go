@@ -2,25 +2,46 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/nicholasgasior/nvcheckup/internal/analyzer"
-	"github.com/nicholasgasior/nvcheckup/internal/collector/ai"
-	"github.com/nicholasgasior/nvcheckup/internal/collector/common"
-	"github.com/nicholasgasior/nvcheckup/internal/collector/wsl"
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	_ "github.com/nicholasgasior/nvcheckup/internal/collector/ai"
+	_ "github.com/nicholasgasior/nvcheckup/internal/collector/common"
+	_ "github.com/nicholasgasior/nvcheckup/internal/collector/wsl"
+	"github.com/nicholasgasior/nvcheckup/internal/nvsmi"
 	"github.com/nicholasgasior/nvcheckup/internal/redact"
 	"github.com/nicholasgasior/nvcheckup/internal/report"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // Run executes the full diagnostic pipeline and returns the completed report.
-func Run(cfg types.RunConfig, verbose bool, printFn func(string)) (*types.Report, error) {
+// If ctx is cancelled (e.g. Ctrl-C) collection stops, in-flight commands are
+// killed, and the report is finalized from whatever was collected and marked
+// incomplete.
+func Run(ctx context.Context, cfg types.RunConfig, verbose bool, printFn func(string)) (*types.Report, error) {
 	startTime := time.Now()
 
+	// Load and validate the knowledge pack before collecting anything, so a
+	// broken --knowledge override fails fast.
+	pack, err := knowledge.Load(cfg.KnowledgePath)
+	if err != nil {
+		return nil, err
+	}
+	analyzer.UseKnowledge(pack)
+	if note := fallbackNote(pack); note != "" {
+		printFn(note)
+	}
+
 	r := &types.Report{
 		Metadata: types.ReportMetadata{
 			ToolVersion:      types.Version,
@@ -28,79 +49,74 @@ func Run(cfg types.RunConfig, verbose bool, printFn func(string)) (*types.Report
 			Mode:             cfg.Mode,
 			RedactionEnabled: cfg.Redact,
 			Platform:         runtime.GOOS,
+			Elevated:         util.IsElevated(),
 		},
 	}
 
 	redactor := redact.New(cfg.Redact)
 	var allErrors []types.CollectorError
 
-	// Phase 1: Collect system info
-	printFn("[1/7] Collecting system information...")
-	sysInfo, sysErrs := common.CollectSystemInfo(cfg.Timeout)
-	r.System = sysInfo
-	allErrors = append(allErrors, sysErrs...)
-
-	// Phase 2: Collect GPU info
-	printFn("[2/7] Detecting GPUs and drivers...")
-	gpus, driver, gpuErrs := common.CollectGPUInfo(cfg.Timeout)
-	r.GPUs = gpus
-	r.Driver = driver
-	allErrors = append(allErrors, gpuErrs...)
-
-	// Phase 3: GPU thermal + PCIe
-	printFn("[3/7] Collecting GPU thermal and PCIe data...")
-	thermalInfo, thermalErrs := common.CollectThermalInfo(cfg.Timeout)
-	if thermalInfo.TemperatureC > 0 || thermalInfo.PowerState != "" {
-		r.Thermal = &thermalInfo
-	}
-	allErrors = append(allErrors, thermalErrs...)
-
-	pcieInfo, pcieErrs := common.CollectPCIeInfo(cfg.Timeout)
-	if pcieInfo.CurrentSpeed != "" || pcieInfo.MaxSpeed != "" {
-		r.PCIe = &pcieInfo
-	}
-	allErrors = append(allErrors, pcieErrs...)
-
-	// Phase 4: Platform-specific collection (Windows/Linux)
-	printFn("[4/7] Running platform-specific checks...")
-	platformErrs := collectPlatformSpecific(r, cfg)
-	allErrors = append(allErrors, platformErrs...)
-
-	// Phase 5: AI/CUDA checks (if applicable mode)
-	if cfg.Mode == types.ModeAI || cfg.Mode == types.ModeFull || cfg.Mode == types.ModeCreator {
-		printFn("[5/7] Checking AI/CUDA environment...")
-		aiInfo, aiErrs := ai.CollectAIInfo(cfg.Timeout)
-		r.AI = &aiInfo
-		allErrors = append(allErrors, aiErrs...)
-	} else {
-		printFn("[5/7] Skipping AI checks (not selected)...")
-	}
-
-	// WSL detection (full mode or AI mode)
-	if cfg.Mode == types.ModeFull || cfg.Mode == types.ModeAI {
-		wslInfo, wslErrs := wsl.DetectWSL(cfg.Timeout)
-		if wslInfo.IsWSL {
-			r.WSL = &wslInfo
+	fsys, err := sysroot.New(cfg.Sysroot)
+	if err != nil {
+		return nil, err
+	}
+
+	collectors, err := collector.Select(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checks that need root/Administrator go through the gate individually;
+	// say up front which collectors will come back with partial data
+	gate := collector.NewGate(cfg.NoAdmin, r.Metadata.Elevated)
+	if cfg.NoAdmin || !r.Metadata.Elevated {
+		var limited []string
+		for _, c := range collectors {
+			if c.Privileges() == collector.PrivilegeAdmin {
+				limited = append(limited, c.Name())
+			}
+		}
+		if len(limited) > 0 {
+			printFn(fmt.Sprintf("Without root/Administrator, some checks in %s may be skipped.", strings.Join(limited, ", ")))
 		}
-		allErrors = append(allErrors, wslErrs...)
 	}
 
-	// Phase 6: Network diagnostics (if enabled or relevant mode)
-	if cfg.NetworkTest || cfg.Mode == types.ModeGaming || cfg.Mode == types.ModeStreaming || cfg.Mode == types.ModeFull {
-		printFn("[6/7] Running network diagnostics...")
-		netInfo, netErrs := common.CollectNetworkInfo(cfg.Timeout)
-		if netInfo.InterfaceName != "" {
-			r.Network = &netInfo
+	// Record or replay every external command the collectors run
+	if cfg.ReplayDir != "" {
+		rec, err := util.StartReplay(cfg.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+		defer util.StopRecording()
+		if rec.Platform != runtime.GOOS {
+			return nil, fmt.Errorf("recording was made on %s and can only be replayed there", rec.Platform)
+		}
+		r.Metadata.Replayed = true
+	} else if cfg.RecordDir != "" {
+		if err := util.StartRecording(cfg.RecordDir); err != nil {
+			return nil, err
 		}
-		allErrors = append(allErrors, netErrs...)
-	} else {
-		printFn("[6/7] Skipping network checks...")
 	}
 
+	// Run collectors concurrently within the overall time budget
+	env := &collector.Env{Config: cfg, Knowledge: pack, FS: fsys, Gate: gate, SMI: &nvsmi.Cache{}}
+	runs, collectErrs := runCollectors(ctx, collectors, env, r, time.Duration(cfg.Budget)*time.Second, printFn)
+	r.Collectors = runs
+	allErrors = append(allErrors, collectErrs...)
+
 	r.CollectorErrors = allErrors
+	r.SkippedChecks = gate.Skipped()
+
+	if cfg.RecordDir != "" && cfg.ReplayDir == "" {
+		path, err := util.StopRecording()
+		if err != nil {
+			return nil, err
+		}
+		printFn(fmt.Sprintf("Recorded command output: %s (not redacted)", path))
+	}
 
-	// Phase 7: Analyze and produce findings
-	printFn("[7/7] Analyzing results...")
+	// Analyze and produce findings
+	printFn(fmt.Sprintf("[%d/%d] Analyzing results...", len(collectors)+1, len(collectors)+1))
 	analyzer.Analyze(r, cfg.Mode)
 
 	// Calculate runtime
@@ -134,8 +150,8 @@ func WriteReport(r *types.Report, cfg types.RunConfig) ([]string, error) {
 	}
 	outputFiles = append(outputFiles, txtPath)
 
-	// JSON if requested
-	if cfg.JSON {
+	// JSON if requested, and always for partial reports so nothing collected is lost
+	if cfg.JSON || r.Metadata.Incomplete {
 		jsonPath := filepath.Join(outDir, "report.json")
 		jsonContent, err := report.GenerateJSON(r)
 		if err != nil {
@@ -164,9 +180,13 @@ func applyRedaction(r *types.Report, redactor *redact.Redactor) {
 	r.System.Hostname = redactor.RedactHostname(r.System.Hostname)
 	r.SummaryBlock = redactor.Redact(r.SummaryBlock)
 
-	// Redact GPU bus IDs paths if needed
+	// Redact GPU bus IDs paths if needed, and GPU process names (which
+	// are often paths under the user's home)
 	for i := range r.GPUs {
 		r.GPUs[i].PCIBusID = redactor.Redact(r.GPUs[i].PCIBusID)
+		for j := range r.GPUs[i].Processes {
+			r.GPUs[i].Processes[j].Name = redactor.Redact(r.GPUs[i].Processes[j].Name)
+		}
 	}
 
 	// Redact nvidia-smi output
@@ -3,12 +3,20 @@
 package core
 
 import (
+	"context"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
-func collectPlatformSpecific(r *types.Report, cfg types.RunConfig) []types.CollectorError {
-	return []types.CollectorError{{
-		Collector: "platform",
-		Error:     "unsupported platform: platform-specific collectors not available",
-	}}
+func init() {
+	collector.Register(collector.Spec{
+		ID: "platform",
+		Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			return []types.CollectorError{{
+				Collector: "platform",
+				Error:     "unsupported platform: platform-specific collectors not available",
+			}}
+		},
+	})
 }
@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+// maxParallelCollectors bounds how many collectors run at once. Most of their
+// time is spent waiting on child processes, but running dozens of nvidia-smi
+// and PowerShell invocations at once can itself make a struggling machine worse.
+const maxParallelCollectors = 4
+
+// collectorResult is sent back by a collector goroutine when it finishes.
+type collectorResult struct {
+	name     string
+	base     *types.Report // snapshot the collector started from
+	work     *types.Report // the collector's private copy after Collect
+	errs     []types.CollectorError
+	duration time.Duration
+}
+
+// runCollectors runs the selected collectors concurrently, respecting their
+// dependencies, and merges each one's changes into r as it finishes.
+//
+// Collectors still running when ctx is cancelled (Ctrl-C) or the budget
+// expires are cancelled, which kills their child processes, and r is marked
+// incomplete. Every collector works on a private copy of the report, so one
+// that is cut off can never write into r afterwards. When budget is zero
+// there is no overall deadline.
+func runCollectors(ctx context.Context, collectors []collector.Collector, env *collector.Env, r *types.Report, budget time.Duration, printFn func(string)) ([]types.CollectorRun, []types.CollectorError) {
+	var allErrors []types.CollectorError
+	runs := make(map[string]*types.CollectorRun, len(collectors))
+	selected := make(map[string]bool, len(collectors))
+	for _, c := range collectors {
+		runs[c.Name()] = &types.CollectorRun{Name: c.Name(), Status: types.CollectorNotStarted}
+		selected[c.Name()] = true
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if budget > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, budget)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	results := make(chan collectorResult, len(collectors))
+	pending := append([]collector.Collector{}, collectors...)
+	finished := make(map[string]bool, len(collectors))
+	running := make(map[string]time.Time)
+	total := len(collectors) + 1
+	step := 0
+
+	ready := func(c collector.Collector) bool {
+		for _, dep := range collector.DependsOn(c) {
+			if selected[dep] && !finished[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for len(pending) > 0 || len(running) > 0 {
+		// Start as many ready collectors as the concurrency limit allows
+		next := pending[:0]
+		for _, c := range pending {
+			if len(running) >= maxParallelCollectors || !ready(c) {
+				next = append(next, c)
+				continue
+			}
+			c := c
+			base := cloneReport(r)
+			work := cloneReport(r)
+			running[c.Name()] = time.Now()
+			go func() {
+				start := time.Now()
+				errs := c.Collect(runCtx, env, work)
+				results <- collectorResult{name: c.Name(), base: base, work: work, errs: errs, duration: time.Since(start)}
+			}()
+		}
+		pending = next
+
+		if len(running) == 0 {
+			// Nothing can start: the remaining collectors wait on each other
+			for _, c := range pending {
+				var waiting []string
+				for _, dep := range collector.DependsOn(c) {
+					if selected[dep] && !finished[dep] {
+						waiting = append(waiting, dep)
+					}
+				}
+				reason := "waits on " + strings.Join(waiting, ", ") + ", which never finished"
+				runs[c.Name()].Note = "not started: " + reason
+				allErrors = append(allErrors, types.CollectorError{
+					Collector: c.Name(),
+					Error:     "not run: " + reason,
+				})
+			}
+			break
+		}
+
+		select {
+		case res := <-results:
+			delete(running, res.name)
+			finished[res.name] = true
+			mergeChanges(reflect.ValueOf(r).Elem(), reflect.ValueOf(res.base).Elem(), reflect.ValueOf(res.work).Elem())
+			allErrors = append(allErrors, res.errs...)
+
+			run := runs[res.name]
+			run.Status = types.CollectorCompleted
+			run.DurationMs = res.duration.Milliseconds()
+			run.Errors = len(res.errs)
+
+			step++
+			printFn(fmt.Sprintf("[%d/%d] Collected %s (%.1fs)", step, total, res.name, res.duration.Seconds()))
+
+		case <-runCtx.Done():
+			reason := fmt.Sprintf("the %s time budget ran out", budget)
+			if ctx.Err() != nil {
+				reason = "the run was interrupted"
+			}
+			for name, started := range running {
+				run := runs[name]
+				run.Status = types.CollectorCancelled
+				run.DurationMs = time.Since(started).Milliseconds()
+				run.Note = "cut off: " + reason
+				allErrors = append(allErrors, types.CollectorError{
+					Collector: name,
+					Error:     "cancelled: still running when " + reason,
+				})
+			}
+			for _, c := range pending {
+				runs[c.Name()].Note = "not started: " + reason
+				allErrors = append(allErrors, types.CollectorError{
+					Collector: c.Name(),
+					Error:     "not run: " + reason + " first",
+				})
+			}
+			r.Metadata.Incomplete = true
+			r.Metadata.IncompleteReason = reason + " before all collectors finished"
+			printFn(fmt.Sprintf("Stopping collection: %s; %d collector(s) cut off.", reason, len(running)+len(pending)))
+			return collectRuns(collectors, runs), allErrors
+		}
+	}
+
+	return collectRuns(collectors, runs), allErrors
+}
+
+// collectRuns returns the run records in collector order.
+func collectRuns(collectors []collector.Collector, runs map[string]*types.CollectorRun) []types.CollectorRun {
+	out := make([]types.CollectorRun, 0, len(collectors))
+	for _, c := range collectors {
+		out = append(out, *runs[c.Name()])
+	}
+	return out
+}
+
+// cloneReport deep-copies a report so a collector can work on it without
+// sharing memory with the live report.
+func cloneReport(r *types.Report) *types.Report {
+	data, err := json.Marshal(r)
+	if err != nil {
+		// The report is plain data; this cannot fail in practice.
+		panic("core: cannot clone report: " + err.Error())
+	}
+	var out types.Report
+	if err := json.Unmarshal(data, &out); err != nil {
+		panic("core: cannot clone report: " + err.Error())
+	}
+	return &out
+}
+
+// mergeChanges copies into dst every field that a collector changed, i.e.
+// where work differs from base. Structs and pointers to structs are merged
+// field by field so two collectors can fill different parts of one section.
+// Slices of structs are merged element by element over the entries the
+// collector started with, and entries it appended are appended to dst, so
+// the thermal and pcie collectors can each annotate r.GPUs while linux.procfs
+// adds a GPU nvidia-smi missed.
+func mergeChanges(dst, base, work reflect.Value) {
+	for i := 0; i < work.NumField(); i++ {
+		d, b, w := dst.Field(i), base.Field(i), work.Field(i)
+		if reflect.DeepEqual(b.Interface(), w.Interface()) {
+			continue
+		}
+		switch {
+		case w.Kind() == reflect.Struct && w.Type() != reflect.TypeOf(time.Time{}):
+			mergeChanges(d, b, w)
+		case w.Kind() == reflect.Ptr && w.Type().Elem().Kind() == reflect.Struct && !w.IsNil() && !d.IsNil():
+			if b.IsNil() {
+				b = reflect.New(w.Type().Elem())
+			}
+			mergeChanges(d.Elem(), b.Elem(), w.Elem())
+		case w.Kind() == reflect.Slice && w.Type().Elem().Kind() == reflect.Struct && w.Type().Elem() != reflect.TypeOf(time.Time{}) &&
+			w.Len() >= b.Len() && d.Len() >= b.Len():
+			for j := 0; j < b.Len(); j++ {
+				mergeChanges(d.Index(j), b.Index(j), w.Index(j))
+			}
+			if w.Len() > b.Len() {
+				d.Set(reflect.AppendSlice(d, w.Slice(b.Len(), w.Len())))
+			}
+		default:
+			d.Set(w)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicholasgasior/nvcheckup/internal/collector"
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func quiet(string) {}
+
+func TestRunCollectors_MergesSections(t *testing.T) {
+	cs := []collector.Collector{
+		collector.Spec{ID: "linux", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			r.Linux = &types.LinuxInfo{SessionType: "wayland"}
+			return nil
+		}},
+		collector.Spec{ID: "linux.xid", Deps: []string{"linux"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			if r.Linux == nil {
+				r.Linux = &types.LinuxInfo{}
+			}
+			r.Linux.XidErrors = []types.XidError{{Code: 79, Count: 1}}
+			return []types.CollectorError{{Collector: "linux.xid", Error: "dmesg needs root"}}
+		}},
+		collector.Spec{ID: "gpu", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			r.Driver.Version = "550.54"
+			return nil
+		}},
+	}
+
+	r := &types.Report{}
+	runs, errs := runCollectors(context.Background(), cs, &collector.Env{}, r, 0, quiet)
+
+	if r.Linux == nil || r.Linux.SessionType != "wayland" || len(r.Linux.XidErrors) != 1 {
+		t.Errorf("expected linux and linux.xid changes to be merged, got %+v", r.Linux)
+	}
+	if r.Driver.Version != "550.54" {
+		t.Errorf("expected driver version to be merged, got %q", r.Driver.Version)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 collector error, got %d", len(errs))
+	}
+	for _, run := range runs {
+		if run.Status != types.CollectorCompleted {
+			t.Errorf("%s: expected completed, got %s", run.Name, run.Status)
+		}
+	}
+}
+
+func TestRunCollectors_MergesGPUAnnotations(t *testing.T) {
+	cs := []collector.Collector{
+		collector.Spec{ID: "gpu", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			r.GPUs = []types.GPUInfo{{Index: 0, PCIBusID: "00000000:01:00.0"}, {Index: 1, PCIBusID: "00000000:41:00.0"}}
+			return nil
+		}},
+		collector.Spec{ID: "thermal", Deps: []string{"gpu"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			for i := range r.GPUs {
+				r.GPUs[i].Thermal = &types.ThermalInfo{GPUIndex: i, TemperatureC: 60 + i}
+			}
+			return nil
+		}},
+		collector.Spec{ID: "pcie", Deps: []string{"gpu"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			for i := range r.GPUs {
+				r.GPUs[i].PCIe = &types.PCIeInfo{GPUIndex: i, CurrentSpeed: "Gen4"}
+			}
+			return nil
+		}},
+	}
+
+	r := &types.Report{}
+	runCollectors(context.Background(), cs, &collector.Env{}, r, 0, quiet)
+
+	if len(r.GPUs) != 2 {
+		t.Fatalf("expected 2 GPUs, got %d", len(r.GPUs))
+	}
+	for i, g := range r.GPUs {
+		if g.Thermal == nil || g.PCIe == nil {
+			t.Fatalf("GPU %d: expected both thermal and pcie data to be merged, got %+v", i, g)
+		}
+		if g.Thermal.TemperatureC != 60+i || g.PCIBusID == "" {
+			t.Errorf("GPU %d: got %+v", i, g)
+		}
+	}
+}
+
+func TestRunCollectors_Budget(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	cs := []collector.Collector{
+		collector.Spec{ID: "fast", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			r.System.Hostname = "fast"
+			return nil
+		}},
+		collector.Spec{ID: "slow", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			<-release
+			r.System.CPUModel = "late write"
+			return nil
+		}},
+		collector.Spec{ID: "after-slow", Deps: []string{"slow"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			return nil
+		}},
+	}
+
+	r := &types.Report{}
+	runs, errs := runCollectors(context.Background(), cs, &collector.Env{}, r, 50*time.Millisecond, quiet)
+
+	want := map[string]types.CollectorStatus{
+		"fast":       types.CollectorCompleted,
+		"slow":       types.CollectorCancelled,
+		"after-slow": types.CollectorNotStarted,
+	}
+	for _, run := range runs {
+		if run.Status != want[run.Name] {
+			t.Errorf("%s: expected %s, got %s", run.Name, want[run.Name], run.Status)
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("expected 2 collector errors for cut-off collectors, got %d", len(errs))
+	}
+	if r.System.Hostname != "fast" {
+		t.Error("expected fast collector's data in the report")
+	}
+	if r.System.CPUModel != "" {
+		t.Error("cancelled collector must not write into the report")
+	}
+	if !r.Metadata.Incomplete || !strings.Contains(r.Metadata.IncompleteReason, "budget") {
+		t.Errorf("expected the report to be marked incomplete by the budget, got %+v", r.Metadata)
+	}
+}
+
+func TestRunCollectors_Interrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cs := []collector.Collector{
+		collector.Spec{ID: "blocked", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+			<-ctx.Done()
+			return nil
+		}},
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	r := &types.Report{}
+	runs, errs := runCollectors(ctx, cs, &collector.Env{}, r, 0, quiet)
+	if len(runs) != 1 || runs[0].Status != types.CollectorCancelled {
+		t.Fatalf("expected blocked collector to be cancelled, got %+v", runs)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error, "interrupted") {
+		t.Errorf("expected an interrupted collector error, got %+v", errs)
+	}
+	if !r.Metadata.Incomplete {
+		t.Error("expected the report to be marked incomplete")
+	}
+}
+
+func TestRunCollectors_MergesAppendedGPU(t *testing.T) {
+	// Either collector may finish first
+	for _, slow := range []string{"linux.procfs", "thermal"} {
+		wait := func(id string) {
+			if id == slow {
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+		cs := []collector.Collector{
+			collector.Spec{ID: "gpu", Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+				r.GPUs = []types.GPUInfo{{Index: 0, PCIBusID: "00000000:01:00.0"}}
+				return nil
+			}},
+			// Both start from the same snapshot of r.GPUs
+			collector.Spec{ID: "linux.procfs", Deps: []string{"gpu"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+				wait("linux.procfs")
+				r.GPUs[0].IRQ = 139
+				r.GPUs = append(r.GPUs, types.GPUInfo{Index: 1, PCIBusID: "0000:41:00.0"})
+				return nil
+			}},
+			collector.Spec{ID: "thermal", Deps: []string{"gpu"}, Fn: func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError {
+				wait("thermal")
+				r.GPUs[0].Thermal = &types.ThermalInfo{GPUIndex: 0, TemperatureC: 61}
+				return nil
+			}},
+		}
+
+		r := &types.Report{}
+		runCollectors(context.Background(), cs, &collector.Env{}, r, 0, quiet)
+
+		if len(r.GPUs) != 2 || r.GPUs[1].PCIBusID != "0000:41:00.0" {
+			t.Fatalf("%s last: expected the appended GPU to be kept, got %+v", slow, r.GPUs)
+		}
+		if g := r.GPUs[0]; g.IRQ != 139 || g.Thermal == nil || g.Thermal.TemperatureC != 61 {
+			t.Errorf("%s last: expected both annotations on GPU 0, got %+v", slow, g)
+		}
+	}
+}
+
+func TestRunCollectors_UnmetDependencies(t *testing.T) {
+	noop := func(ctx context.Context, env *collector.Env, r *types.Report) []types.CollectorError { return nil }
+	cs := []collector.Collector{
+		collector.Spec{ID: "a", Deps: []string{"b"}, Fn: noop},
+		collector.Spec{ID: "b", Deps: []string{"a"}, Fn: noop},
+	}
+
+	runs, errs := runCollectors(context.Background(), cs, &collector.Env{}, &types.Report{}, 0, quiet)
+	if len(errs) != 2 || !strings.Contains(errs[0].Error, "waits on b") {
+		t.Errorf("expected a collector error for each collector that could not start, got %+v", errs)
+	}
+	for _, run := range runs {
+		if run.Status != types.CollectorNotStarted || run.Note == "" {
+			t.Errorf("%s: expected not started with a note, got %+v", run.Name, run)
+		}
+	}
+}
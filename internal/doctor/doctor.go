@@ -3,6 +3,7 @@ package doctor
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -102,7 +103,7 @@ func RunInteractive() {
 		IncludeLogs: includeLogs,
 	}
 
-	report, err := core.Run(cfg, false, func(msg string) {
+	report, err := core.Run(context.Background(), cfg, false, func(msg string) {
 		fmt.Println(msg)
 	})
 	if err != nil {
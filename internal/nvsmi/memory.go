@@ -0,0 +1,164 @@
+package nvsmi
+
+import "strings"
+
+// MemoryHealth is a GPU's ECC, page retirement and row remapping state. Each
+// mechanism is only present on some GPUs: ECC on data-center and workstation
+// cards, page retirement up to Volta/Turing, row remapping from Ampere on.
+type MemoryHealth struct {
+	ECCSupported      bool
+	ECCEnabled        bool
+	ECCPendingEnabled bool // the ECC mode that applies after the next reset
+	ECCVolatile       ECCCounts
+	ECCAggregate      ECCCounts
+	// SRAMThresholdExceeded is set by R535+ drivers once uncorrectable SRAM
+	// errors pass the point where NVIDIA recommends replacing the GPU.
+	SRAMThresholdExceeded bool
+
+	RetiredPagesSupported bool
+	RetiredSingleBit      int // pages retired for multiple single-bit errors
+	RetiredDoubleBit      int // pages retired for a double-bit error
+	RetirementPending     bool
+
+	RowRemapSupported bool
+	RowRemap          RowRemap
+}
+
+// ECCCounts are error counts since the last driver reload (volatile) or over
+// the GPU's lifetime (aggregate).
+type ECCCounts struct {
+	Correctable   int64
+	Uncorrectable int64
+}
+
+// RowRemap is the row remapper state of an Ampere or later GPU.
+type RowRemap struct {
+	Correctable   int  // rows remapped for correctable errors
+	Uncorrectable int  // rows remapped for uncorrectable errors
+	Pending       bool // a remap is waiting for a GPU reset
+	Failed        bool // a remap failed; the GPU is out of spare rows
+
+	// Histogram of memory banks by spare rows left
+	HistogramMax     int
+	HistogramHigh    int
+	HistogramPartial int
+	HistogramLow     int
+	HistogramNone    int
+}
+
+// ── XML layout ──────────────────────────────────────────────────
+
+type xmlECCMode struct {
+	Current string `xml:"current_ecc"`
+	Pending string `xml:"pending_ecc"`
+}
+
+type xmlECCErrors struct {
+	Volatile  xmlECCCounts `xml:"volatile"`
+	Aggregate xmlECCCounts `xml:"aggregate"`
+}
+
+// xmlECCCounts covers the three layouts drivers have used: single_bit and
+// double_bit totals (before R510), sram/dram counts (R510+), and split SRAM
+// uncorrectable counts with a threshold flag (R535+).
+type xmlECCCounts struct {
+	SingleBitTotal          string `xml:"single_bit>total"`
+	DoubleBitTotal          string `xml:"double_bit>total"`
+	SRAMCorrectable         string `xml:"sram_correctable"`
+	SRAMUncorrectable       string `xml:"sram_uncorrectable"`
+	SRAMUncorrectableParity string `xml:"sram_uncorrectable_parity"`
+	SRAMUncorrectableSECDED string `xml:"sram_uncorrectable_secded"`
+	DRAMCorrectable         string `xml:"dram_correctable"`
+	DRAMUncorrectable       string `xml:"dram_uncorrectable"`
+	SRAMThresholdExceeded   string `xml:"sram_threshold_exceeded"`
+}
+
+type xmlRetiredPages struct {
+	SingleBit        string `xml:"multiple_single_bit_retirement>retired_count"`
+	DoubleBit        string `xml:"double_bit_retirement>retired_count"`
+	Pending          string `xml:"pending_retirement"`
+	PendingBlacklist string `xml:"pending_blacklist"` // drivers before R450
+}
+
+type xmlRemappedRows struct {
+	Correctable   string `xml:"remapped_row_corr"`
+	Uncorrectable string `xml:"remapped_row_unc"`
+	Pending       string `xml:"remapped_row_pending"`
+	Failure       string `xml:"remapped_row_failure"`
+	Histogram     struct {
+		Max     string `xml:"row_remapper_histogram_max"`
+		High    string `xml:"row_remapper_histogram_high"`
+		Partial string `xml:"row_remapper_histogram_partial"`
+		Low     string `xml:"row_remapper_histogram_low"`
+		None    string `xml:"row_remapper_histogram_none"`
+	} `xml:"row_remapper_histogram"`
+}
+
+func (g xmlGPU) memoryHealth() MemoryHealth {
+	var m MemoryHealth
+
+	if enabled, ok := yesNo(g.ECCMode.Current); ok {
+		m.ECCSupported = true
+		m.ECCEnabled = enabled
+		m.ECCPendingEnabled, _ = yesNo(g.ECCMode.Pending)
+		m.ECCVolatile = g.ECCErrors.Volatile.counts()
+		m.ECCAggregate = g.ECCErrors.Aggregate.counts()
+		m.SRAMThresholdExceeded, _ = yesNo(g.ECCErrors.Aggregate.SRAMThresholdExceeded)
+	}
+
+	r := g.RetiredPages
+	pending, ok := yesNo(r.Pending)
+	if !ok {
+		pending, ok = yesNo(r.PendingBlacklist)
+	}
+	if _, counted := parseNumber(r.SingleBit); ok || counted {
+		m.RetiredPagesSupported = true
+		m.RetiredSingleBit = int(number(r.SingleBit))
+		m.RetiredDoubleBit = int(number(r.DoubleBit))
+		m.RetirementPending = pending
+	}
+
+	rr := g.RemappedRows
+	if pending, ok := yesNo(rr.Pending); ok {
+		m.RowRemapSupported = true
+		m.RowRemap = RowRemap{
+			Correctable:      int(number(rr.Correctable)),
+			Uncorrectable:    int(number(rr.Uncorrectable)),
+			Pending:          pending,
+			HistogramMax:     int(number(rr.Histogram.Max)),
+			HistogramHigh:    int(number(rr.Histogram.High)),
+			HistogramPartial: int(number(rr.Histogram.Partial)),
+			HistogramLow:     int(number(rr.Histogram.Low)),
+			HistogramNone:    int(number(rr.Histogram.None)),
+		}
+		m.RowRemap.Failed, _ = yesNo(rr.Failure)
+	}
+
+	return m
+}
+
+func (c xmlECCCounts) counts() ECCCounts {
+	if _, ok := parseNumber(c.SingleBitTotal); ok {
+		return ECCCounts{
+			Correctable:   int64(number(c.SingleBitTotal)),
+			Uncorrectable: int64(number(c.DoubleBitTotal)),
+		}
+	}
+	return ECCCounts{
+		Correctable: int64(number(c.SRAMCorrectable) + number(c.DRAMCorrectable)),
+		Uncorrectable: int64(number(c.SRAMUncorrectable) + number(c.SRAMUncorrectableParity) +
+			number(c.SRAMUncorrectableSECDED) + number(c.DRAMUncorrectable)),
+	}
+}
+
+// yesNo reads the Yes/No and Enabled/Disabled values nvidia-smi uses for
+// flags. ok is false for N/A and anything else.
+func yesNo(s string) (value, ok bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "enabled":
+		return true, true
+	case "no", "disabled":
+		return false, true
+	}
+	return false, false
+}
@@ -0,0 +1,110 @@
+package nvsmi
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MIGMode is a GPU's Multi-Instance GPU mode. A change to it only takes
+// effect after a GPU reset, until then PendingEnabled differs from Enabled.
+type MIGMode struct {
+	Supported      bool
+	Enabled        bool
+	PendingEnabled bool
+}
+
+// MIGDevice is one MIG compute instance, which CUDA sees as a device of its
+// own. nvidia-smi -q does not print profile names or MIG UUIDs; those come
+// from nvidia-smi -L, see ParseMIGListing.
+type MIGDevice struct {
+	Index             int
+	GPUInstanceID     int
+	ComputeInstanceID int
+	SMCount           int
+	MemoryTotalMiB    int64
+	MemoryUsedMiB     int64
+}
+
+type xmlMIGMode struct {
+	Current string `xml:"current_mig"`
+	Pending string `xml:"pending_mig"`
+}
+
+type xmlMIGDevice struct {
+	Index             string `xml:"index"`
+	GPUInstanceID     string `xml:"gpu_instance_id"`
+	ComputeInstanceID string `xml:"compute_instance_id"`
+	SMCount           string `xml:"device_attributes>shared>multiprocessor_count"`
+	FBMemory          struct {
+		Total string `xml:"total"`
+		Used  string `xml:"used"`
+	} `xml:"fb_memory_usage"`
+}
+
+func (g xmlGPU) mig() (MIGMode, []MIGDevice) {
+	var mode MIGMode
+	enabled, ok := yesNo(g.MIGMode.Current)
+	if !ok {
+		return mode, nil
+	}
+	mode.Supported = true
+	mode.Enabled = enabled
+	mode.PendingEnabled, _ = yesNo(g.MIGMode.Pending)
+
+	var devices []MIGDevice
+	for _, d := range g.MIGDevices {
+		devices = append(devices, MIGDevice{
+			Index:             int(number(d.Index)),
+			GPUInstanceID:     int(number(d.GPUInstanceID)),
+			ComputeInstanceID: int(number(d.ComputeInstanceID)),
+			SMCount:           int(number(d.SMCount)),
+			MemoryTotalMiB:    int64(number(d.FBMemory.Total)),
+			MemoryUsedMiB:     int64(number(d.FBMemory.Used)),
+		})
+	}
+	return mode, devices
+}
+
+var (
+	listingGPU = regexp.MustCompile(`^GPU (\d+):`)
+	listingMIG = regexp.MustCompile(`^MIG\s+(\S+)\s+Device\s+(\d+):\s*\(UUID:\s*([^)]+)\)`)
+)
+
+// MIGListing is a MIG device line of nvidia-smi -L.
+type MIGListing struct {
+	Device  int    // MIGDevice.Index
+	Profile string // "3g.40gb"
+	UUID    string // "MIG-<uuid>", or "MIG-GPU-<uuid>/<gi>/<ci>" before R470
+}
+
+// ParseMIGListing parses the MIG device lines of nvidia-smi -L, keyed by GPU
+// index.
+func ParseMIGListing(data []byte) map[int][]MIGListing {
+	listing := map[int][]MIGListing{}
+	gpu := -1
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := listingGPU.FindStringSubmatch(line); m != nil {
+			gpu, _ = strconv.Atoi(m[1])
+			continue
+		}
+		m := listingMIG.FindStringSubmatch(line)
+		if m == nil || gpu < 0 {
+			continue
+		}
+		device, _ := strconv.Atoi(m[2])
+		listing[gpu] = append(listing[gpu], MIGListing{Device: device, Profile: m[1], UUID: strings.TrimSpace(m[3])})
+	}
+	return listing
+}
+
+// MIGEnabled reports whether any GPU in the log has MIG mode enabled.
+func (l *Log) MIGEnabled() bool {
+	for _, g := range l.GPUs {
+		if g.MIG.Enabled {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+// Package nvsmi runs `nvidia-smi -q -x` and parses its XML into a typed model.
+// The GPU, thermal and PCIe collectors all read the same query result through
+// a run-wide Cache, so a slow or hanging nvidia-smi is only paid for once.
+// It also parses the `nvidia-smi topo -m` matrix and NVLink status.
+package nvsmi
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+)
+
+// Clock event reasons as reported by NVML (nvmlClocksEventReason*). Drivers
+// before R535 call them clock throttle reasons; the bits are the same.
+const (
+	ReasonGPUIdle              uint64 = 0x1
+	ReasonApplicationsClocks   uint64 = 0x2
+	ReasonSWPowerCap           uint64 = 0x4
+	ReasonHWSlowdown           uint64 = 0x8
+	ReasonSyncBoost            uint64 = 0x10
+	ReasonSWThermalSlowdown    uint64 = 0x20
+	ReasonHWThermalSlowdown    uint64 = 0x40
+	ReasonHWPowerBrakeSlowdown uint64 = 0x80
+	ReasonDisplayClockSetting  uint64 = 0x100
+)
+
+// reasons lists every clock event reason in bit order. name is the XML
+// element name minus its clocks_event_reason_ or clocks_throttle_reason_
+// prefix, and is also the identifier used in reports.
+var reasons = []struct {
+	bit  uint64
+	name string
+}{
+	{ReasonGPUIdle, "gpu_idle"},
+	{ReasonApplicationsClocks, "applications_clocks_setting"},
+	{ReasonSWPowerCap, "sw_power_cap"},
+	{ReasonHWSlowdown, "hw_slowdown"},
+	{ReasonSyncBoost, "sync_boost"},
+	{ReasonSWThermalSlowdown, "sw_thermal_slowdown"},
+	{ReasonHWThermalSlowdown, "hw_thermal_slowdown"},
+	{ReasonHWPowerBrakeSlowdown, "hw_power_brake_slowdown"},
+	{ReasonDisplayClockSetting, "display_clocks_setting"},
+}
+
+// ReasonNames decodes a clock event reason bitmask into reason names, in bit
+// order. Undocumented bits are ignored.
+func ReasonNames(mask uint64) []string {
+	var names []string
+	for _, r := range reasons {
+		if mask&r.bit != 0 {
+			names = append(names, r.name)
+		}
+	}
+	return names
+}
+
+func reasonBit(name string) (uint64, bool) {
+	for _, r := range reasons {
+		if r.name == name {
+			return r.bit, true
+		}
+	}
+	return 0, false
+}
+
+// Log is the parsed output of one nvidia-smi -q -x run. It is shared between
+// collectors and must be treated as read-only.
+type Log struct {
+	DriverVersion string
+	CUDAVersion   string // highest CUDA version the driver supports
+	GPUs          []GPU
+}
+
+// GPU is one <gpu> element. Numeric values the driver reports as N/A or
+// [Not Supported] are left at zero unless noted otherwise.
+type GPU struct {
+	Index        int    // position in the output, the same numbering as nvidia-smi -L
+	BusID        string // "00000000:01:00.0"
+	Name         string
+	UUID         string
+	VBIOSVersion string
+	Architecture string
+	PCIVendorID  string // "10de"
+	PCIDeviceID  string // "2684"
+
+	MemoryTotalMiB int64
+	MemoryUsedMiB  int64
+	MemoryFreeMiB  int64
+	BAR1TotalMiB   int64 // the GPU memory window the CPU can map
+	BAR1UsedMiB    int64
+
+	PerformanceState    string // "P0" to "P12"
+	TemperatureC        int
+	SlowdownTempC       int // hardware slowdown threshold
+	ShutdownTempC       int // hardware shutdown threshold
+	MaxOperatingTempC   int // above this, the driver lowers clocks
+	TLimitMarginC       int // degrees below the T.Limit; only valid if TLimitKnown
+	TLimitKnown         bool
+	PowerDrawW          float64
+	PowerLimitW         float64
+	DefaultPowerLimitW  float64
+	FanSpeedPct         int // -1 when the GPU has no fan reading (laptops, passive cards)
+	GraphicsClockMHz    int
+	MaxGraphicsClockMHz int
+
+	PCIeGenCurrent   int
+	PCIeGenMax       int
+	PCIeWidthCurrent int
+	PCIeWidthMax     int
+
+	// ClockEventReasons is the bitmask of active Reason* values.
+	// ClockEventReasonsKnown is false when the driver did not report them.
+	ClockEventReasons      uint64
+	ClockEventReasonsKnown bool
+
+	Memory MemoryHealth
+
+	MIG        MIGMode
+	MIGDevices []MIGDevice // one per compute instance when MIG is enabled
+
+	Processes []Process
+}
+
+// Query runs nvidia-smi -q -x and parses the result.
+func Query(ctx context.Context, timeout int) (*Log, error) {
+	r := util.RunCommandContext(ctx, timeout, "nvidia-smi", "-q", "-x")
+	if r.Err != nil {
+		return nil, fmt.Errorf("nvidia-smi -q -x failed: %w", r.Err)
+	}
+	return Parse([]byte(r.Stdout))
+}
+
+// Cache holds the result of a single Query for the length of a run. The zero
+// value is ready to use. A nil *Cache runs a fresh query on every call.
+type Cache struct {
+	mu   sync.Mutex
+	done bool
+	log  *Log
+	err  error
+}
+
+// Get returns the cached query result, running the query on first use.
+// Concurrent callers wait for that first query rather than starting their own.
+func (c *Cache) Get(ctx context.Context, timeout int) (*Log, error) {
+	if c == nil {
+		return Query(ctx, timeout)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.done {
+		c.log, c.err = Query(ctx, timeout)
+		c.done = true
+	}
+	return c.log, c.err
+}
+
+// Parse decodes nvidia-smi -q -x output.
+func Parse(data []byte) (*Log, error) {
+	var raw xmlLog
+	if err := xml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cannot parse nvidia-smi XML: %w", err)
+	}
+
+	log := &Log{
+		DriverVersion: strings.TrimSpace(raw.DriverVersion),
+		CUDAVersion:   strings.TrimSpace(raw.CUDAVersion),
+	}
+	for i, g := range raw.GPUs {
+		log.GPUs = append(log.GPUs, g.toGPU(i))
+	}
+	return log, nil
+}
+
+// ── XML layout ──────────────────────────────────────────────────
+
+type xmlLog struct {
+	XMLName       xml.Name `xml:"nvidia_smi_log"`
+	DriverVersion string   `xml:"driver_version"`
+	CUDAVersion   string   `xml:"cuda_version"`
+	GPUs          []xmlGPU `xml:"gpu"`
+}
+
+type xmlGPU struct {
+	ID           string `xml:"id,attr"`
+	ProductName  string `xml:"product_name"`
+	Architecture string `xml:"product_architecture"`
+	UUID         string `xml:"uuid"`
+	VBIOSVersion string `xml:"vbios_version"`
+	PCI          struct {
+		BusID    string `xml:"pci_bus_id"`
+		DeviceID string `xml:"pci_device_id"` // "0x268410DE": device then vendor
+		Link     struct {
+			Max     string `xml:"pcie_gen>max_link_gen"`
+			Current string `xml:"pcie_gen>current_link_gen"`
+			MaxW    string `xml:"link_widths>max_link_width"`
+			CurW    string `xml:"link_widths>current_link_width"`
+		} `xml:"pci_gpu_link_info"`
+	} `xml:"pci"`
+	FanSpeed         string     `xml:"fan_speed"`
+	PerformanceState string     `xml:"performance_state"`
+	EventReasons     *xmlValues `xml:"clocks_event_reasons"`
+	ThrottleReasons  *xmlValues `xml:"clocks_throttle_reasons"` // drivers before R535
+	FBMemory         struct {
+		Total string `xml:"total"`
+		Used  string `xml:"used"`
+		Free  string `xml:"free"`
+	} `xml:"fb_memory_usage"`
+	BAR1Memory struct {
+		Total string `xml:"total"`
+		Used  string `xml:"used"`
+	} `xml:"bar1_memory_usage"`
+	Temperature struct {
+		GPU          string `xml:"gpu_temp"`
+		TLimit       string `xml:"gpu_temp_tlimit"` // R535+
+		Shutdown     string `xml:"gpu_temp_max_threshold"`
+		Slowdown     string `xml:"gpu_temp_slow_threshold"`
+		MaxOperating string `xml:"gpu_temp_max_gpu_threshold"`
+	} `xml:"temperature"`
+	Power       xmlPower `xml:"gpu_power_readings"`
+	LegacyPower xmlPower `xml:"power_readings"` // drivers before R535
+	Graphics    string   `xml:"clocks>graphics_clock"`
+	MaxGraphics string   `xml:"max_clocks>graphics_clock"`
+
+	ECCMode      xmlECCMode      `xml:"ecc_mode"`
+	ECCErrors    xmlECCErrors    `xml:"ecc_errors"`
+	RetiredPages xmlRetiredPages `xml:"retired_pages"`
+	RemappedRows xmlRemappedRows `xml:"remapped_rows"`
+
+	MIGMode    xmlMIGMode     `xml:"mig_mode"`
+	MIGDevices []xmlMIGDevice `xml:"mig_devices>mig_device"`
+	Processes  []xmlProcess   `xml:"processes>process_info"`
+}
+
+type xmlPower struct {
+	PowerState    string `xml:"power_state"`
+	PowerDraw     string `xml:"power_draw"`
+	InstantDraw   string `xml:"instant_power_draw"`
+	AverageDraw   string `xml:"average_power_draw"`
+	PowerLimit    string `xml:"power_limit"`
+	CurrentLimit  string `xml:"current_power_limit"`
+	EnforcedLimit string `xml:"enforced_power_limit"`
+	DefaultLimit  string `xml:"default_power_limit"`
+}
+
+// xmlValues captures every child element of a section by name.
+type xmlValues struct {
+	Items []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+func (g xmlGPU) toGPU(index int) GPU {
+	gpu := GPU{
+		Index:        index,
+		BusID:        strings.TrimSpace(g.PCI.BusID),
+		Name:         strings.TrimSpace(g.ProductName),
+		UUID:         strings.TrimSpace(g.UUID),
+		VBIOSVersion: strings.TrimSpace(g.VBIOSVersion),
+		Architecture: strings.TrimSpace(g.Architecture),
+		FanSpeedPct:  -1,
+
+		MemoryTotalMiB: int64(number(g.FBMemory.Total)),
+		MemoryUsedMiB:  int64(number(g.FBMemory.Used)),
+		MemoryFreeMiB:  int64(number(g.FBMemory.Free)),
+		BAR1TotalMiB:   int64(number(g.BAR1Memory.Total)),
+		BAR1UsedMiB:    int64(number(g.BAR1Memory.Used)),
+
+		PerformanceState:    strings.TrimSpace(g.PerformanceState),
+		TemperatureC:        int(number(g.Temperature.GPU)),
+		SlowdownTempC:       int(number(g.Temperature.Slowdown)),
+		ShutdownTempC:       int(number(g.Temperature.Shutdown)),
+		MaxOperatingTempC:   int(number(g.Temperature.MaxOperating)),
+		GraphicsClockMHz:    int(number(g.Graphics)),
+		MaxGraphicsClockMHz: int(number(g.MaxGraphics)),
+
+		PCIeGenCurrent:   int(number(g.PCI.Link.Current)),
+		PCIeGenMax:       int(number(g.PCI.Link.Max)),
+		PCIeWidthCurrent: int(number(g.PCI.Link.CurW)),
+		PCIeWidthMax:     int(number(g.PCI.Link.MaxW)),
+	}
+	if gpu.BusID == "" {
+		gpu.BusID = strings.TrimSpace(g.ID)
+	}
+
+	// pci_device_id packs the device ID above the vendor ID
+	if id := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(g.PCI.DeviceID)), "0x"); len(id) == 8 {
+		gpu.PCIDeviceID = id[:4]
+		gpu.PCIVendorID = id[4:]
+	}
+
+	if v, ok := parseNumber(g.Temperature.TLimit); ok {
+		gpu.TLimitMarginC = int(v)
+		gpu.TLimitKnown = true
+	}
+	if v, ok := parseNumber(g.FanSpeed); ok {
+		gpu.FanSpeedPct = int(v)
+	}
+
+	power := g.Power
+	if power == (xmlPower{}) {
+		power = g.LegacyPower
+	}
+	if gpu.PerformanceState == "" {
+		gpu.PerformanceState = strings.TrimSpace(power.PowerState)
+	}
+	gpu.PowerDrawW = firstNumber(power.InstantDraw, power.PowerDraw, power.AverageDraw)
+	gpu.PowerLimitW = firstNumber(power.CurrentLimit, power.PowerLimit, power.EnforcedLimit)
+	if v, ok := parseNumber(power.DefaultLimit); ok {
+		gpu.DefaultPowerLimitW = v
+	}
+
+	reasons := g.EventReasons
+	if reasons == nil {
+		reasons = g.ThrottleReasons
+	}
+	if reasons != nil {
+		for _, item := range reasons.Items {
+			name := item.XMLName.Local
+			name = strings.TrimPrefix(name, "clocks_event_reason_")
+			name = strings.TrimPrefix(name, "clocks_throttle_reason_")
+			bit, ok := reasonBit(name)
+			if !ok {
+				continue
+			}
+			gpu.ClockEventReasonsKnown = true
+			if strings.EqualFold(strings.TrimSpace(item.Value), "Active") {
+				gpu.ClockEventReasons |= bit
+			}
+		}
+	}
+
+	gpu.Memory = g.memoryHealth()
+	gpu.MIG, gpu.MIGDevices = g.mig()
+	for _, p := range g.Processes {
+		gpu.Processes = append(gpu.Processes, p.toProcess())
+	}
+
+	return gpu
+}
+
+// parseNumber reads the leading number of a value such as "24564 MiB",
+// "45 C", "16x" or "30 %". N/A, [N/A], [Not Supported] and similar fail.
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimSuffix(s, "x")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+func number(s string) float64 {
+	v, _ := parseNumber(s)
+	return v
+}
+
+// firstNumber returns the first of values that holds a number.
+func firstNumber(values ...string) float64 {
+	for _, s := range values {
+		if v, ok := parseNumber(s); ok {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,361 @@
+package nvsmi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func parseFixture(t *testing.T, name string) *Log {
+	t.Helper()
+	log, err := Parse(readFixture(t, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func TestParse_R550(t *testing.T) {
+	log := parseFixture(t, "r550-dual.xml")
+
+	if log.DriverVersion != "550.107.02" || log.CUDAVersion != "12.4" {
+		t.Errorf("driver = %q, cuda = %q", log.DriverVersion, log.CUDAVersion)
+	}
+	if len(log.GPUs) != 2 {
+		t.Fatalf("got %d GPUs, want 2", len(log.GPUs))
+	}
+
+	g := log.GPUs[0]
+	if g.Index != 0 || g.Name != "NVIDIA GeForce RTX 4090" || g.BusID != "00000000:01:00.0" {
+		t.Errorf("identity = %d %q %q", g.Index, g.Name, g.BusID)
+	}
+	if g.UUID != "GPU-5c7f3a1e-8b2d-4f60-9a1c-2e4b7d9f0a31" || g.VBIOSVersion != "95.02.3C.00.8E" {
+		t.Errorf("uuid = %q, vbios = %q", g.UUID, g.VBIOSVersion)
+	}
+	if g.PCIVendorID != "10de" || g.PCIDeviceID != "2684" {
+		t.Errorf("PCI IDs = %s:%s, want 10de:2684", g.PCIVendorID, g.PCIDeviceID)
+	}
+	if g.MemoryTotalMiB != 24564 || g.MemoryUsedMiB != 18211 || g.MemoryFreeMiB != 6007 {
+		t.Errorf("memory = %d/%d/%d", g.MemoryTotalMiB, g.MemoryUsedMiB, g.MemoryFreeMiB)
+	}
+	if g.TemperatureC != 71 || g.FanSpeedPct != 42 || g.PerformanceState != "P2" {
+		t.Errorf("temp = %d, fan = %d, pstate = %q", g.TemperatureC, g.FanSpeedPct, g.PerformanceState)
+	}
+	if g.SlowdownTempC != 89 || g.ShutdownTempC != 92 || g.MaxOperatingTempC != 87 || !g.TLimitKnown || g.TLimitMarginC != 12 {
+		t.Errorf("limits = slowdown %d, shutdown %d, max operating %d, T.Limit %d (known %v)",
+			g.SlowdownTempC, g.ShutdownTempC, g.MaxOperatingTempC, g.TLimitMarginC, g.TLimitKnown)
+	}
+	if g.PowerDrawW != 410.55 || g.PowerLimitW != 450 || g.DefaultPowerLimitW != 450 {
+		t.Errorf("power = %.2f / %.2f W (default %.2f W)", g.PowerDrawW, g.PowerLimitW, g.DefaultPowerLimitW)
+	}
+	if g.GraphicsClockMHz != 2610 || g.MaxGraphicsClockMHz != 3120 {
+		t.Errorf("clocks = %d / %d MHz", g.GraphicsClockMHz, g.MaxGraphicsClockMHz)
+	}
+	if !g.ClockEventReasonsKnown || g.ClockEventReasons != ReasonSWPowerCap {
+		t.Errorf("reasons = %#x (known %v), want sw_power_cap", g.ClockEventReasons, g.ClockEventReasonsKnown)
+	}
+
+	g = log.GPUs[1]
+	if g.Index != 1 || g.PCIeGenCurrent != 1 || g.PCIeGenMax != 4 || g.PCIeWidthCurrent != 8 || g.PCIeWidthMax != 16 {
+		t.Errorf("second GPU link = Gen%d/Gen%d x%d/x%d", g.PCIeGenCurrent, g.PCIeGenMax, g.PCIeWidthCurrent, g.PCIeWidthMax)
+	}
+	if g.ClockEventReasons != ReasonGPUIdle {
+		t.Errorf("second GPU reasons = %#x, want gpu_idle", g.ClockEventReasons)
+	}
+	if log.GPUs[0].BAR1TotalMiB != 32768 || log.GPUs[0].BAR1UsedMiB != 18 || g.BAR1TotalMiB != 256 {
+		t.Errorf("BAR1 = %d/%d and %d MiB", log.GPUs[0].BAR1UsedMiB, log.GPUs[0].BAR1TotalMiB, g.BAR1TotalMiB)
+	}
+}
+
+func TestParse_LegacyDriver(t *testing.T) {
+	log := parseFixture(t, "r470-laptop.xml")
+	if len(log.GPUs) != 1 {
+		t.Fatalf("got %d GPUs, want 1", len(log.GPUs))
+	}
+	g := log.GPUs[0]
+
+	// fan_speed N/A on laptops
+	if g.FanSpeedPct != -1 {
+		t.Errorf("FanSpeedPct = %d, want -1", g.FanSpeedPct)
+	}
+	// power_readings instead of gpu_power_readings; limit is N/A
+	if g.PowerDrawW != 78.42 || g.PowerLimitW != 0 {
+		t.Errorf("power = %.2f / %.2f W", g.PowerDrawW, g.PowerLimitW)
+	}
+	// clocks_throttle_reasons instead of clocks_event_reasons
+	want := ReasonHWSlowdown | ReasonHWThermalSlowdown
+	if !g.ClockEventReasonsKnown || g.ClockEventReasons != want {
+		t.Errorf("reasons = %#x, want %#x", g.ClockEventReasons, want)
+	}
+	// No T.Limit or max operating temperature before R535
+	if g.TLimitKnown || g.MaxOperatingTempC != 0 || g.SlowdownTempC != 102 {
+		t.Errorf("limits = T.Limit known %v, max operating %d, slowdown %d", g.TLimitKnown, g.MaxOperatingTempC, g.SlowdownTempC)
+	}
+	if g.Architecture != "" {
+		t.Errorf("Architecture = %q, want empty (not reported by R470)", g.Architecture)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse([]byte("NVIDIA-SMI has failed because it couldn't communicate with the NVIDIA driver.")); err == nil {
+		t.Error("expected an error for non-XML output")
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"24564 MiB", 24564, true},
+		{"45 C", 45, true},
+		{"16x", 16, true},
+		{"30 %", 30, true},
+		{"410.55 W", 410.55, true},
+		{"N/A", 0, false},
+		{"[N/A]", 0, false},
+		{"[Not Supported]", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseNumber(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCache_QueriesOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := util.StartRecording(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	var c Cache
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Get(context.Background(), 5)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := util.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := util.LoadRecording(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Commands) != 1 {
+		t.Errorf("nvidia-smi ran %d times, want 1", len(rec.Commands))
+	}
+}
+
+func TestReasonNames(t *testing.T) {
+	got := ReasonNames(ReasonSWPowerCap | ReasonHWPowerBrakeSlowdown | 0x8000)
+	if len(got) != 2 || got[0] != "sw_power_cap" || got[1] != "hw_power_brake_slowdown" {
+		t.Errorf("ReasonNames = %v", got)
+	}
+	if ReasonNames(0) != nil {
+		t.Error("expected no names for an empty mask")
+	}
+}
+
+func TestParse_RowRemap(t *testing.T) {
+	log := parseFixture(t, "r535-a100-remap.xml")
+	if len(log.GPUs) != 2 {
+		t.Fatalf("got %d GPUs, want 2", len(log.GPUs))
+	}
+
+	m := log.GPUs[0].Memory
+	if !m.ECCSupported || !m.ECCEnabled || !m.ECCPendingEnabled {
+		t.Errorf("ECC supported %v, enabled %v, pending %v", m.ECCSupported, m.ECCEnabled, m.ECCPendingEnabled)
+	}
+	if m.ECCVolatile != (ECCCounts{Correctable: 12, Uncorrectable: 1}) || m.ECCAggregate != (ECCCounts{Correctable: 340, Uncorrectable: 3}) {
+		t.Errorf("ECC volatile %+v, aggregate %+v", m.ECCVolatile, m.ECCAggregate)
+	}
+	// Page retirement is N/A on Ampere
+	if m.RetiredPagesSupported {
+		t.Error("RetiredPagesSupported = true, want false")
+	}
+	if !m.RowRemapSupported || !m.RowRemap.Pending || m.RowRemap.Failed || m.RowRemap.Uncorrectable != 3 {
+		t.Errorf("row remap = %+v (supported %v)", m.RowRemap, m.RowRemapSupported)
+	}
+	if m.RowRemap.HistogramMax != 637 || m.RowRemap.HistogramHigh != 3 || m.RowRemap.HistogramNone != 0 {
+		t.Errorf("histogram = %+v", m.RowRemap)
+	}
+
+	m = log.GPUs[1].Memory
+	if !m.SRAMThresholdExceeded || !m.RowRemap.Failed || m.RowRemap.HistogramNone != 638 {
+		t.Errorf("second GPU: SRAM threshold %v, remap %+v", m.SRAMThresholdExceeded, m.RowRemap)
+	}
+	// parity + SECDED + DRAM
+	if m.ECCAggregate.Uncorrectable != 540 || m.ECCVolatile.Uncorrectable != 2 {
+		t.Errorf("second GPU uncorrectable = %d aggregate, %d volatile", m.ECCAggregate.Uncorrectable, m.ECCVolatile.Uncorrectable)
+	}
+}
+
+func TestParse_RetiredPages(t *testing.T) {
+	m := parseFixture(t, "r470-v100-retired.xml").GPUs[0].Memory
+
+	// single_bit/double_bit totals before R510
+	if m.ECCVolatile != (ECCCounts{Correctable: 5}) || m.ECCAggregate != (ECCCounts{Correctable: 1873, Uncorrectable: 4}) {
+		t.Errorf("ECC volatile %+v, aggregate %+v", m.ECCVolatile, m.ECCAggregate)
+	}
+	if !m.ECCEnabled || m.ECCPendingEnabled {
+		t.Errorf("ECC enabled %v, pending %v; want on now, off after reset", m.ECCEnabled, m.ECCPendingEnabled)
+	}
+	if !m.RetiredPagesSupported || m.RetiredSingleBit != 58 || m.RetiredDoubleBit != 4 || !m.RetirementPending {
+		t.Errorf("retired pages = %d single, %d double, pending %v (supported %v)",
+			m.RetiredSingleBit, m.RetiredDoubleBit, m.RetirementPending, m.RetiredPagesSupported)
+	}
+	if m.RowRemapSupported {
+		t.Error("RowRemapSupported = true, want false before Ampere")
+	}
+}
+
+func TestParse_NoMemoryHealthOnGeForce(t *testing.T) {
+	m := parseFixture(t, "r550-dual.xml").GPUs[0].Memory
+	if m.ECCSupported || m.RetiredPagesSupported || m.RowRemapSupported {
+		t.Errorf("memory health = %+v, want nothing supported", m)
+	}
+}
+
+func TestParseTopology_NVLink(t *testing.T) {
+	topo, err := ParseTopology(readFixture(t, "topo-nvlink-quad.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo.GPUs) != 4 {
+		t.Fatalf("got %d GPUs, want 4 (NIC rows skipped)", len(topo.GPUs))
+	}
+	g := topo.GPUs[2]
+	if g.Index != 2 || len(g.Paths) != 4 || g.Paths[0] != "NV12" || g.Paths[2] != "X" {
+		t.Errorf("GPU2 paths = %v", g.Paths)
+	}
+	if g.CPUAffinity != "32-63,96-127" || g.NUMANode != 1 {
+		t.Errorf("GPU2 affinity = %q, NUMA %d", g.CPUAffinity, g.NUMANode)
+	}
+
+	topo.AddNVLinks(ParseNVLinkStatus(readFixture(t, "nvlink-status-quad.txt")))
+	links := topo.GPUs[1].NVLinks
+	if len(links) != 4 || links[1].Up || !links[0].Up || links[0].SpeedGBs != 25 {
+		t.Errorf("GPU1 links = %+v", links)
+	}
+}
+
+func TestParseTopology_PCIeOnly(t *testing.T) {
+	topo, err := ParseTopology(readFixture(t, "topo-pcie-dual.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo.GPUs) != 2 || topo.GPUs[0].Paths[1] != "SYS" || topo.GPUs[1].CPUAffinity != "16-31" {
+		t.Errorf("topology = %+v", topo.GPUs)
+	}
+	if len(ParseNVLinkStatus(nil)) != 0 {
+		t.Error("expected no links from empty nvlink output")
+	}
+}
+
+func TestParseTopology_Invalid(t *testing.T) {
+	if _, err := ParseTopology([]byte("Failed to initialize NVML: Driver/library version mismatch\n")); err == nil {
+		t.Error("expected an error without a GPU matrix")
+	}
+}
+
+func TestParse_MIG(t *testing.T) {
+	log := parseFixture(t, "r535-h100-mig.xml")
+	if len(log.GPUs) != 2 || !log.MIGEnabled() {
+		t.Fatalf("got %d GPUs, MIG enabled %v", len(log.GPUs), log.MIGEnabled())
+	}
+
+	g := log.GPUs[0]
+	if !g.MIG.Supported || !g.MIG.Enabled || !g.MIG.PendingEnabled {
+		t.Errorf("GPU0 MIG mode = %+v", g.MIG)
+	}
+	if len(g.MIGDevices) != 2 {
+		t.Fatalf("GPU0 has %d MIG devices, want 2", len(g.MIGDevices))
+	}
+	d := g.MIGDevices[1]
+	if d.Index != 1 || d.GPUInstanceID != 9 || d.ComputeInstanceID != 0 || d.SMCount != 16 || d.MemoryTotalMiB != 9984 || d.MemoryUsedMiB != 5 {
+		t.Errorf("MIG device 1 = %+v", d)
+	}
+
+	// Enabled after the next reset; "None" yields no devices
+	g = log.GPUs[1]
+	if g.MIG.Enabled || !g.MIG.PendingEnabled || len(g.MIGDevices) != 0 {
+		t.Errorf("GPU1 MIG = %+v, %d devices", g.MIG, len(g.MIGDevices))
+	}
+
+	// GeForce reports N/A
+	if g := parseFixture(t, "r550-dual.xml").GPUs[0]; g.MIG.Supported {
+		t.Errorf("GeForce MIG = %+v, want unsupported", g.MIG)
+	}
+}
+
+func TestParseMIGListing(t *testing.T) {
+	listing := ParseMIGListing(readFixture(t, "list-mig.txt"))
+	if len(listing[0]) != 2 || len(listing[1]) != 0 {
+		t.Fatalf("listing = %+v", listing)
+	}
+	m := listing[0][1]
+	if m.Device != 1 || m.Profile != "1g.10gb" || m.UUID != "MIG-a27f0e63-48bd-5c19-8e75-3f91b6d0c2e4" {
+		t.Errorf("device 1 = %+v", m)
+	}
+
+	// R450 named MIG devices by GPU UUID and instance IDs
+	old := ParseMIGListing([]byte("GPU 0: A100-SXM4-40GB (UUID: GPU-5d5ba0d6-d33d-2b2c-524d-9e3d8d2b8a77)\n  MIG 7g.40gb Device 0: (UUID: MIG-GPU-5d5ba0d6-d33d-2b2c-524d-9e3d8d2b8a77/0/0)\n"))
+	if len(old[0]) != 1 || old[0][0].UUID != "MIG-GPU-5d5ba0d6-d33d-2b2c-524d-9e3d8d2b8a77/0/0" {
+		t.Errorf("R450 listing = %+v", old)
+	}
+}
+
+func TestParse_Processes(t *testing.T) {
+	log := parseFixture(t, "r550-dual.xml")
+	procs := log.GPUs[0].Processes
+	if len(procs) != 2 {
+		t.Fatalf("got %d processes, want 2", len(procs))
+	}
+	if procs[0].Type != "G" || procs[0].UsedMemoryMiB != 312 {
+		t.Errorf("Xorg = %+v", procs[0])
+	}
+	if procs[1].PID != 48311 || procs[1].Type != "C" || procs[1].Name != "/home/alice/venv/bin/python3" || procs[1].UsedMemoryMiB != 17850 {
+		t.Errorf("python = %+v", procs[1])
+	}
+	if len(log.GPUs[1].Processes) != 0 {
+		t.Errorf("second GPU processes = %+v", log.GPUs[1].Processes)
+	}
+}
+
+func TestParseComputeApps(t *testing.T) {
+	apps := ParseComputeApps(readFixture(t, "compute-apps.csv"))
+	if len(apps) != 3 {
+		t.Fatalf("got %d apps, want 3", len(apps))
+	}
+	if apps[0].BusID != "00000000:01:00.0" || apps[0].PID != 48311 || apps[0].UsedMemoryMiB != 17850 {
+		t.Errorf("first app = %+v", apps[0])
+	}
+	if apps[1].Name != "python3 train.py --tag a,b" {
+		t.Errorf("name with a comma = %q", apps[1].Name)
+	}
+	if apps[2].UsedMemoryMiB != -1 {
+		t.Errorf("unreported memory = %d, want -1", apps[2].UsedMemoryMiB)
+	}
+}
@@ -0,0 +1,90 @@
+package nvsmi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+)
+
+// Process is a process with a context on a GPU, from the processes section
+// of nvidia-smi -q -x.
+type Process struct {
+	PID  int
+	Name string
+	// Type is "C" (compute), "G" (graphics) or "C+G"
+	Type string
+	// UsedMemoryMiB is -1 when the driver does not report it, as under
+	// Windows WDDM
+	UsedMemoryMiB int64
+}
+
+// ComputeApp is a line of nvidia-smi --query-compute-apps.
+type ComputeApp struct {
+	BusID         string
+	PID           int
+	Name          string
+	UsedMemoryMiB int64 // -1 when not reported
+}
+
+type xmlProcess struct {
+	PID        string `xml:"pid"`
+	Type       string `xml:"type"`
+	Name       string `xml:"process_name"`
+	UsedMemory string `xml:"used_memory"`
+}
+
+func (p xmlProcess) toProcess() Process {
+	proc := Process{
+		PID:           int(number(p.PID)),
+		Name:          strings.TrimSpace(p.Name),
+		Type:          strings.TrimSpace(p.Type),
+		UsedMemoryMiB: -1,
+	}
+	if v, ok := parseNumber(p.UsedMemory); ok {
+		proc.UsedMemoryMiB = int64(v)
+	}
+	return proc
+}
+
+// QueryComputeApps runs nvidia-smi --query-compute-apps for every compute
+// process on every GPU.
+func QueryComputeApps(ctx context.Context, timeout int) ([]ComputeApp, error) {
+	r := util.RunCommandContext(ctx, timeout, "nvidia-smi",
+		"--query-compute-apps=gpu_bus_id,pid,process_name,used_memory",
+		"--format=csv,noheader,nounits")
+	if r.Err != nil {
+		return nil, fmt.Errorf("nvidia-smi --query-compute-apps failed: %w", r.Err)
+	}
+	return ParseComputeApps([]byte(r.Stdout)), nil
+}
+
+// ParseComputeApps parses --query-compute-apps CSV output. Process names may
+// contain commas, so the name is everything between the PID and the last
+// field.
+func ParseComputeApps(data []byte) []ComputeApp {
+	var apps []ComputeApp
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ", ")
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			continue
+		}
+		app := ComputeApp{
+			BusID:         strings.TrimSpace(fields[0]),
+			PID:           pid,
+			Name:          strings.TrimSpace(strings.Join(fields[2:len(fields)-1], ", ")),
+			UsedMemoryMiB: -1,
+		}
+		if v, ok := parseNumber(fields[len(fields)-1]); ok {
+			app.UsedMemoryMiB = int64(v)
+		}
+		apps = append(apps, app)
+	}
+	return apps
+}
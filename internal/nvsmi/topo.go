@@ -0,0 +1,156 @@
+package nvsmi
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/internal/util"
+)
+
+// Topology is the GPU interconnect matrix from `nvidia-smi topo -m`, with
+// NVLink state from `nvidia-smi nvlink --status` where the GPUs have NVLink.
+type Topology struct {
+	GPUs []TopoGPU
+}
+
+// TopoGPU is one GPU row of the topology matrix.
+type TopoGPU struct {
+	Index int
+	// Paths[j] is the connection to Topology.GPUs[j]: "X" for itself, then
+	// NV# (bonded NVLinks), PIX, PXB, PHB, NODE or SYS from nearest to
+	// farthest.
+	Paths       []string
+	CPUAffinity string // CPU list such as "0-31,64-95"; empty when N/A
+	NUMANode    int    // -1 when N/A
+	NVLinks     []NVLink
+}
+
+// NVLink is one NVLink of a GPU.
+type NVLink struct {
+	Link     int
+	Up       bool
+	SpeedGBs float64
+}
+
+// QueryTopology runs nvidia-smi topo -m and nvidia-smi nvlink --status. A
+// failed nvlink query is not an error; most GPUs have no NVLink.
+func QueryTopology(ctx context.Context, timeout int) (*Topology, error) {
+	r := util.RunCommandContext(ctx, timeout, "nvidia-smi", "topo", "-m")
+	if r.Err != nil {
+		return nil, fmt.Errorf("nvidia-smi topo -m failed: %w", r.Err)
+	}
+	topo, err := ParseTopology([]byte(r.Stdout))
+	if err != nil {
+		return nil, err
+	}
+	r = util.RunCommandContext(ctx, timeout, "nvidia-smi", "nvlink", "--status")
+	if r.Err == nil {
+		topo.AddNVLinks(ParseNVLinkStatus([]byte(r.Stdout)))
+	}
+	return topo, nil
+}
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// ParseTopology parses the matrix printed by nvidia-smi topo -m. NIC rows
+// and columns are skipped.
+func ParseTopology(data []byte) (*Topology, error) {
+	var header []string
+	topo := &Topology{}
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(string(data), ""), "\n") {
+		fields := tabFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if header == nil {
+			if fields[0] == "GPU0" && strings.HasPrefix(line, "\t") {
+				header = fields
+			}
+			continue
+		}
+		if !strings.HasPrefix(fields[0], "GPU") {
+			if fields[0] == "Legend:" {
+				break
+			}
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(fields[0], "GPU"))
+		if err != nil {
+			continue
+		}
+		g := TopoGPU{Index: index, NUMANode: -1}
+		for i, value := range fields[1:] {
+			if i >= len(header) {
+				break
+			}
+			col := header[i]
+			switch {
+			case strings.HasPrefix(col, "GPU") && !strings.Contains(col, "NUMA"):
+				g.Paths = append(g.Paths, value)
+			case col == "CPU Affinity" && value != "N/A":
+				g.CPUAffinity = value
+			case col == "NUMA Affinity":
+				if n, err := strconv.Atoi(value); err == nil {
+					g.NUMANode = n
+				}
+			}
+		}
+		topo.GPUs = append(topo.GPUs, g)
+	}
+	if header == nil || len(topo.GPUs) == 0 {
+		return nil, fmt.Errorf("nvidia-smi topo -m printed no GPU matrix")
+	}
+	return topo, nil
+}
+
+// tabFields splits a topo -m line on tabs, dropping the empty fields the
+// alignment padding leaves behind.
+func tabFields(line string) []string {
+	var fields []string
+	for _, f := range strings.Split(line, "\t") {
+		if f = strings.TrimSpace(f); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+var (
+	nvlinkGPU  = regexp.MustCompile(`^GPU (\d+):`)
+	nvlinkLink = regexp.MustCompile(`^Link (\d+):\s*(.*)$`)
+)
+
+// ParseNVLinkStatus parses nvidia-smi nvlink --status into links per GPU
+// index. Links reported as <inactive> are down.
+func ParseNVLinkStatus(data []byte) map[int][]NVLink {
+	links := map[int][]NVLink{}
+	gpu := -1
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := nvlinkGPU.FindStringSubmatch(line); m != nil {
+			gpu, _ = strconv.Atoi(m[1])
+			continue
+		}
+		m := nvlinkLink.FindStringSubmatch(line)
+		if m == nil || gpu < 0 {
+			continue
+		}
+		link := NVLink{}
+		link.Link, _ = strconv.Atoi(m[1])
+		if strings.Contains(m[2], "GB/s") {
+			link.SpeedGBs, link.Up = parseNumber(m[2])
+		}
+		links[gpu] = append(links[gpu], link)
+	}
+	return links
+}
+
+// AddNVLinks attaches parsed NVLink state to the matching GPUs.
+func (t *Topology) AddNVLinks(links map[int][]NVLink) {
+	for i := range t.GPUs {
+		t.GPUs[i].NVLinks = links[t.GPUs[i].Index]
+	}
+}
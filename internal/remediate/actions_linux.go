@@ -5,8 +5,6 @@ package remediate
 import (
 	"fmt"
 	"os"
-
-	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // nouveauBlacklistPath is the file path for the nouveau blacklist modprobe config.
@@ -62,67 +60,28 @@ func (e *Engine) actionUpdateLdconfig() (output, undoInfo string, err error) {
 		nil
 }
 
-// applyAction dispatches a remediation action by ID to the appropriate
-// Linux-specific implementation.
-func (e *Engine) applyAction(id string) (output string, undoInfo string, err error) {
-	switch id {
-	case "blacklist-nouveau":
-		return e.actionBlacklistNouveau()
-	case "update-ldconfig":
-		return e.actionUpdateLdconfig()
-	default:
-		return "", "", fmt.Errorf("unknown remediation action: %q", id)
-	}
+// actionImpls binds the Linux remediation IDs from knowledge/remediations.json
+// to their implementations.
+var actionImpls = map[string]actionImpl{
+	"blacklist-nouveau": {apply: (*Engine).actionBlacklistNouveau, undo: (*Engine).undoBlacklistNouveau},
+	"update-ldconfig":   {apply: (*Engine).actionUpdateLdconfig, undo: (*Engine).undoUpdateLdconfig},
 }
 
-// undoAction reverses a previously applied Linux remediation action using
-// the stored undo information.
-func (e *Engine) undoAction(id string, undoInfo string) error {
-	switch id {
-	case "blacklist-nouveau":
-		// undoInfo contains the file path to remove, or the previous file contents
-		// if the file already existed before we modified it.
-		if undoInfo == nouveauBlacklistPath {
-			// We created the file; undo by removing it
-			return os.Remove(undoInfo)
-		}
-		// The file existed before; restore its original contents
-		return os.WriteFile(nouveauBlacklistPath, []byte(undoInfo), 0644)
-
-	case "update-ldconfig":
-		// ldconfig is idempotent; running it again is the "undo"
-		_, err := e.executor.Run("ldconfig")
-		return err
-
-	default:
-		return fmt.Errorf("unknown action for undo: %q", id)
+// undoBlacklistNouveau removes the blacklist file, or restores its previous
+// contents if it already existed. undoInfo holds the file path when we created
+// the file, otherwise the original contents.
+func (e *Engine) undoBlacklistNouveau(undoInfo string) error {
+	if undoInfo == nouveauBlacklistPath {
+		// We created the file; undo by removing it
+		return os.Remove(undoInfo)
 	}
+	// The file existed before; restore its original contents
+	return os.WriteFile(nouveauBlacklistPath, []byte(undoInfo), 0644)
 }
 
-// getAvailableActions returns the list of remediation actions available on Linux.
-func getAvailableActions() []types.RemediationAction {
-	return []types.RemediationAction{
-		{
-			ID:          "blacklist-nouveau",
-			Title:       "Blacklist nouveau driver",
-			Description: "Creates /etc/modprobe.d/blacklist-nouveau.conf to prevent the open-source nouveau driver from loading, allowing the proprietary NVIDIA driver to work correctly.",
-			Risk:        types.RiskMedium,
-			NeedsAdmin:  true,
-			NeedsReboot: true,
-			Platform:    "linux",
-			Category:    "driver",
-			RelatedFind: "nouveau driver is loaded",
-		},
-		{
-			ID:          "update-ldconfig",
-			Title:       "Refresh shared library cache (ldconfig)",
-			Description: "Runs ldconfig to update the dynamic linker cache. This ensures NVIDIA libraries (libcuda.so, libnvidia-ml.so) are findable by applications.",
-			Risk:        types.RiskLow,
-			NeedsAdmin:  true,
-			NeedsReboot: false,
-			Platform:    "linux",
-			Category:    "driver",
-			RelatedFind: "libcuda.so not found in library path",
-		},
-	}
+// undoUpdateLdconfig re-runs ldconfig; it is idempotent, so running it again
+// is the "undo".
+func (e *Engine) undoUpdateLdconfig(undoInfo string) error {
+	_, err := e.executor.Run("ldconfig")
+	return err
 }
@@ -5,8 +5,6 @@ package remediate
 import (
 	"fmt"
 	"strings"
-
-	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
 // Windows power plan GUIDs (well-known Microsoft constants)
@@ -108,86 +106,36 @@ func (e *Engine) actionDisableGameMode() (output, undoInfo string, err error) {
 		currentVal, nil
 }
 
-// applyAction dispatches a remediation action by ID to the appropriate
-// Windows-specific implementation.
-func (e *Engine) applyAction(id string) (output string, undoInfo string, err error) {
-	switch id {
-	case "set-high-performance":
-		return e.actionSetHighPerformance()
-	case "disable-hags":
-		return e.actionDisableHAGS()
-	case "disable-game-mode":
-		return e.actionDisableGameMode()
-	default:
-		return "", "", fmt.Errorf("unknown remediation action: %q", id)
-	}
+// actionImpls binds the Windows remediation IDs from knowledge/remediations.json
+// to their implementations.
+var actionImpls = map[string]actionImpl{
+	"set-high-performance": {apply: (*Engine).actionSetHighPerformance, undo: (*Engine).undoSetHighPerformance},
+	"disable-hags":         {apply: (*Engine).actionDisableHAGS, undo: (*Engine).undoDisableHAGS},
+	"disable-game-mode":    {apply: (*Engine).actionDisableGameMode, undo: (*Engine).undoDisableGameMode},
 }
 
-// undoAction reverses a previously applied Windows remediation action using
-// the stored undo information.
-func (e *Engine) undoAction(id string, undoInfo string) error {
-	switch id {
-	case "set-high-performance":
-		// undoInfo contains the previous power plan GUID
-		_, err := e.executor.Run("powercfg", "/setactive", undoInfo)
-		return err
-
-	case "disable-hags":
-		// undoInfo contains the previous HwSchMode value
-		regPath := `HKLM\SYSTEM\CurrentControlSet\Control\GraphicsDrivers`
-		_, err := e.executor.Run("reg", "add", regPath, "/v", "HwSchMode",
-			"/t", "REG_DWORD", "/d", undoInfo, "/f")
-		return err
-
-	case "disable-game-mode":
-		// undoInfo contains the previous AutoGameModeEnabled value
-		regPath := `HKCU\Software\Microsoft\GameBar`
-		_, err := e.executor.Run("reg", "add", regPath, "/v", "AutoGameModeEnabled",
-			"/t", "REG_DWORD", "/d", undoInfo, "/f")
-		return err
-
-	default:
-		return fmt.Errorf("unknown action for undo: %q", id)
-	}
+// undoSetHighPerformance restores the previous power plan. undoInfo contains
+// the previous power plan GUID.
+func (e *Engine) undoSetHighPerformance(undoInfo string) error {
+	_, err := e.executor.Run("powercfg", "/setactive", undoInfo)
+	return err
 }
 
-// getAvailableActions returns the list of remediation actions available on Windows.
-func getAvailableActions() []types.RemediationAction {
-	return []types.RemediationAction{
-		{
-			ID:          "set-high-performance",
-			Title:       "Switch to High Performance power plan",
-			Description: "Sets the Windows power plan to 'High performance' using powercfg. This prevents CPU throttling and ensures maximum GPU performance.",
-			Risk:        types.RiskLow,
-			NeedsAdmin:  true,
-			NeedsReboot: false,
-			Platform:    "windows",
-			Category:    "power",
-			RelatedFind: "Power plan is not set to High performance",
-		},
-		{
-			ID:          "disable-hags",
-			Title:       "Disable Hardware-Accelerated GPU Scheduling (HAGS)",
-			Description: "Sets the HwSchMode registry value to 1 to disable HAGS. Some games and applications experience stuttering or instability with HAGS enabled.",
-			Risk:        types.RiskMedium,
-			NeedsAdmin:  true,
-			NeedsReboot: true,
-			Platform:    "windows",
-			Category:    "registry",
-			RelatedFind: "HAGS is enabled",
-		},
-		{
-			ID:          "disable-game-mode",
-			Title:       "Disable Windows Game Mode",
-			Description: "Sets the AutoGameModeEnabled registry value to 0 to disable Game Mode. Game Mode can cause frame pacing issues in some titles.",
-			Risk:        types.RiskLow,
-			NeedsAdmin:  false,
-			NeedsReboot: false,
-			Platform:    "windows",
-			Category:    "registry",
-			RelatedFind: "Game Mode is enabled",
-		},
-	}
+// undoDisableHAGS restores the previous HwSchMode value held in undoInfo.
+func (e *Engine) undoDisableHAGS(undoInfo string) error {
+	regPath := `HKLM\SYSTEM\CurrentControlSet\Control\GraphicsDrivers`
+	_, err := e.executor.Run("reg", "add", regPath, "/v", "HwSchMode",
+		"/t", "REG_DWORD", "/d", undoInfo, "/f")
+	return err
+}
+
+// undoDisableGameMode restores the previous AutoGameModeEnabled value held
+// in undoInfo.
+func (e *Engine) undoDisableGameMode(undoInfo string) error {
+	regPath := `HKCU\Software\Microsoft\GameBar`
+	_, err := e.executor.Run("reg", "add", regPath, "/v", "AutoGameModeEnabled",
+		"/t", "REG_DWORD", "/d", undoInfo, "/f")
+	return err
 }
 
 // parsePowerSchemeGUID extracts the power scheme GUID from the output of
@@ -7,9 +7,11 @@ package remediate
 import (
 	"fmt"
 	"os/exec"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
@@ -33,6 +35,14 @@ func (e *DefaultExecutor) Run(name string, args ...string) (string, error) {
 	return strings.TrimSpace(string(out)), err
 }
 
+// actionImpl binds a remediation ID from knowledge/remediations.json to its
+// Go implementation. Each build-tagged actions file declares an actionImpls
+// map for the actions it supports.
+type actionImpl struct {
+	apply func(e *Engine) (output, undoInfo string, err error)
+	undo  func(e *Engine, undoInfo string) error
+}
+
 // Engine manages the remediation lifecycle: listing available actions,
 // previewing changes, applying fixes, recording a change journal, and
 // undoing previously applied changes.
@@ -40,23 +50,52 @@ type Engine struct {
 	executor   Executor
 	journalDir string
 	dryRun     bool
+	registry   []types.RemediationAction
 }
 
 // NewEngine creates a new remediation Engine.
 //
 // Parameters:
 //   - executor: the command executor to use (pass nil for DefaultExecutor)
+//   - pack: the knowledge pack listing remediations (pass nil for the embedded one)
 //   - journalDir: directory where the change journal file is stored
 //   - dryRun: when true, no commands are actually executed
-func NewEngine(executor Executor, journalDir string, dryRun bool) *Engine {
+func NewEngine(executor Executor, pack *knowledge.Pack, journalDir string, dryRun bool) *Engine {
 	if executor == nil {
 		executor = &DefaultExecutor{}
 	}
+	if pack == nil {
+		pack = knowledge.Default()
+	}
 	return &Engine{
 		executor:   executor,
 		journalDir: journalDir,
 		dryRun:     dryRun,
+		registry:   buildRegistry(pack, runtime.GOOS),
+	}
+}
+
+// buildRegistry returns the remediation actions from the knowledge pack that
+// target the given platform and have a Go implementation in this build.
+func buildRegistry(pack *knowledge.Pack, platform string) []types.RemediationAction {
+	var actions []types.RemediationAction
+	for _, a := range pack.Remediations {
+		if a.Platform != "all" && a.Platform != platform {
+			continue
+		}
+		if !Implemented(a.ID) {
+			continue
+		}
+		actions = append(actions, a)
 	}
+	return actions
+}
+
+// Implemented reports whether this build has a Go implementation for the
+// remediation action with the given ID.
+func Implemented(id string) bool {
+	_, ok := actionImpls[id]
+	return ok
 }
 
 // Preview returns a human-readable description of what the action would do,
@@ -87,7 +126,7 @@ func (e *Engine) Preview(action types.RemediationAction) string {
 // a result is returned indicating what would have happened.
 //
 // The method:
-//  1. Looks up the action by ID in the platform action registry
+//  1. Looks up the action's implementation by ID
 //  2. Calls the platform-specific implementation (or simulates in dry-run)
 //  3. Writes a ChangeJournalEntry to disk for audit/undo purposes
 //  4. Returns a RemediationResult with the outcome
@@ -183,8 +222,28 @@ func (e *Engine) Undo(entry types.ChangeJournalEntry) error {
 }
 
 // ListAvailable returns all remediation actions applicable to the current platform.
-// This delegates to the platform-specific getAvailableActions() function, which is
-// defined in the build-tagged action files (actions_windows.go, actions_linux.go, etc.).
+// The registry is built from knowledge/remediations.json, filtered to the entries
+// bound to an implementation in the build-tagged action files.
 func (e *Engine) ListAvailable() []types.RemediationAction {
-	return getAvailableActions()
+	return e.registry
+}
+
+// applyAction dispatches a remediation action by ID to its platform-specific
+// implementation.
+func (e *Engine) applyAction(id string) (output string, undoInfo string, err error) {
+	impl, ok := actionImpls[id]
+	if !ok {
+		return "", "", fmt.Errorf("unknown remediation action: %q", id)
+	}
+	return impl.apply(e)
+}
+
+// undoAction reverses a previously applied remediation action using the
+// stored undo information.
+func (e *Engine) undoAction(id string, undoInfo string) error {
+	impl, ok := actionImpls[id]
+	if !ok {
+		return fmt.Errorf("unknown action for undo: %q", id)
+	}
+	return impl.undo(e, undoInfo)
 }
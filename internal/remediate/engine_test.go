@@ -1,8 +1,10 @@
 package remediate
 
 import (
+	"runtime"
 	"testing"
 
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
@@ -23,7 +25,7 @@ func (m *MockExecutor) Run(name string, args ...string) (string, error) {
 }
 
 func TestNewEngine_DefaultExecutor(t *testing.T) {
-	e := NewEngine(nil, t.TempDir(), false)
+	e := NewEngine(nil, nil, t.TempDir(), false)
 	if e == nil {
 		t.Fatal("NewEngine returned nil")
 	}
@@ -34,7 +36,7 @@ func TestNewEngine_DefaultExecutor(t *testing.T) {
 
 func TestNewEngine_CustomExecutor(t *testing.T) {
 	mock := &MockExecutor{}
-	e := NewEngine(mock, t.TempDir(), true)
+	e := NewEngine(mock, nil, t.TempDir(), true)
 	if e.executor != mock {
 		t.Fatal("Engine should use the provided executor")
 	}
@@ -44,7 +46,7 @@ func TestNewEngine_CustomExecutor(t *testing.T) {
 }
 
 func TestPreview(t *testing.T) {
-	e := NewEngine(&MockExecutor{}, t.TempDir(), false)
+	e := NewEngine(&MockExecutor{}, nil, t.TempDir(), false)
 	action := types.RemediationAction{
 		ID:          "test-action",
 		Title:       "Test Action",
@@ -70,7 +72,7 @@ func TestPreview(t *testing.T) {
 }
 
 func TestPreview_DryRun(t *testing.T) {
-	e := NewEngine(&MockExecutor{}, t.TempDir(), true)
+	e := NewEngine(&MockExecutor{}, nil, t.TempDir(), true)
 	action := types.RemediationAction{
 		ID:    "test",
 		Title: "Test",
@@ -85,7 +87,7 @@ func TestPreview_DryRun(t *testing.T) {
 
 func TestApply_DryRun(t *testing.T) {
 	mock := &MockExecutor{}
-	e := NewEngine(mock, t.TempDir(), true)
+	e := NewEngine(mock, nil, t.TempDir(), true)
 	action := types.RemediationAction{
 		ID:    "test-action",
 		Title: "Test Action",
@@ -108,7 +110,7 @@ func TestApply_DryRun(t *testing.T) {
 }
 
 func TestListAvailable(t *testing.T) {
-	e := NewEngine(&MockExecutor{}, t.TempDir(), false)
+	e := NewEngine(&MockExecutor{}, nil, t.TempDir(), false)
 	actions := e.ListAvailable()
 	// Should return at least some actions on the current platform
 	// (on Windows during tests, should have set-high-performance, disable-hags, etc.)
@@ -133,3 +135,44 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestBuildRegistry_FromKnowledge(t *testing.T) {
+	pack := knowledge.Default()
+	actions := buildRegistry(pack, runtime.GOOS)
+	for _, a := range actions {
+		if a.Platform != runtime.GOOS && a.Platform != "all" {
+			t.Errorf("action %s targets %s, not %s", a.ID, a.Platform, runtime.GOOS)
+		}
+		if _, ok := actionImpls[a.ID]; !ok {
+			t.Errorf("action %s has no implementation", a.ID)
+		}
+	}
+	// Every bound implementation must have a knowledge pack entry, or it
+	// would never be listed by fix.
+	for id := range actionImpls {
+		if _, ok := pack.Remediation(id); !ok {
+			t.Errorf("implementation %s has no entry in remediations.json", id)
+		}
+	}
+	if len(actions) != len(actionImpls) {
+		t.Errorf("expected %d registered actions, got %d", len(actionImpls), len(actions))
+	}
+}
+
+func TestNewEngine_UsesGivenPack(t *testing.T) {
+	custom := *knowledge.Default()
+	custom.Remediations = nil
+	for _, a := range knowledge.Default().Remediations {
+		if Implemented(a.ID) && (a.Platform == runtime.GOOS || a.Platform == "all") {
+			custom.Remediations = append(custom.Remediations, a)
+			break
+		}
+	}
+	if len(custom.Remediations) == 0 {
+		t.Skip("no remediation actions are implemented on " + runtime.GOOS)
+	}
+	e := NewEngine(&MockExecutor{}, &custom, t.TempDir(), true)
+	if actions := e.ListAvailable(); len(actions) != 1 || actions[0].ID != custom.Remediations[0].ID {
+		t.Errorf("expected only %s from the given pack, got %+v", custom.Remediations[0].ID, actions)
+	}
+}
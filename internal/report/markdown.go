@@ -19,6 +19,15 @@ func GenerateMarkdown(report *types.Report) string {
 	w("**Generated:** %s | **Mode:** %s | **Platform:** %s\n\n",
 		report.Metadata.Timestamp.Format("2006-01-02 15:04:05"),
 		report.Metadata.Mode, report.Metadata.Platform)
+	if report.Metadata.Incomplete {
+		w("> **Incomplete report:** %s. Some sections may be missing.\n\n", report.Metadata.IncompleteReason)
+	}
+	if report.Metadata.ReanalyzedBy != "" {
+		w("> **Re-analyzed:** findings were regenerated offline by NVCheckup %s from previously collected data.\n\n", report.Metadata.ReanalyzedBy)
+	}
+	if report.Metadata.Replayed {
+		w("> **Replayed report:** command output was served from a recording; no commands were run.\n\n")
+	}
 
 	// Summary
 	w("## Summary\n\n")
@@ -46,17 +55,84 @@ func GenerateMarkdown(report *types.Report) string {
 		w("| Property | Value |\n")
 		w("|----------|-------|\n")
 		w("| Vendor | %s |\n", gpu.Vendor)
+		if model := gpuModel(gpu); model != "" {
+			w("| Model | %s |\n", model)
+		}
 		w("| Driver | %s |\n", gpu.DriverVersion)
+		if fw := gpuFirmware(gpu); fw != "" {
+			w("| Firmware | %s |\n", fw)
+		}
 		if gpu.VRAMTotalMB > 0 {
 			w("| VRAM | %d MB total / %d MB free |\n", gpu.VRAMTotalMB, gpu.VRAMFreeMB)
 		}
 		if gpu.Temperature > 0 {
 			w("| Temperature | %d°C |\n", gpu.Temperature)
 		}
+		if gpu.ReBAR != nil {
+			w("| Resizable BAR | %s |\n", rebarState(gpu.ReBAR))
+		}
+		if gpu.MIG != nil {
+			w("| MIG | %s |\n", migMode(gpu.MIG))
+			for _, inst := range gpu.MIG.Instances {
+				w("| MIG %d | %s, GI %d / CI %d, %d MB, %s |\n", inst.DeviceIndex, valueOrNA(inst.Profile),
+					inst.GPUInstanceID, inst.ComputeInstanceID, inst.MemoryTotalMB, valueOrNA(inst.UUID))
+			}
+		}
+		w("\n")
+	}
+
+	w("**NVIDIA Driver:** %s | **CUDA:** %s\n\n", driverVersion(report.Driver), valueOrNA(report.Driver.CUDAVersion))
+
+	// Per-GPU thermal and PCIe state
+	if rows := gpuTelemetry(report); len(rows) > 0 {
+		w("## GPU Thermal & PCIe\n\n")
+		w("| GPU | Bus ID | Temp | P-State | Clock | Power | Fan | Slowdown | PCIe (current / max) |\n")
+		w("|-----|--------|------|---------|-------|-------|-----|----------|----------------------|\n")
+		for _, r := range rows {
+			w("| %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+				r.GPU, r.BusID, r.Temp, r.PState, r.Clock, r.Power, r.Fan, r.Slowdown, r.PCIe)
+		}
+		w("\n")
+	}
+
+	// Per-GPU ECC, retired pages and row remapping
+	if rows := gpuMemoryHealth(report); len(rows) > 0 {
+		w("## GPU Memory Health\n\n")
+		w("| GPU | Bus ID | ECC | Volatile (corr / unc) | Aggregate (corr / unc) | Retired Pages | Row Remap |\n")
+		w("|-----|--------|-----|-----------------------|------------------------|---------------|-----------|\n")
+		for _, r := range rows {
+			w("| %s | %s | %s | %s | %s | %s | %s |\n",
+				r.GPU, r.BusID, r.ECC, r.Volatile, r.Aggregate, r.Retired, r.Remap)
+		}
 		w("\n")
 	}
 
-	w("**NVIDIA Driver:** %s | **CUDA:** %s\n\n", valueOrNA(report.Driver.Version), valueOrNA(report.Driver.CUDAVersion))
+	// Processes holding each GPU
+	if rows := gpuProcesses(report); len(rows) > 0 {
+		w("## GPU Processes\n\n")
+		w("| GPU | PID | Type | VRAM | State | Process |\n")
+		w("|-----|-----|------|------|-------|---------|\n")
+		for _, r := range rows {
+			w("| %s | %s | %s | %s | %s | %s |\n", r.GPU, r.PID, r.Type, r.Memory, r.State, r.Name)
+		}
+		w("\n")
+	}
+
+	// GPU-to-GPU link matrix
+	if report.Topology != nil && len(report.Topology.GPUs) > 0 {
+		header, rows := topologyTable(report.Topology)
+		w("## GPU Topology\n\n")
+		w("| %s |\n", strings.Join(header, " | "))
+		w("|%s\n", strings.Repeat("---|", len(header)))
+		for _, r := range rows {
+			w("| %s |\n", strings.Join(r, " | "))
+		}
+		w("\n**Allowed CPUs:** %s", valueOrNA(report.Topology.AllowedCPUs))
+		if report.Topology.VisibleDevices != "" {
+			w(" | **CUDA_VISIBLE_DEVICES:** %s", report.Topology.VisibleDevices)
+		}
+		w("\n\n")
+	}
 
 	// Findings
 	w("## Findings\n\n")
@@ -104,6 +180,36 @@ func GenerateMarkdown(report *types.Report) string {
 	}
 	w("\n")
 
+	// Collector timings
+	if len(report.Collectors) > 0 {
+		w("## Collectors\n\n")
+		w("| Collector | Status | Time | Note |\n")
+		w("|-----------|--------|------|------|\n")
+		for _, c := range report.Collectors {
+			note := c.Note
+			if note == "" {
+				note = "—"
+			}
+			w("| %s | %s | %.1fs | %s |\n", c.Name, c.Status, float64(c.DurationMs)/1000, note)
+		}
+		w("\n")
+	}
+
+	// Checks skipped for lack of privileges
+	if len(report.SkippedChecks) > 0 {
+		w("## Skipped: Needs Root/Admin\n\n")
+		w("| Check | Reason | Affects |\n")
+		w("|-------|--------|---------|\n")
+		for _, s := range report.SkippedChecks {
+			affects := strings.Join(s.Affects, ", ")
+			if affects == "" {
+				affects = "—"
+			}
+			w("| %s | %s | %s |\n", s.Check, s.Reason, affects)
+		}
+		w("\n")
+	}
+
 	// Collector errors
 	if len(report.CollectorErrors) > 0 {
 		w("## Collector Notes\n\n")
@@ -25,6 +25,20 @@ func GenerateText(report *types.Report) string {
 	w("  Mode:      %s\n", report.Metadata.Mode)
 	w("  Platform:  %s\n", report.Metadata.Platform)
 	w("  Runtime:   %.1fs\n", report.Metadata.RuntimeSeconds)
+	if report.Metadata.Incomplete {
+		w("  Status:    INCOMPLETE — %s\n", report.Metadata.IncompleteReason)
+	}
+	if report.Metadata.ReanalyzedBy != "" {
+		w("  Analysis:  RE-ANALYZED offline by %s\n", report.Metadata.ReanalyzedBy)
+	}
+	if report.Metadata.Replayed {
+		w("  Source:    REPLAYED from a command recording (no commands were run)\n")
+	}
+	if report.Metadata.Elevated {
+		w("  Privilege: root/Administrator\n")
+	} else {
+		w("  Privilege: standard user\n")
+	}
 	if report.Metadata.RedactionEnabled {
 		w("  Redaction: ENABLED (PII removed)\n")
 	} else {
@@ -66,7 +80,13 @@ func GenerateText(report *types.Report) string {
 	for _, gpu := range report.GPUs {
 		w("  [GPU %d] %s\n", gpu.Index, gpu.Name)
 		w("    Vendor:    %s\n", gpu.Vendor)
+		if model := gpuModel(gpu); model != "" {
+			w("    Model:     %s\n", model)
+		}
 		w("    Driver:    %s\n", gpu.DriverVersion)
+		if fw := gpuFirmware(gpu); fw != "" {
+			w("    Firmware:  %s\n", fw)
+		}
 		if gpu.PCIBusID != "" {
 			w("    PCI Bus:   %s\n", gpu.PCIBusID)
 		}
@@ -80,14 +100,87 @@ func GenerateText(report *types.Report) string {
 		if gpu.WDDMVersion != "" {
 			w("    WDDM:      %s\n", gpu.WDDMVersion)
 		}
+		if gpu.ReBAR != nil {
+			w("    ReBAR:     %s\n", rebarState(gpu.ReBAR))
+		}
+		if gpu.MIG != nil {
+			w("    MIG:       %s\n", migMode(gpu.MIG))
+			for _, inst := range gpu.MIG.Instances {
+				w("      [MIG %d] %-8s GI %d / CI %d  %d MB  %s\n", inst.DeviceIndex, valueOrNA(inst.Profile),
+					inst.GPUInstanceID, inst.ComputeInstanceID, inst.MemoryTotalMB, valueOrNA(inst.UUID))
+			}
+		}
 		w("\n")
 	}
 
 	// Driver Info
-	w("  NVIDIA Driver: %s\n", valueOrNA(report.Driver.Version))
+	w("  NVIDIA Driver: %s\n", driverVersion(report.Driver))
 	w("  CUDA (driver): %s\n", valueOrNA(report.Driver.CUDAVersion))
 	line()
 
+	// Per-GPU thermal and PCIe state
+	if rows := gpuTelemetry(report); len(rows) > 0 {
+		w("\n== GPU THERMAL & PCIE ==\n\n")
+		w("  %-4s %-17s %-6s %-6s %-15s %-17s %-5s %-9s %s\n",
+			"GPU", "Bus ID", "Temp", "State", "Clock", "Power", "Fan", "Slowdown", "PCIe (current / max)")
+		for _, r := range rows {
+			w("  %-4s %-17s %-6s %-6s %-15s %-17s %-5s %-9s %s\n",
+				r.GPU, r.BusID, r.Temp, r.PState, r.Clock, r.Power, r.Fan, r.Slowdown, r.PCIe)
+		}
+		w("\n")
+		line()
+	}
+
+	// Per-GPU ECC, retired pages and row remapping
+	if rows := gpuMemoryHealth(report); len(rows) > 0 {
+		w("\n== GPU MEMORY HEALTH ==\n\n")
+		w("  %-4s %-17s %-10s %-15s %-15s %-16s %s\n",
+			"GPU", "Bus ID", "ECC", "Volatile c/u", "Aggregate c/u", "Retired pages", "Row remap")
+		for _, r := range rows {
+			w("  %-4s %-17s %-10s %-15s %-15s %-16s %s\n",
+				r.GPU, r.BusID, r.ECC, r.Volatile, r.Aggregate, r.Retired, r.Remap)
+		}
+		w("\n")
+		line()
+	}
+
+	// Processes holding each GPU
+	if rows := gpuProcesses(report); len(rows) > 0 {
+		w("\n== GPU PROCESSES ==\n\n")
+		w("  %-4s %-8s %-4s %-10s %-8s %s\n", "GPU", "PID", "Type", "VRAM", "State", "Process")
+		for _, r := range rows {
+			w("  %-4s %-8s %-4s %-10s %-8s %s\n", r.GPU, r.PID, r.Type, r.Memory, r.State, r.Name)
+		}
+		w("\n")
+		line()
+	}
+
+	// GPU-to-GPU link matrix
+	if report.Topology != nil && len(report.Topology.GPUs) > 0 {
+		header, rows := topologyTable(report.Topology)
+		w("\n== GPU TOPOLOGY ==\n\n")
+		for _, r := range append([][]string{header}, rows...) {
+			w(" ")
+			for i, cell := range r {
+				switch {
+				case i < len(r)-3:
+					w(" %-6s", cell)
+				case i < len(r)-1:
+					w(" %-14s", cell)
+				default:
+					w(" %s", cell)
+				}
+			}
+			w("\n")
+		}
+		w("\n  Allowed CPUs: %s\n", valueOrNA(report.Topology.AllowedCPUs))
+		if report.Topology.VisibleDevices != "" {
+			w("  CUDA_VISIBLE_DEVICES: %s\n", report.Topology.VisibleDevices)
+		}
+		w("\n")
+		line()
+	}
+
 	// Platform-specific sections
 	if report.Windows != nil {
 		writeWindowsSection(&sb, report.Windows)
@@ -155,6 +248,34 @@ func GenerateText(report *types.Report) string {
 	w("\n")
 	line()
 
+	// Collector timings
+	if len(report.Collectors) > 0 {
+		w("\n== COLLECTORS ==\n\n")
+		for _, c := range report.Collectors {
+			w("  %-18s %-12s %6.1fs", c.Name, c.Status, float64(c.DurationMs)/1000)
+			if c.Note != "" {
+				w("  (%s)", c.Note)
+			}
+			w("\n")
+		}
+		w("\n")
+		line()
+	}
+
+	// Checks skipped for lack of privileges
+	if len(report.SkippedChecks) > 0 {
+		w("\n== SKIPPED: NEEDS ROOT/ADMIN ==\n\n")
+		for _, s := range report.SkippedChecks {
+			w("  %-28s %s", s.Check, s.Reason)
+			if len(s.Affects) > 0 {
+				w("  (affects: %s)", strings.Join(s.Affects, ", "))
+			}
+			w("\n")
+		}
+		w("\n")
+		line()
+	}
+
 	// Collector Errors
 	if len(report.CollectorErrors) > 0 {
 		w("\n== COLLECTOR NOTES ==\n\n")
@@ -247,7 +368,13 @@ func writeLinuxSection(sb *strings.Builder, l *types.LinuxInfo) {
 	}
 
 	fmt.Fprintf(sb, "  libcuda.so:     %s\n", valueOrNA(l.LibCudaPath))
-	fmt.Fprintf(sb, "  DKMS Status:    %s\n", valueOrNA(l.DKMSStatus))
+	if len(l.DKMSModules) == 0 {
+		fmt.Fprintf(sb, "  DKMS Status:    %s\n", valueOrNA(l.DKMSStatus))
+	}
+	if v := l.DriverVersions; v != nil {
+		fmt.Fprintf(sb, "  Driver versions: module %s, NVML %s, libcuda %s, package %s, modinfo %s\n",
+			valueOrNA(v.LoadedModule), valueOrNA(v.NVML), valueOrNA(v.LibCUDA), valueOrNA(v.Package), valueOrNA(v.Modinfo))
+	}
 	fmt.Fprintf(sb, "  PRIME:          %s\n", valueOrNA(l.PRIMEStatus))
 
 	if l.ContainerRuntime != "" {
@@ -255,6 +382,32 @@ func writeLinuxSection(sb *strings.Builder, l *types.LinuxInfo) {
 		fmt.Fprintf(sb, "  NV Container:   %s\n", valueOrNA(l.NVContainerToolkit))
 	}
 
+	if len(l.DKMSModules) > 0 {
+		fmt.Fprintf(sb, "\n  DKMS:\n")
+		for _, m := range l.DKMSModules {
+			state := m.State
+			if m.Note != "" {
+				state += " (" + m.Note + ")"
+			}
+			fmt.Fprintf(sb, "    - %-10s %-12s %-28s %s\n", m.Module, m.Version, valueOrNA(m.Kernel), state)
+		}
+	}
+
+	if len(l.Kernels) > 0 {
+		fmt.Fprintf(sb, "\n  Installed Kernels:\n")
+		for _, k := range l.Kernels {
+			module := "no nvidia module"
+			if k.NVIDIAModule != "" {
+				module = k.NVIDIAModule
+			}
+			next := ""
+			if k.Version == l.NextBootKernel {
+				next = fmt.Sprintf("  [next boot: %s]", l.NextBootSource)
+			}
+			fmt.Fprintf(sb, "    - %-28s %s%s\n", k.Version, module, next)
+		}
+	}
+
 	if len(l.NVIDIAPackages) > 0 {
 		fmt.Fprintf(sb, "\n  NVIDIA Packages Installed:\n")
 		for _, pkg := range l.NVIDIAPackages {
@@ -325,3 +478,269 @@ func valueOrNA(s string) string {
 	}
 	return s
 }
+
+// gpuTelemetryRow is one GPU's line in the thermal/PCIe table.
+type gpuTelemetryRow struct {
+	GPU, BusID, Temp, PState, Clock, Power, Fan, Slowdown, PCIe string
+}
+
+// gpuTelemetry returns a row per GPU with thermal or PCIe data. Reports
+// saved before that data was kept per GPU get one row from the report-level
+// readings.
+func gpuTelemetry(report *types.Report) []gpuTelemetryRow {
+	var rows []gpuTelemetryRow
+	for _, g := range report.GPUs {
+		if g.Thermal != nil || g.PCIe != nil {
+			rows = append(rows, telemetryRow(g.Index, g.PCIBusID, g.Thermal, g.PCIe))
+		}
+	}
+	if len(rows) == 0 && (report.Thermal != nil || report.PCIe != nil) {
+		var index int
+		var busID string
+		if report.Thermal != nil {
+			index, busID = report.Thermal.GPUIndex, report.Thermal.PCIBusID
+		} else {
+			index, busID = report.PCIe.GPUIndex, report.PCIe.PCIBusID
+		}
+		rows = append(rows, telemetryRow(index, busID, report.Thermal, report.PCIe))
+	}
+	return rows
+}
+
+func telemetryRow(index int, busID string, t *types.ThermalInfo, p *types.PCIeInfo) gpuTelemetryRow {
+	row := gpuTelemetryRow{
+		GPU: fmt.Sprintf("%d", index), BusID: valueOrNA(busID),
+		Temp: "N/A", PState: "N/A", Clock: "N/A", Power: "N/A", Fan: "N/A", Slowdown: "N/A", PCIe: "N/A",
+	}
+	if t != nil {
+		if t.TemperatureC > 0 {
+			row.Temp = fmt.Sprintf("%d°C", t.TemperatureC)
+		}
+		row.PState = valueOrNA(t.PowerState)
+		if t.MaxClockMHz > 0 {
+			row.Clock = fmt.Sprintf("%d/%d MHz", t.CurrentClockMHz, t.MaxClockMHz)
+		}
+		if t.PowerDrawW != "" {
+			row.Power = t.PowerDrawW + "/" + valueOrNA(t.PowerLimitW) + " W"
+		}
+		if t.FanSpeedPct >= 0 {
+			row.Fan = fmt.Sprintf("%d%%", t.FanSpeedPct)
+		}
+		switch {
+		case t.ThermalThrottle:
+			row.Slowdown = "THERMAL"
+		case t.SlowdownActive:
+			row.Slowdown = "yes"
+		default:
+			row.Slowdown = "no"
+		}
+	}
+	if p != nil && (p.CurrentSpeed != "" || p.MaxSpeed != "") {
+		row.PCIe = fmt.Sprintf("%s %s / %s %s", p.CurrentSpeed, p.CurrentWidth, p.MaxSpeed, p.MaxWidth)
+		if p.Downshifted {
+			row.PCIe += " (DOWNSHIFTED)"
+		}
+		if u := p.Upstream; u != nil && u.MaxSpeed != "" {
+			row.PCIe += fmt.Sprintf(", slot %s %s", u.MaxSpeed, u.MaxWidth)
+		}
+		if a := p.AER; a != nil && a.Correctable+a.NonFatal+a.Fatal > 0 {
+			row.PCIe += fmt.Sprintf(", AER %d corr / %d nonfatal / %d fatal", a.Correctable, a.NonFatal, a.Fatal)
+		}
+	}
+	return row
+}
+
+// gpuMemoryHealthRow is one GPU's line in the memory health table.
+type gpuMemoryHealthRow struct {
+	GPU, BusID, ECC, Volatile, Aggregate, Retired, Remap string
+}
+
+// gpuMemoryHealth returns a row per GPU that reports ECC, retired pages or
+// row remapping.
+func gpuMemoryHealth(report *types.Report) []gpuMemoryHealthRow {
+	var rows []gpuMemoryHealthRow
+	for _, g := range report.GPUs {
+		m := g.MemoryHealth
+		if m == nil {
+			continue
+		}
+		row := gpuMemoryHealthRow{
+			GPU: fmt.Sprintf("%d", g.Index), BusID: valueOrNA(g.PCIBusID),
+			ECC: "N/A", Volatile: "N/A", Aggregate: "N/A", Retired: "N/A", Remap: "N/A",
+		}
+		if m.ECCSupported {
+			row.ECC = onOff(m.ECCEnabled)
+			if m.ECCPendingEnabled != m.ECCEnabled {
+				row.ECC += " -> " + onOff(m.ECCPendingEnabled)
+			}
+			row.Volatile = fmt.Sprintf("%d / %d", m.VolatileCorrectable, m.VolatileUncorrectable)
+			row.Aggregate = fmt.Sprintf("%d / %d", m.AggregateCorrectable, m.AggregateUncorrectable)
+			if m.SRAMThresholdExceeded {
+				row.Aggregate += " (SRAM LIMIT)"
+			}
+		}
+		if m.RetiredPagesSupported {
+			row.Retired = fmt.Sprintf("%d sbe, %d dbe", m.RetiredSingleBit, m.RetiredDoubleBit)
+			if m.RetirementPending {
+				row.Retired += " (PENDING)"
+			}
+		}
+		if m.RowRemapSupported {
+			row.Remap = fmt.Sprintf("%d corr, %d unc", m.RemappedCorrectable, m.RemappedUncorrectable)
+			switch {
+			case m.RemapFailed:
+				row.Remap += " (FAILED)"
+			case m.RemapPending:
+				row.Remap += " (PENDING RESET)"
+			}
+			if len(m.RemapHistogram) == 5 && m.RemapHistogram[4] > 0 {
+				row.Remap += fmt.Sprintf(", %d bank(s) exhausted", m.RemapHistogram[4])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// gpuModel describes a GPU from the PCI ID table, e.g. "GeForce RTX 3080
+// (Ampere, compute 8.6, desktop)". It is empty for GPUs the table does not
+// list.
+func gpuModel(gpu types.GPUInfo) string {
+	if gpu.Architecture == "" {
+		return ""
+	}
+	model := fmt.Sprintf("%s (%s, compute %s, %s)", gpu.MarketingName, gpu.Architecture, gpu.ComputeCapability, gpu.Class)
+	if gpu.LastDriverBranch != "" {
+		model += fmt.Sprintf(", last driver branch R%s", gpu.LastDriverBranch)
+	}
+	return model
+}
+
+// gpuProcessRow is one process's line in the GPU processes table.
+type gpuProcessRow struct {
+	GPU, PID, Type, Memory, State, Name string
+}
+
+// gpuProcesses returns a row per process on every GPU.
+func gpuProcesses(report *types.Report) []gpuProcessRow {
+	var rows []gpuProcessRow
+	for _, g := range report.GPUs {
+		for _, p := range g.Processes {
+			row := gpuProcessRow{
+				GPU: fmt.Sprintf("%d", g.Index), PID: fmt.Sprintf("%d", p.PID),
+				Type: valueOrNA(p.Type), Memory: "N/A", State: valueOrNA(p.State), Name: valueOrNA(p.Name),
+			}
+			if p.UsedMemoryMB >= 0 {
+				row.Memory = fmt.Sprintf("%d MB", p.UsedMemoryMB)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
+
+// topologyTable lays out the topology matrix: a header and one row per GPU
+// with its path to every GPU, then CPU affinity, NUMA node and NVLinks up.
+func topologyTable(topo *types.TopologyInfo) (header []string, rows [][]string) {
+	header = []string{""}
+	for _, g := range topo.GPUs {
+		header = append(header, fmt.Sprintf("GPU%d", g.Index))
+	}
+	header = append(header, "CPU Affinity", "NUMA", "NVLinks up")
+
+	for _, g := range topo.GPUs {
+		row := []string{fmt.Sprintf("GPU%d", g.Index)}
+		for j := range topo.GPUs {
+			path := "N/A"
+			if j < len(g.Paths) {
+				path = g.Paths[j]
+			}
+			row = append(row, path)
+		}
+		numa := "N/A"
+		if g.NUMANode >= 0 {
+			numa = fmt.Sprintf("%d", g.NUMANode)
+		}
+		links := "N/A"
+		if len(g.NVLinks) > 0 {
+			up := 0
+			for _, l := range g.NVLinks {
+				if l.Up {
+					up++
+				}
+			}
+			links = fmt.Sprintf("%d/%d", up, len(g.NVLinks))
+		}
+		rows = append(rows, append(row, valueOrNA(g.CPUAffinity), numa, links))
+	}
+	return header, rows
+}
+
+// migMode describes a GPU's MIG mode, including a change waiting for reset.
+func migMode(m *types.MIGInfo) string {
+	mode := "disabled"
+	if m.Enabled {
+		mode = fmt.Sprintf("enabled, %d instance(s)", len(m.Instances))
+	}
+	switch {
+	case m.PendingEnabled && !m.Enabled:
+		mode += " (enabled after GPU reset)"
+	case !m.PendingEnabled && m.Enabled:
+		mode += " (disabled after GPU reset)"
+	}
+	return mode
+}
+
+// rebarState describes a GPU's Resizable BAR state and BAR1 size.
+func rebarState(r *types.ReBARInfo) string {
+	state := "not supported"
+	switch {
+	case r.Enabled != nil && *r.Enabled:
+		state = "enabled"
+	case r.Supported && r.Enabled != nil:
+		state = "disabled (supported by the GPU)"
+	case r.Supported:
+		state = "supported by the GPU, current BAR1 size unknown"
+	case len(r.Sources) == 1 && r.Sources[0] == "nvidia-smi":
+		// nvidia-smi gives the BAR1 size but not the capability
+		state = "unknown"
+	}
+	if r.BAR1SizeMB > 0 {
+		state += fmt.Sprintf(", BAR1 %d MB", r.BAR1SizeMB)
+		if r.BAR1MaxMB > r.BAR1SizeMB {
+			state += fmt.Sprintf(" of %d MB max", r.BAR1MaxMB)
+		}
+	}
+	if r.Above4GDecoding != "" {
+		state += ", Above 4G decoding " + r.Above4GDecoding
+	}
+	return state
+}
+
+// gpuFirmware lists a GPU's VBIOS and GSP firmware versions.
+func gpuFirmware(gpu types.GPUInfo) string {
+	var parts []string
+	if gpu.VBIOSVersion != "" {
+		parts = append(parts, "VBIOS "+gpu.VBIOSVersion)
+	}
+	if gpu.FirmwareVersion != "" {
+		parts = append(parts, "GSP "+gpu.FirmwareVersion)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// driverVersion shows the driver version, noting when it came from the loaded
+// kernel module because nvidia-smi did not report one.
+func driverVersion(d types.DriverInfo) string {
+	if d.Sources["version"] == "procfs" {
+		return d.Version + " (loaded kernel module; nvidia-smi unavailable)"
+	}
+	return valueOrNA(d.Version)
+}
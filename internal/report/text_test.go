@@ -170,3 +170,244 @@ func createTestReport() *types.Report {
 		SummaryBlock: "NVCheckup v0.1.0 | 2025-01-15 14:30:00\nGPU: RTX 4090 | Driver: 566.36\n",
 	}
 }
+
+func TestGenerateText_CollectorTimings(t *testing.T) {
+	report := createTestReport()
+	report.Collectors = []types.CollectorRun{
+		{Name: "gpu", Status: types.CollectorCompleted, DurationMs: 1200},
+		{Name: "ai", Status: types.CollectorCancelled, DurationMs: 30000, Note: "cut off by the 30s time budget"},
+	}
+	output := GenerateText(report)
+	if !strings.Contains(output, "COLLECTORS") {
+		t.Error("missing collectors section")
+	}
+	if !strings.Contains(output, "cancelled") || !strings.Contains(output, "cut off by the 30s time budget") {
+		t.Error("expected cancelled collector and its note")
+	}
+}
+
+func TestGenerateText_SkippedChecks(t *testing.T) {
+	report := createTestReport()
+	report.SkippedChecks = []types.SkippedCheck{
+		{Check: "linux.xid.dmesg", Needs: "admin", Reason: "--no-admin", Affects: []string{"xid-errors"}},
+	}
+	output := GenerateText(report)
+	if !strings.Contains(output, "SKIPPED: NEEDS ROOT/ADMIN") {
+		t.Error("missing skipped checks section")
+	}
+	if !strings.Contains(output, "linux.xid.dmesg") || !strings.Contains(output, "affects: xid-errors") {
+		t.Error("expected the skipped check and what it affects")
+	}
+}
+
+func TestGPUTelemetryTable(t *testing.T) {
+	report := createTestReport()
+	report.GPUs = append(report.GPUs, types.GPUInfo{Index: 1, Name: "NVIDIA GeForce RTX 3090", Vendor: "NVIDIA", IsNVIDIA: true, PCIBusID: "00000000:41:00.0"})
+	report.GPUs[0].PCIBusID = "00000000:01:00.0"
+	report.GPUs[0].Thermal = &types.ThermalInfo{TemperatureC: 42, PowerState: "P8", FanSpeedPct: 30}
+	report.GPUs[1].Thermal = &types.ThermalInfo{GPUIndex: 1, TemperatureC: 88, PowerState: "P0", FanSpeedPct: -1, ThermalThrottle: true}
+	report.GPUs[1].PCIe = &types.PCIeInfo{GPUIndex: 1, CurrentSpeed: "Gen4", CurrentWidth: "x8", MaxSpeed: "Gen4", MaxWidth: "x16", Downshifted: true,
+		Upstream: &types.PCIeLink{BDF: "0000:40:01.1", MaxSpeed: "Gen4", MaxWidth: "x16"}, AER: &types.PCIeAER{Correctable: 153}}
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "== GPU THERMAL & PCIE ==") {
+		t.Fatal("missing per-GPU thermal/PCIe section")
+	}
+	if !strings.Contains(text, "00000000:41:00.0") || !strings.Contains(text, "THERMAL") || !strings.Contains(text, "Gen4 x8 / Gen4 x16 (DOWNSHIFTED), slot Gen4 x16, AER 153 corr / 0 nonfatal / 0 fatal") {
+		t.Errorf("second GPU row incomplete:\n%s", text)
+	}
+
+	md := GenerateMarkdown(report)
+	if !strings.Contains(md, "## GPU Thermal & PCIe") || !strings.Contains(md, "| 1 | 00000000:41:00.0 | 88°C | P0 |") {
+		t.Errorf("markdown per-GPU table incomplete:\n%s", md)
+	}
+
+	// No telemetry at all: no section
+	if strings.Contains(GenerateText(createTestReport()), "GPU THERMAL") {
+		t.Error("section should be omitted without thermal or PCIe data")
+	}
+}
+
+func TestGPUMemoryHealthTable(t *testing.T) {
+	report := createTestReport()
+	report.GPUs[0].PCIBusID = "00000000:07:00.0"
+	report.GPUs[0].MemoryHealth = &types.MemoryHealthInfo{
+		ECCSupported: true, ECCEnabled: true, ECCPendingEnabled: true,
+		VolatileCorrectable: 12, VolatileUncorrectable: 1, AggregateCorrectable: 340, AggregateUncorrectable: 3,
+		RowRemapSupported: true, RemappedUncorrectable: 3, RemapPending: true, RemapHistogram: []int{637, 3, 0, 0, 0},
+	}
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "== GPU MEMORY HEALTH ==") || !strings.Contains(text, "12 / 1") || !strings.Contains(text, "0 corr, 3 unc (PENDING RESET)") {
+		t.Errorf("memory health section incomplete:\n%s", text)
+	}
+	md := GenerateMarkdown(report)
+	if !strings.Contains(md, "| 0 | 00000000:07:00.0 | on | 12 / 1 | 340 / 3 | N/A | 0 corr, 3 unc (PENDING RESET) |") {
+		t.Errorf("markdown memory health row incomplete:\n%s", md)
+	}
+
+	if strings.Contains(GenerateText(createTestReport()), "MEMORY HEALTH") {
+		t.Error("section should be omitted for GPUs without ECC data")
+	}
+}
+
+func TestGPUModel(t *testing.T) {
+	report := createTestReport()
+	report.GPUs[0].MarketingName = "Tesla K80"
+	report.GPUs[0].Architecture = "Kepler"
+	report.GPUs[0].ComputeCapability = "3.7"
+	report.GPUs[0].Class = "datacenter"
+	report.GPUs[0].LastDriverBranch = "470"
+
+	want := "Tesla K80 (Kepler, compute 3.7, datacenter), last driver branch R470"
+	if text := GenerateText(report); !strings.Contains(text, "Model:     "+want) {
+		t.Errorf("GPU inventory missing model line:\n%s", text)
+	}
+	if md := GenerateMarkdown(report); !strings.Contains(md, "| Model | "+want+" |") {
+		t.Errorf("markdown GPU table missing model row:\n%s", md)
+	}
+	if strings.Contains(GenerateText(createTestReport()), "Model:") {
+		t.Error("model line should be omitted for GPUs not in the PCI ID table")
+	}
+}
+
+func TestReBARState(t *testing.T) {
+	report := createTestReport()
+	enabled, disabled := true, false
+	report.GPUs[0].ReBAR = &types.ReBARInfo{Supported: true, Enabled: &disabled, BAR1SizeMB: 256, BAR1MaxMB: 32768, Above4GDecoding: "disabled"}
+
+	want := "disabled (supported by the GPU), BAR1 256 MB of 32768 MB max, Above 4G decoding disabled"
+	if text := GenerateText(report); !strings.Contains(text, "ReBAR:     "+want) {
+		t.Errorf("GPU inventory missing ReBAR line:\n%s", text)
+	}
+	if md := GenerateMarkdown(report); !strings.Contains(md, "| Resizable BAR | "+want+" |") {
+		t.Errorf("markdown GPU table missing ReBAR row:\n%s", md)
+	}
+	if got := rebarState(&types.ReBARInfo{Supported: true, Enabled: &enabled, BAR1SizeMB: 32768}); got != "enabled, BAR1 32768 MB" {
+		t.Errorf("rebarState = %q", got)
+	}
+	// A V100's 16 GB BAR1 is fixed, not resized
+	if got := rebarState(&types.ReBARInfo{BAR1SizeMB: 16384, Sources: []string{"nvidia-smi"}}); got != "unknown, BAR1 16384 MB" {
+		t.Errorf("rebarState = %q", got)
+	}
+}
+
+func TestDriverFromProcfs(t *testing.T) {
+	report := createTestReport()
+	report.Driver.Sources = map[string]string{"version": "procfs"}
+	report.GPUs[0].VBIOSVersion = "94.02.42.00.a9"
+	report.GPUs[0].FirmwareVersion = "550.54.14"
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "(loaded kernel module; nvidia-smi unavailable)") {
+		t.Errorf("driver line should name the kernel module as its source:\n%s", text)
+	}
+	if !strings.Contains(text, "Firmware:  VBIOS 94.02.42.00.a9, GSP 550.54.14") {
+		t.Errorf("GPU inventory missing firmware line:\n%s", text)
+	}
+	if md := GenerateMarkdown(report); !strings.Contains(md, "| Firmware | VBIOS 94.02.42.00.a9, GSP 550.54.14 |") {
+		t.Errorf("markdown GPU table missing firmware row:\n%s", md)
+	}
+}
+
+func TestLinuxDriverVersions(t *testing.T) {
+	report := createTestReport()
+	report.Linux = &types.LinuxInfo{DriverVersions: &types.DriverVersions{LoadedModule: "550.54.14", NVML: "550.67", Package: "550.67"}}
+
+	want := "Driver versions: module 550.54.14, NVML 550.67, libcuda N/A, package 550.67, modinfo N/A"
+	if text := GenerateText(report); !strings.Contains(text, want) {
+		t.Errorf("Linux section missing driver versions:\n%s", text)
+	}
+}
+
+func TestLinuxKernelsAndDKMS(t *testing.T) {
+	report := createTestReport()
+	report.Linux = &types.LinuxInfo{
+		DKMSStatus:  "nvidia/550.54.14, 6.5.0-27-generic, x86_64: installed\n",
+		DKMSModules: []types.DKMSModule{{Module: "nvidia", Version: "550.54.14", Kernel: "6.5.0-27-generic", Arch: "x86_64", State: "installed"}},
+		Kernels: []types.KernelModules{
+			{Version: "6.5.0-27-generic", NVIDIAModule: "/lib/modules/6.5.0-27-generic/updates/dkms/nvidia.ko.zst"},
+			{Version: "6.8.0-31-generic"},
+		},
+		NextBootKernel: "6.8.0-31-generic",
+		NextBootSource: "newest installed kernel",
+	}
+
+	text := GenerateText(report)
+	for _, want := range []string{
+		"- nvidia     550.54.14    6.5.0-27-generic             installed",
+		"- 6.8.0-31-generic             no nvidia module  [next boot: newest installed kernel]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Linux section missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "DKMS Status:") {
+		t.Error("raw DKMS status should be replaced by the parsed table")
+	}
+}
+
+func TestGPUProcessesTable(t *testing.T) {
+	report := createTestReport()
+	report.GPUs[0].Processes = []types.GPUProcess{
+		{PID: 2214, Name: "/usr/lib/xorg/Xorg", Type: "G", UsedMemoryMB: -1, State: "S"},
+		{PID: 48311, Name: "python3", Type: "C", UsedMemoryMB: 17850, State: "Z"},
+	}
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "== GPU PROCESSES ==") || !strings.Contains(text, "0    48311    C    17850 MB   Z        python3") {
+		t.Errorf("processes section incomplete:\n%s", text)
+	}
+	md := GenerateMarkdown(report)
+	if !strings.Contains(md, "| 0 | 2214 | G | N/A | S | /usr/lib/xorg/Xorg |") {
+		t.Errorf("markdown processes row incomplete:\n%s", md)
+	}
+
+	if strings.Contains(GenerateText(createTestReport()), "GPU PROCESSES") {
+		t.Error("section should be omitted without process data")
+	}
+}
+
+func TestGPUTopologyTable(t *testing.T) {
+	report := createTestReport()
+	report.Topology = &types.TopologyInfo{
+		AllowedCPUs: "0-15",
+		GPUs: []types.GPUTopology{
+			{Index: 0, Paths: []string{"X", "NV4"}, CPUAffinity: "0-15", NUMANode: 0,
+				NVLinks: []types.NVLinkState{{Link: 0, Up: true}, {Link: 1}}},
+			{Index: 1, Paths: []string{"NV4", "X"}, CPUAffinity: "16-31", NUMANode: -1},
+		},
+	}
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "== GPU TOPOLOGY ==") || !strings.Contains(text, "Allowed CPUs: 0-15") {
+		t.Fatalf("missing topology section:\n%s", text)
+	}
+	if !strings.Contains(text, "GPU1   NV4    X      16-31          N/A            N/A") {
+		t.Errorf("GPU1 row incomplete:\n%s", text)
+	}
+
+	md := GenerateMarkdown(report)
+	if !strings.Contains(md, "|  | GPU0 | GPU1 | CPU Affinity | NUMA | NVLinks up |") || !strings.Contains(md, "| GPU0 | X | NV4 | 0-15 | 0 | 1/2 |") {
+		t.Errorf("markdown topology table incomplete:\n%s", md)
+	}
+}
+
+func TestGPUInventory_MIG(t *testing.T) {
+	report := createTestReport()
+	report.GPUs[0].MIG = &types.MIGInfo{Enabled: true, PendingEnabled: true, Instances: []types.MIGInstance{
+		{DeviceIndex: 0, GPUInstanceID: 2, Profile: "3g.40gb", MemoryTotalMB: 40192, UUID: "MIG-4e5b21c8-9d07-5a3f-b6e2-18c0d94f7a31"},
+	}}
+	report.GPUs = append(report.GPUs, types.GPUInfo{Index: 1, Name: "NVIDIA H100 80GB HBM3", IsNVIDIA: true, MIG: &types.MIGInfo{PendingEnabled: true}})
+
+	text := GenerateText(report)
+	if !strings.Contains(text, "MIG:       enabled, 1 instance(s)") || !strings.Contains(text, "[MIG 0] 3g.40gb  GI 2 / CI 0  40192 MB  MIG-4e5b21c8") {
+		t.Errorf("MIG inventory incomplete:\n%s", text)
+	}
+	if !strings.Contains(text, "MIG:       disabled (enabled after GPU reset)") {
+		t.Errorf("pending MIG change missing:\n%s", text)
+	}
+	if md := GenerateMarkdown(report); !strings.Contains(md, "| MIG 0 | 3g.40gb, GI 2 / CI 0, 40192 MB, MIG-4e5b21c8-9d07-5a3f-b6e2-18c0d94f7a31 |") {
+		t.Errorf("markdown MIG rows incomplete:\n%s", md)
+	}
+}
@@ -2,6 +2,7 @@
 package snapshot
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/nicholasgasior/nvcheckup/internal/collector/ai"
 	"github.com/nicholasgasior/nvcheckup/internal/collector/common"
+	"github.com/nicholasgasior/nvcheckup/internal/sysroot"
+	"github.com/nicholasgasior/nvcheckup/knowledge"
 	"github.com/nicholasgasior/nvcheckup/pkg/types"
 )
 
@@ -20,6 +23,7 @@ func Create(outDir string, timeout int) (string, error) {
 		return "", fmt.Errorf("cannot create output directory: %w", err)
 	}
 
+	ctx := context.Background()
 	snap := types.Snapshot{
 		Metadata: types.ReportMetadata{
 			ToolVersion: types.Version,
@@ -29,16 +33,16 @@ func Create(outDir string, timeout int) (string, error) {
 	}
 
 	// Collect system info
-	sysInfo, _ := common.CollectSystemInfo(timeout)
+	sysInfo, _ := common.CollectSystemInfo(ctx, sysroot.Host(), timeout)
 	snap.System = sysInfo
 
 	// Collect GPU info
-	gpus, driver, _ := common.CollectGPUInfo(timeout)
+	gpus, driver, _ := common.CollectGPUInfo(ctx, nil, timeout, knowledge.Default())
 	snap.GPUs = gpus
 	snap.Driver = driver
 
 	// Collect AI info
-	aiInfo, _ := ai.CollectAIInfo(timeout)
+	aiInfo, _ := ai.CollectAIInfo(ctx, sysroot.Host(), timeout)
 	snap.AI = &aiInfo
 
 	snap.Metadata.RuntimeSeconds = time.Since(snap.Metadata.Timestamp).Seconds()
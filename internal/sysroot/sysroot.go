@@ -0,0 +1,197 @@
+// Package sysroot gives collectors read access to the system's files (/proc,
+// /sys, /etc, /dev, ...) relative to a configurable root. The default is the
+// live filesystem; --sysroot points it at a mounted disk image or a fixture
+// tree instead.
+package sysroot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks bounds symlink resolution inside a sysroot, as the kernel does.
+const maxSymlinks = 40
+
+// FS reads files by their absolute path on the target system.
+type FS interface {
+	// Root is the host directory the target system's "/" maps to.
+	Root() string
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	Readlink(name string) (string, error)
+	// Glob returns matches as paths on the target system, e.g. /dev/nvidia0.
+	Glob(pattern string) ([]string, error)
+}
+
+// Host returns the live filesystem.
+func Host() FS {
+	return hostFS{}
+}
+
+// New returns an FS rooted at dir. An empty dir or "/" means the live
+// filesystem.
+func New(dir string) (FS, error) {
+	if dir == "" || dir == "/" {
+		return Host(), nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sysroot %s: %w", dir, err)
+	}
+	fi, err := os.Stat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open sysroot: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("sysroot %s is not a directory", dir)
+	}
+	return rootFS{root: abs}, nil
+}
+
+// hostFS passes paths straight to the os package.
+type hostFS struct{}
+
+func (hostFS) Root() string                               { return "/" }
+func (hostFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (hostFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (hostFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (hostFS) Readlink(name string) (string, error)       { return os.Readlink(name) }
+func (hostFS) Glob(pattern string) ([]string, error)      { return filepath.Glob(pattern) }
+
+// rootFS maps absolute paths below root. Symlinks are resolved as the target
+// system would see them, so an absolute link such as
+// /usr/local/cuda -> /usr/local/cuda-12.2 stays inside the tree.
+type rootFS struct {
+	root string
+}
+
+func (f rootFS) Root() string { return f.root }
+
+func (f rootFS) ReadFile(name string) ([]byte, error) {
+	p, err := f.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(p)
+}
+
+func (f rootFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := f.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(p)
+}
+
+func (f rootFS) Stat(name string) (fs.FileInfo, error) {
+	p, err := f.resolve(name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (f rootFS) Readlink(name string) (string, error) {
+	p, err := f.resolve(name, false)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(p)
+}
+
+func (f rootFS) Glob(pattern string) ([]string, error) {
+	dir, base := path.Split(path.Clean("/" + filepath.ToSlash(pattern)))
+	if hasMeta(dir) {
+		// Wildcards in directory components: match on the raw tree
+		matches, err := filepath.Glob(f.host(pattern))
+		if err != nil {
+			return nil, err
+		}
+		for i, m := range matches {
+			matches[i] = f.target(m)
+		}
+		return matches, nil
+	}
+
+	hostDir, err := f.resolve(dir, true)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(hostDir, base))
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range matches {
+		matches[i] = path.Join(dir, filepath.Base(m))
+	}
+	return matches, nil
+}
+
+// resolve returns the host path for name, following symlinks inside the root.
+// The last component is only followed when followLast is set. Missing
+// components are not an error here; the caller's os call reports them.
+func (f rootFS) resolve(name string, followLast bool) (string, error) {
+	rest := splitPath(name)
+	cur := "/"
+	links := 0
+	for len(rest) > 0 {
+		c := rest[0]
+		rest = rest[1:]
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			cur = path.Dir(cur)
+			continue
+		}
+		next := path.Join(cur, c)
+		if len(rest) == 0 && !followLast {
+			cur = next
+			break
+		}
+		target, err := os.Readlink(f.host(next))
+		if err != nil {
+			// Not a symlink (or missing)
+			cur = next
+			continue
+		}
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: "resolve", Path: name, Err: errors.New("too many levels of symbolic links")}
+		}
+		target = filepath.ToSlash(target)
+		if path.IsAbs(target) {
+			cur = "/"
+		}
+		rest = append(splitPath(target), rest...)
+	}
+	return f.host(cur), nil
+}
+
+// host maps a target-system path to the host path under root.
+func (f rootFS) host(name string) string {
+	return filepath.Join(f.root, filepath.FromSlash(path.Clean("/"+filepath.ToSlash(name))))
+}
+
+// target maps a host path under root back to the target-system path.
+func (f rootFS) target(hostPath string) string {
+	rel, err := filepath.Rel(f.root, hostPath)
+	if err != nil {
+		return hostPath
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.Trim(p, "/"), "/")
+}
+
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
@@ -0,0 +1,116 @@
+package sysroot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, name, content string) {
+	t.Helper()
+	p := filepath.Join(root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRootFS_ReadFileAndGlob(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "etc/os-release", "NAME=Test\n")
+	writeFile(t, root, "dev/nvidia0", "")
+	writeFile(t, root, "dev/nvidiactl", "")
+	writeFile(t, root, "dev/null", "")
+
+	fsys, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fsys.ReadFile("/etc/os-release")
+	if err != nil || string(data) != "NAME=Test\n" {
+		t.Fatalf("ReadFile = %q, %v", data, err)
+	}
+
+	matches, err := fsys.Glob("/dev/nvidia*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/dev/nvidia0", "/dev/nvidiactl"}; !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob = %v, want %v", matches, want)
+	}
+
+	if _, err := fsys.Stat("/dev/dxg"); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error for a missing file, got %v", err)
+	}
+}
+
+func TestRootFS_AbsoluteSymlinksStayInside(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	root := t.TempDir()
+	writeFile(t, root, "usr/local/cuda-12.2/bin/nvcc", "")
+	if err := os.Symlink("/usr/local/cuda-12.2", filepath.Join(root, "usr/local/cuda")); err != nil {
+		t.Fatal(err)
+	}
+	// An absolute link must not escape to the host's /etc
+	if err := os.Symlink("/etc/passwd", filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target, err := fsys.Readlink("/usr/local/cuda")
+	if err != nil || target != "/usr/local/cuda-12.2" {
+		t.Fatalf("Readlink = %q, %v", target, err)
+	}
+	if _, err := fsys.Stat("/usr/local/cuda/bin/nvcc"); err != nil {
+		t.Errorf("expected nvcc through the cuda symlink, got %v", err)
+	}
+	if _, err := fsys.ReadFile("/escape"); !os.IsNotExist(err) {
+		t.Errorf("expected the absolute link to resolve inside the root, got %v", err)
+	}
+	if _, err := fsys.ReadFile("/../../etc/passwd"); !os.IsNotExist(err) {
+		t.Errorf("expected .. to stop at the root, got %v", err)
+	}
+}
+
+func TestRootFS_SymlinkLoop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks need privileges on Windows")
+	}
+	root := t.TempDir()
+	if err := os.Symlink("/b", filepath.Join(root, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/a", filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadFile("/a"); err == nil {
+		t.Error("expected an error for a symlink loop")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, dir := range []string{"", "/"} {
+		fsys, err := New(dir)
+		if err != nil || fsys.Root() != "/" {
+			t.Errorf("New(%q) should return the live filesystem, got %v, %v", dir, fsys, err)
+		}
+	}
+	if _, err := New(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing sysroot")
+	}
+}
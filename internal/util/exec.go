@@ -23,14 +23,32 @@ type CommandResult struct {
 
 // RunCommand executes a command with a timeout. Never panics; always returns a result.
 func RunCommand(timeoutSec int, name string, args ...string) CommandResult {
+	return RunCommandContext(context.Background(), timeoutSec, name, args...)
+}
+
+// RunCommandContext executes a command with a timeout, also stopping it when
+// ctx is cancelled (Ctrl-C, run budget). The command and any children it
+// spawned are killed. Never panics; always returns a result.
+func RunCommandContext(parent context.Context, timeoutSec int, name string, args ...string) CommandResult {
+	argv := append([]string{name}, args...)
+	if result, ok := replayCommand(argv); ok {
+		return result
+	}
+	result := runCommand(parent, timeoutSec, name, args...)
+	recordCommand(argv, result)
+	return result
+}
+
+func runCommand(parent context.Context, timeoutSec int, name string, args ...string) CommandResult {
 	start := time.Now()
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSec)*time.Second)
+	ctx, cancel := context.WithTimeout(parent, time.Duration(timeoutSec)*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	killProcessTree(cmd)
 
 	err := cmd.Run()
 	duration := time.Since(start)
@@ -41,6 +59,12 @@ func RunCommand(timeoutSec int, name string, args ...string) CommandResult {
 		Duration: duration,
 	}
 
+	if parent.Err() != nil {
+		result.Err = fmt.Errorf("command cancelled: %s: %w", name, parent.Err())
+		result.ExitCode = -1
+		return result
+	}
+
 	if ctx.Err() == context.DeadlineExceeded {
 		result.TimedOut = true
 		result.Err = fmt.Errorf("command timed out after %ds: %s", timeoutSec, name)
@@ -60,9 +84,29 @@ func RunCommand(timeoutSec int, name string, args ...string) CommandResult {
 	return result
 }
 
+// AccessDenied reports whether a command failed for lack of rights, e.g.
+// dmesg under kernel.dmesg_restrict or Get-WinEvent on a protected log, as
+// opposed to failing or finding nothing for another reason.
+func AccessDenied(r CommandResult) bool {
+	if r.Err == nil {
+		return false
+	}
+	msg := strings.ToLower(r.Stderr)
+	for _, s := range []string{"operation not permitted", "permission denied", "access is denied", "unauthorizedaccess"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
 // CommandExists checks if a command is available in PATH
 func CommandExists(name string) bool {
+	if found, ok := replayLookup(name); ok {
+		return found
+	}
 	_, err := exec.LookPath(name)
+	recordLookup(name, err == nil)
 	return err == nil
 }
 
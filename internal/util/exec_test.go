@@ -1,8 +1,11 @@
 package util
 
 import (
+	"context"
+	"errors"
 	"runtime"
 	"testing"
+	"time"
 )
 
 func TestRunCommandSuccess(t *testing.T) {
@@ -144,3 +147,45 @@ func TestParseKeyValue(t *testing.T) {
 		}
 	}
 }
+
+func TestRunCommandContextCancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	r := RunCommandContext(ctx, 30, "sh", "-c", "sleep 30 | cat")
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("cancelled command should return promptly")
+	}
+	if r.Err == nil {
+		t.Fatal("expected an error for a cancelled command")
+	}
+	if r.TimedOut {
+		t.Error("cancellation should not be reported as a timeout")
+	}
+}
+
+func TestAccessDenied(t *testing.T) {
+	failed := errors.New("exit status 1")
+	tests := []struct {
+		name string
+		r    CommandResult
+		want bool
+	}{
+		{"dmesg_restrict", CommandResult{Err: failed, Stderr: "dmesg: read kernel buffer failed: Operation not permitted"}, true},
+		{"event log", CommandResult{Err: failed, Stderr: "Get-WinEvent : Attempted to perform an unauthorized operation. + FullyQualifiedErrorId : UnauthorizedAccessException"}, true},
+		{"nothing found", CommandResult{Err: failed, Stderr: "Get-WinEvent : No events were found that match the specified selection criteria."}, false},
+		{"empty output", CommandResult{}, false},
+	}
+	for _, tt := range tests {
+		if got := AccessDenied(tt.r); got != tt.want {
+			t.Errorf("%s: AccessDenied = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package util
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// killProcessTree runs cmd in its own process group and, on cancellation,
+// kills the whole group so pipelines started via "sh -c" do not leave
+// children behind.
+func killProcessTree(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever on pipes held open by a grandchild that escaped the group
+	cmd.WaitDelay = 2 * time.Second
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package util
+
+import (
+	"os/exec"
+	"time"
+)
+
+// killProcessTree relies on exec.CommandContext killing the process on
+// cancellation; it only bounds how long we wait for inherited pipes to close.
+func killProcessTree(cmd *exec.Cmd) {
+	cmd.WaitDelay = 2 * time.Second
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package util
+
+import "os"
+
+// IsElevated reports whether the process runs as Administrator. Opening the
+// first physical drive for reading is only permitted to elevated processes.
+func IsElevated() bool {
+	f, err := os.Open(`\\.\PHYSICALDRIVE0`)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
@@ -0,0 +1,224 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordingFile is the file inside a --record/--replay directory that holds
+// every captured command invocation.
+const RecordingFile = "commands.json"
+
+// recordingVersion is bumped when the layout of RecordingFile changes.
+const recordingVersion = 1
+
+// CommandRecord is one captured external command invocation.
+type CommandRecord struct {
+	Argv       []string `json:"argv"`
+	Stdout     string   `json:"stdout"`
+	Stderr     string   `json:"stderr"`
+	ExitCode   int      `json:"exit_code"`
+	DurationMs int64    `json:"duration_ms"`
+	TimedOut   bool     `json:"timed_out,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Recording is the on-disk layout of RecordingFile.
+type Recording struct {
+	Version  int             `json:"version"`
+	Platform string          `json:"platform"`
+	Created  time.Time       `json:"created"`
+	Commands []CommandRecord `json:"commands"`
+	Lookups  map[string]bool `json:"lookups"` // CommandExists results by name
+}
+
+// The active recording or replay, if any. Collectors run concurrently, so all
+// access goes through commandLogMu.
+var (
+	commandLogMu sync.Mutex
+	recordDir    string
+	recording    *Recording
+	replay       *replayState
+)
+
+// replayState serves recorded results back in the order they were captured.
+// Commands run more often than they were recorded get the last result again.
+type replayState struct {
+	results map[string][]CommandRecord
+	served  map[string]int
+	lookups map[string]bool
+}
+
+// StartRecording captures every RunCommand/CommandExists call until
+// StopRecording is called, then writes them to dir. Recorded output is not
+// redacted.
+func StartRecording(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create recording directory: %w", err)
+	}
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if recording != nil || replay != nil {
+		return errors.New("a command recording or replay is already active")
+	}
+	recordDir = dir
+	recording = &Recording{
+		Version:  recordingVersion,
+		Platform: runtime.GOOS,
+		Created:  time.Now(),
+		Lookups:  make(map[string]bool),
+	}
+	return nil
+}
+
+// StopRecording ends an active recording or replay. A recording is written to
+// its directory; the path of the written file is returned.
+func StopRecording() (string, error) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	replay = nil
+	if recording == nil {
+		return "", nil
+	}
+	rec, dir := recording, recordDir
+	recording, recordDir = nil, ""
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("cannot encode recording: %w", err)
+	}
+	path := filepath.Join(dir, RecordingFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("cannot write recording: %w", err)
+	}
+	return path, nil
+}
+
+// LoadRecording reads a recording directory written by StartRecording.
+func LoadRecording(dir string) (*Recording, error) {
+	data, err := os.ReadFile(filepath.Join(dir, RecordingFile))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read recording: %w", err)
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", RecordingFile, err)
+	}
+	if rec.Version != recordingVersion {
+		return nil, fmt.Errorf("recording has version %d, this build reads version %d", rec.Version, recordingVersion)
+	}
+	return &rec, nil
+}
+
+// StartReplay serves RunCommand/CommandExists from the recording in dir
+// instead of running anything, until StopRecording is called. Commands that
+// are not in the recording fail as if they were not installed.
+func StartReplay(dir string) (*Recording, error) {
+	rec, err := LoadRecording(dir)
+	if err != nil {
+		return nil, err
+	}
+	st := &replayState{
+		results: make(map[string][]CommandRecord),
+		served:  make(map[string]int),
+		lookups: rec.Lookups,
+	}
+	for _, c := range rec.Commands {
+		key := argvKey(c.Argv)
+		st.results[key] = append(st.results[key], c)
+	}
+
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if recording != nil || replay != nil {
+		return nil, errors.New("a command recording or replay is already active")
+	}
+	replay = st
+	return rec, nil
+}
+
+// replayCommand returns the recorded result for argv, if replay is active.
+func replayCommand(argv []string) (CommandResult, bool) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if replay == nil {
+		return CommandResult{}, false
+	}
+	key := argvKey(argv)
+	recs := replay.results[key]
+	if len(recs) == 0 {
+		return CommandResult{
+			ExitCode: -1,
+			Err:      fmt.Errorf("command not in recording: %s", strings.Join(argv, " ")),
+		}, true
+	}
+	i := replay.served[key]
+	if i >= len(recs) {
+		i = len(recs) - 1
+	}
+	replay.served[key]++
+
+	c := recs[i]
+	result := CommandResult{
+		Stdout:   c.Stdout,
+		Stderr:   c.Stderr,
+		ExitCode: c.ExitCode,
+		TimedOut: c.TimedOut,
+		Duration: time.Duration(c.DurationMs) * time.Millisecond,
+	}
+	if c.Error != "" {
+		result.Err = errors.New(c.Error)
+	}
+	return result, true
+}
+
+// recordCommand appends a finished command to the active recording, if any.
+func recordCommand(argv []string, result CommandResult) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if recording == nil {
+		return
+	}
+	c := CommandRecord{
+		Argv:       argv,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		ExitCode:   result.ExitCode,
+		DurationMs: result.Duration.Milliseconds(),
+		TimedOut:   result.TimedOut,
+	}
+	if result.Err != nil {
+		c.Error = result.Err.Error()
+	}
+	recording.Commands = append(recording.Commands, c)
+}
+
+// replayLookup answers CommandExists from the recording, if replay is active.
+func replayLookup(name string) (found, ok bool) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if replay == nil {
+		return false, false
+	}
+	return replay.lookups[name], true
+}
+
+// recordLookup stores a CommandExists result in the active recording, if any.
+func recordLookup(name string, found bool) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	if recording != nil {
+		recording.Lookups[name] = found
+	}
+}
+
+func argvKey(argv []string) string {
+	return strings.Join(argv, "\x00")
+}
@@ -0,0 +1,85 @@
+package util
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+
+	if err := StartRecording(dir); err != nil {
+		t.Fatal(err)
+	}
+	live := RunCommand(5, "sh", "-c", "echo out; echo err >&2; exit 3")
+	hasSh := CommandExists("sh")
+	if _, err := StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := StartReplay(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer StopRecording()
+
+	got := RunCommand(5, "sh", "-c", "echo out; echo err >&2; exit 3")
+	if got.Stdout != live.Stdout || got.Stderr != live.Stderr || got.ExitCode != live.ExitCode {
+		t.Errorf("replayed %+v, recorded %+v", got, live)
+	}
+	if got.Err == nil || got.Err.Error() != live.Err.Error() {
+		t.Errorf("replayed error %v, recorded %v", got.Err, live.Err)
+	}
+	if CommandExists("sh") != hasSh {
+		t.Error("CommandExists should be answered from the recording")
+	}
+	if CommandExists("definitely-not-recorded") {
+		t.Error("commands never looked up should not exist during replay")
+	}
+
+	missing := RunCommand(5, "sh", "-c", "echo not recorded")
+	if missing.Err == nil || !strings.Contains(missing.Err.Error(), "not in recording") {
+		t.Errorf("expected a not-in-recording error, got %+v", missing)
+	}
+	if missing.Stdout != "" {
+		t.Error("commands outside the recording must not run during replay")
+	}
+}
+
+func TestReplayRepeatsInOrder(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+
+	if err := StartRecording(dir); err != nil {
+		t.Fatal(err)
+	}
+	f := dir + "/n"
+	script := "echo x >> " + f + "; wc -l < " + f
+	RunCommand(5, "sh", "-c", script)
+	RunCommand(5, "sh", "-c", script)
+	if _, err := StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := StartReplay(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer StopRecording()
+
+	for _, want := range []string{"1", "2", "2"} {
+		if got := RunCommand(5, "sh", "-c", script).Stdout; strings.TrimSpace(got) != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestLoadRecordingMissing(t *testing.T) {
+	if _, err := LoadRecording(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without a recording")
+	}
+}
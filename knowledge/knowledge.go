@@ -0,0 +1,460 @@
+// Package knowledge embeds the NVCheckup knowledge pack (rules, Xid codes,
+// remediations and PCI device IDs) and parses it into typed structures. The
+// same JSON files are shared with the Rust implementation.
+package knowledge
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+//go:embed *.json
+var embedded embed.FS
+
+// File names that make up a knowledge pack directory.
+const (
+	RulesFile        = "rules.json"
+	XidCodesFile     = "xid_codes.json"
+	RemediationsFile = "remediations.json"
+	PCIIDsFile       = "pci_ids.json"
+)
+
+// Escalation raises a rule's severity once a measured value reaches a threshold
+// (e.g. number of driver resets, packet loss percentage).
+type Escalation struct {
+	Threshold float64        `json:"threshold"`
+	Escalated types.Severity `json:"escalated"`
+}
+
+// Rule holds the metadata for a single diagnostic finding.
+type Rule struct {
+	ID                 string          `json:"id"`
+	Title              string          `json:"title"`
+	Category           string          `json:"category"`
+	Severity           types.Severity  `json:"severity"`
+	SeverityEscalation *Escalation     `json:"severity_escalation,omitempty"`
+	BaseConfidence     int             `json:"base_confidence"`
+	Modes              []types.RunMode `json:"modes"`
+	Platform           string          `json:"platform,omitempty"`
+	RemediationID      string          `json:"remediation_id,omitempty"`
+	Description        string          `json:"description"`
+}
+
+// AppliesTo reports whether the rule is enabled for the given mode and platform.
+// An empty platform matches every rule.
+func (r Rule) AppliesTo(mode types.RunMode, platform string) bool {
+	if r.Platform != "" && platform != "" && r.Platform != platform {
+		return false
+	}
+	for _, m := range r.Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// SeverityFor returns the rule severity, escalated if value reaches the
+// escalation threshold.
+func (r Rule) SeverityFor(value float64) types.Severity {
+	if r.SeverityEscalation != nil && value >= r.SeverityEscalation.Threshold {
+		return r.SeverityEscalation.Escalated
+	}
+	return r.Severity
+}
+
+// rulesDoc mirrors the layout of rules.json.
+type rulesDoc struct {
+	Description string `json:"description"`
+	Version     string `json:"version"`
+	Rules       []Rule `json:"rules"`
+}
+
+// XidCode describes an NVIDIA Xid error code.
+type XidCode struct {
+	Severity types.Severity `json:"severity"`
+	Summary  string         `json:"summary"`
+	Detail   string         `json:"detail"`
+}
+
+// xidDoc mirrors the layout of xid_codes.json. Codes are keyed by their
+// decimal string.
+type xidDoc struct {
+	Description string             `json:"description"`
+	Codes       map[string]XidCode `json:"codes"`
+}
+
+// Device describes an NVIDIA GPU by its PCI device ID.
+type Device struct {
+	Name              string `json:"name"`
+	Architecture      string `json:"architecture"`
+	ComputeCapability string `json:"compute_capability"`
+	Class             string `json:"class"`                        // "desktop", "laptop" or "datacenter"
+	LastDriverBranch  string `json:"last_driver_branch,omitempty"` // empty while current drivers support it
+}
+
+// pciDoc mirrors the layout of pci_ids.json. Devices are keyed by their
+// lowercase hex device ID.
+type pciDoc struct {
+	Description string            `json:"description"`
+	Devices     map[string]Device `json:"devices"`
+}
+
+// remediationsDoc mirrors the layout of remediations.json.
+type remediationsDoc struct {
+	Description string                    `json:"description"`
+	Actions     []types.RemediationAction `json:"actions"`
+}
+
+// Pack is a parsed and validated knowledge pack.
+type Pack struct {
+	Version      string
+	Rules        []Rule
+	XidCodes     map[int]XidCode
+	Remediations []types.RemediationAction
+	Devices      map[string]Device
+	// FallbackRules lists the rule IDs an override rules.json lacked, which
+	// were taken from the embedded pack so their findings are not lost.
+	FallbackRules []string
+
+	rulesByID map[string]Rule
+}
+
+// Rule looks up a rule by ID.
+func (p *Pack) Rule(id string) (Rule, bool) {
+	r, ok := p.rulesByID[id]
+	return r, ok
+}
+
+// Remediation looks up a remediation action by ID.
+func (p *Pack) Remediation(id string) (types.RemediationAction, bool) {
+	for _, a := range p.Remediations {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return types.RemediationAction{}, false
+}
+
+// RemediationFor returns the remediation action whose finding_ids include
+// the given rule ID.
+func (p *Pack) RemediationFor(ruleID string) (types.RemediationAction, bool) {
+	for _, a := range p.Remediations {
+		for _, id := range a.FindingIDs {
+			if id == ruleID {
+				return a, true
+			}
+		}
+	}
+	return types.RemediationAction{}, false
+}
+
+// Xid looks up an Xid error code.
+func (p *Pack) Xid(code int) (XidCode, bool) {
+	x, ok := p.XidCodes[code]
+	return x, ok
+}
+
+// DescribeXid fills the message, severity and detail of an Xid error from the
+// pack. Codes the pack does not know are marked unrecognized and rated WARN.
+func (p *Pack) DescribeXid(xe *types.XidError) {
+	if x, ok := p.Xid(xe.Code); ok {
+		xe.Message = x.Summary
+		xe.Severity = x.Severity
+		xe.Detail = x.Detail
+		xe.Unrecognized = false
+		return
+	}
+	xe.Message = "Unrecognized Xid"
+	xe.Severity = types.SeverityWarn
+	xe.Detail = ""
+	xe.Unrecognized = true
+}
+
+// Device looks up an NVIDIA GPU by PCI device ID, in any case ("2684",
+// "1DB6").
+func (p *Pack) Device(id string) (Device, bool) {
+	d, ok := p.Devices[strings.ToLower(id)]
+	return d, ok
+}
+
+// DescribeGPU fills the marketing name, architecture, compute capability,
+// class and last driver branch of an NVIDIA GPU from the pack. GPUs the pack
+// does not list are left blank.
+func (p *Pack) DescribeGPU(g *types.GPUInfo) {
+	d, ok := p.Device(g.PCIDeviceID)
+	if !ok || !g.IsNVIDIA {
+		d = Device{}
+	}
+	g.MarketingName = d.Name
+	g.Architecture = d.Architecture
+	g.ComputeCapability = d.ComputeCapability
+	g.Class = d.Class
+	g.LastDriverBranch = d.LastDriverBranch
+}
+
+// Default returns the embedded knowledge pack. It panics if the embedded files
+// are invalid, which is caught by the package tests.
+func Default() *Pack {
+	p, err := Load("")
+	if err != nil {
+		panic("knowledge: embedded pack is invalid: " + err.Error())
+	}
+	return p
+}
+
+// Load parses and validates a knowledge pack. When dir is empty the embedded
+// pack is used; otherwise each file found in dir overrides its embedded
+// counterpart. Rules missing from an override rules.json, e.g. one written for
+// an older release, keep their embedded definition and are listed in
+// FallbackRules. The merged pack must still be consistent: remediations may
+// only reference rule IDs that exist.
+func Load(dir string) (*Pack, error) {
+	if dir != "" {
+		if fi, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("cannot open knowledge pack: %w", err)
+		} else if !fi.IsDir() {
+			return nil, fmt.Errorf("knowledge pack %s is not a directory", dir)
+		}
+	}
+
+	data, err := readFile(dir, RulesFile)
+	if err != nil {
+		return nil, err
+	}
+	var doc rulesDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", RulesFile, err)
+	}
+
+	p := &Pack{
+		Version:   doc.Version,
+		Rules:     doc.Rules,
+		rulesByID: make(map[string]Rule, len(doc.Rules)),
+	}
+	for _, r := range doc.Rules {
+		p.rulesByID[r.ID] = r
+	}
+
+	if p.XidCodes, err = loadXidCodes(dir); err != nil {
+		return nil, err
+	}
+
+	data, err = readFile(dir, RemediationsFile)
+	if err != nil {
+		return nil, err
+	}
+	var rdoc remediationsDoc
+	if err := json.Unmarshal(data, &rdoc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", RemediationsFile, err)
+	}
+	p.Remediations = rdoc.Actions
+
+	if dir != "" {
+		data, err := embedded.ReadFile(RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		var base rulesDoc
+		if err := json.Unmarshal(data, &base); err != nil {
+			return nil, fmt.Errorf("cannot parse embedded %s: %w", RulesFile, err)
+		}
+		for _, r := range base.Rules {
+			if _, ok := p.rulesByID[r.ID]; !ok {
+				// An overridden remediations.json may not have its action
+				if _, ok := p.Remediation(r.RemediationID); !ok {
+					r.RemediationID = ""
+				}
+				p.Rules = append(p.Rules, r)
+				p.rulesByID[r.ID] = r
+				p.FallbackRules = append(p.FallbackRules, r.ID)
+			}
+		}
+	}
+
+	data, err = readFile(dir, PCIIDsFile)
+	if err != nil {
+		return nil, err
+	}
+	var pdoc pciDoc
+	if err := json.Unmarshal(data, &pdoc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", PCIIDsFile, err)
+	}
+	p.Devices = pdoc.Devices
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the pack for duplicate IDs and out-of-range values.
+func (p *Pack) Validate() error {
+	var errs []error
+	seen := make(map[string]bool)
+	for i, r := range p.Rules {
+		if r.ID == "" {
+			errs = append(errs, fmt.Errorf("rule #%d has no id", i))
+			continue
+		}
+		if seen[r.ID] {
+			errs = append(errs, fmt.Errorf("rule %q is defined more than once", r.ID))
+		}
+		seen[r.ID] = true
+
+		if !validSeverity(r.Severity) {
+			errs = append(errs, fmt.Errorf("rule %q has invalid severity %q", r.ID, r.Severity))
+		}
+		if r.BaseConfidence < 0 || r.BaseConfidence > 100 {
+			errs = append(errs, fmt.Errorf("rule %q has base_confidence %d outside 0-100", r.ID, r.BaseConfidence))
+		}
+		if len(r.Modes) == 0 {
+			errs = append(errs, fmt.Errorf("rule %q has no modes", r.ID))
+		}
+		for _, m := range r.Modes {
+			if !validMode(m) {
+				errs = append(errs, fmt.Errorf("rule %q has unknown mode %q", r.ID, m))
+			}
+		}
+		switch r.Platform {
+		case "", "windows", "linux":
+		default:
+			errs = append(errs, fmt.Errorf("rule %q has unknown platform %q", r.ID, r.Platform))
+		}
+		if esc := r.SeverityEscalation; esc != nil && !validSeverity(esc.Escalated) {
+			errs = append(errs, fmt.Errorf("rule %q escalates to invalid severity %q", r.ID, esc.Escalated))
+		}
+		if r.RemediationID != "" {
+			if a, ok := p.Remediation(r.RemediationID); !ok {
+				errs = append(errs, fmt.Errorf("rule %q references unknown remediation %q", r.ID, r.RemediationID))
+			} else if !containsString(a.FindingIDs, r.ID) {
+				errs = append(errs, fmt.Errorf("rule %q references remediation %q, which does not list it in finding_ids", r.ID, a.ID))
+			}
+		}
+	}
+
+	seenActions := make(map[string]bool)
+	for i, a := range p.Remediations {
+		if a.ID == "" {
+			errs = append(errs, fmt.Errorf("remediation #%d has no id", i))
+			continue
+		}
+		if seenActions[a.ID] {
+			errs = append(errs, fmt.Errorf("remediation %q is defined more than once", a.ID))
+		}
+		seenActions[a.ID] = true
+
+		switch a.Risk {
+		case types.RiskLow, types.RiskMedium, types.RiskHigh:
+		default:
+			errs = append(errs, fmt.Errorf("remediation %q has invalid risk %q", a.ID, a.Risk))
+		}
+		switch a.Platform {
+		case "windows", "linux", "all":
+		default:
+			errs = append(errs, fmt.Errorf("remediation %q has unknown platform %q", a.ID, a.Platform))
+		}
+		for _, id := range a.FindingIDs {
+			if _, ok := p.rulesByID[id]; !ok {
+				errs = append(errs, fmt.Errorf("remediation %q lists unknown finding id %q", a.ID, id))
+			}
+		}
+	}
+	for code, x := range p.XidCodes {
+		if !validSeverity(x.Severity) {
+			errs = append(errs, fmt.Errorf("xid %d has invalid severity %q", code, x.Severity))
+		}
+		if x.Summary == "" {
+			errs = append(errs, fmt.Errorf("xid %d has no summary", code))
+		}
+	}
+	for id, d := range p.Devices {
+		if _, err := strconv.ParseUint(id, 16, 16); err != nil || len(id) != 4 || id != strings.ToLower(id) {
+			errs = append(errs, fmt.Errorf("device %q is not a lowercase 4-digit hex ID", id))
+		}
+		if d.Name == "" || d.Architecture == "" {
+			errs = append(errs, fmt.Errorf("device %s has no name or architecture", id))
+		}
+		if _, err := strconv.ParseFloat(d.ComputeCapability, 64); err != nil {
+			errs = append(errs, fmt.Errorf("device %s has invalid compute_capability %q", id, d.ComputeCapability))
+		}
+		switch d.Class {
+		case "desktop", "laptop", "datacenter":
+		default:
+			errs = append(errs, fmt.Errorf("device %s has unknown class %q", id, d.Class))
+		}
+		if d.LastDriverBranch != "" {
+			if _, err := strconv.Atoi(d.LastDriverBranch); err != nil {
+				errs = append(errs, fmt.Errorf("device %s has invalid last_driver_branch %q", id, d.LastDriverBranch))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid knowledge pack: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// loadXidCodes parses xid_codes.json into a map keyed by numeric code.
+func loadXidCodes(dir string) (map[int]XidCode, error) {
+	data, err := readFile(dir, XidCodesFile)
+	if err != nil {
+		return nil, err
+	}
+	var doc xidDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", XidCodesFile, err)
+	}
+	codes := make(map[int]XidCode, len(doc.Codes))
+	for key, x := range doc.Codes {
+		code, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid knowledge pack: %s has non-numeric code %q", XidCodesFile, key)
+		}
+		codes[code] = x
+	}
+	return codes, nil
+}
+
+// readFile reads name from dir if present there, falling back to the embedded copy.
+func readFile(dir, name string) ([]byte, error) {
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err == nil {
+			return data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot read %s: %w", name, err)
+		}
+	}
+	return embedded.ReadFile(name)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func validSeverity(s types.Severity) bool {
+	return s == types.SeverityInfo || s == types.SeverityWarn || s == types.SeverityCrit
+}
+
+func validMode(m types.RunMode) bool {
+	switch m {
+	case types.ModeGaming, types.ModeAI, types.ModeCreator, types.ModeStreaming, types.ModeFull:
+		return true
+	}
+	return false
+}
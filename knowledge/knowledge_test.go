@@ -0,0 +1,195 @@
+package knowledge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nicholasgasior/nvcheckup/pkg/types"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	p, err := Load("")
+	if err != nil {
+		t.Fatalf("embedded pack failed validation: %v", err)
+	}
+	if len(p.Rules) == 0 {
+		t.Fatal("expected embedded rules")
+	}
+	if _, ok := p.Rule("no-nvidia-gpu"); !ok {
+		t.Error("expected rule no-nvidia-gpu in embedded pack")
+	}
+}
+
+func TestLoad_OverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	rules := `{"version": "9.9.9", "rules": [
+		{"id": "custom", "title": "Custom", "severity": "WARN", "base_confidence": 50, "modes": ["ai"]}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, RulesFile), []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, RemediationsFile), []byte(`{"actions": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if p.Version != "9.9.9" {
+		t.Errorf("expected override version 9.9.9, got %s", p.Version)
+	}
+	if _, ok := p.Rule("custom"); !ok {
+		t.Error("expected the override rule")
+	}
+	// Rules the override lacks keep their embedded definition
+	if _, ok := p.Rule("no-nvidia-gpu"); !ok {
+		t.Error("expected no-nvidia-gpu to fall back to the embedded rule")
+	}
+	if !containsString(p.FallbackRules, "no-nvidia-gpu") || containsString(p.FallbackRules, "custom") {
+		t.Errorf("unexpected FallbackRules: %v", p.FallbackRules)
+	}
+	if len(p.XidCodes) == 0 {
+		t.Error("xid_codes.json should fall back to the embedded copy")
+	}
+}
+
+func TestLoad_DanglingFindingID(t *testing.T) {
+	// A remediation pointing at a rule no pack defines must be rejected
+	dir := t.TempDir()
+	actions := `{"actions": [{"id": "custom-fix", "title": "Custom", "platform": "all", "risk": "low", "finding_ids": ["no-such-rule"]}]}`
+	if err := os.WriteFile(filepath.Join(dir, RemediationsFile), []byte(actions), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(dir)
+	if err == nil || !strings.Contains(err.Error(), "unknown finding id") {
+		t.Errorf("expected unknown finding id error, got %v", err)
+	}
+}
+
+func TestPack_RemediationFor(t *testing.T) {
+	p := Default()
+	a, ok := p.RemediationFor("nouveau-active")
+	if !ok {
+		t.Fatal("expected a remediation for nouveau-active")
+	}
+	if a.ID != "blacklist-nouveau" {
+		t.Errorf("expected blacklist-nouveau, got %s", a.ID)
+	}
+	if _, ok := p.RemediationFor("hybrid-gpu"); ok {
+		t.Error("did not expect a remediation for hybrid-gpu")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{"bad severity", `{"rules": [{"id": "a", "severity": "BAD", "base_confidence": 50, "modes": ["ai"]}]}`, "invalid severity"},
+		{"duplicate id", `{"rules": [{"id": "a", "severity": "INFO", "base_confidence": 50, "modes": ["ai"]}, {"id": "a", "severity": "INFO", "base_confidence": 50, "modes": ["ai"]}]}`, "more than once"},
+		{"bad confidence", `{"rules": [{"id": "a", "severity": "INFO", "base_confidence": 150, "modes": ["ai"]}]}`, "outside 0-100"},
+		{"unknown mode", `{"rules": [{"id": "a", "severity": "INFO", "base_confidence": 50, "modes": ["mining"]}]}`, "unknown mode"},
+		{"malformed", `{"rules": [`, "cannot parse"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, RulesFile), []byte(tt.rules), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := Load(dir)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not mention %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLoad_InvalidDevice(t *testing.T) {
+	dir := t.TempDir()
+	ids := `{"devices": {"2684": {"name": "GeForce RTX 4090", "architecture": "Ada Lovelace", "compute_capability": "8.9", "class": "server"}}}`
+	if err := os.WriteFile(filepath.Join(dir, PCIIDsFile), []byte(ids), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Load(dir)
+	if err == nil || !strings.Contains(err.Error(), `unknown class "server"`) {
+		t.Errorf("expected unknown class error, got %v", err)
+	}
+}
+
+func TestPack_DescribeGPU(t *testing.T) {
+	p := Default()
+
+	// nvidia-smi reports device IDs in upper case, lspci in lower case
+	g := types.GPUInfo{Name: "Tesla V100-PCIE-32GB", PCIDeviceID: "1DB6", IsNVIDIA: true}
+	p.DescribeGPU(&g)
+	if g.MarketingName != "Tesla V100 PCIe 32GB" || g.Architecture != "Volta" || g.ComputeCapability != "7.0" ||
+		g.Class != "datacenter" || g.LastDriverBranch != "580" {
+		t.Errorf("unexpected V100 description: %+v", g)
+	}
+
+	g = types.GPUInfo{Name: "GA106M [GeForce RTX 3060 Mobile / Max-Q]", PCIDeviceID: "2520", IsNVIDIA: true}
+	p.DescribeGPU(&g)
+	if g.Architecture != "Ampere" || g.Class != "laptop" || g.LastDriverBranch != "" {
+		t.Errorf("unexpected RTX 3060 Laptop description: %+v", g)
+	}
+
+	// Stale details from an older pack are cleared for unknown devices
+	g = types.GPUInfo{PCIDeviceID: "9a49", Vendor: "Intel", Architecture: "Ampere"}
+	p.DescribeGPU(&g)
+	if g.Architecture != "" || g.MarketingName != "" {
+		t.Errorf("expected no description for an Intel GPU, got %+v", g)
+	}
+}
+
+func TestLoad_MissingDir(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Error("expected error for missing knowledge directory")
+	}
+}
+
+func TestRule_SeverityFor(t *testing.T) {
+	r := Rule{
+		Severity:           types.SeverityWarn,
+		SeverityEscalation: &Escalation{Threshold: 3, Escalated: types.SeverityCrit},
+	}
+	if got := r.SeverityFor(2); got != types.SeverityWarn {
+		t.Errorf("SeverityFor(2) = %s, want WARN", got)
+	}
+	if got := r.SeverityFor(3); got != types.SeverityCrit {
+		t.Errorf("SeverityFor(3) = %s, want CRIT", got)
+	}
+}
+
+func TestRule_AppliesTo(t *testing.T) {
+	r := Rule{Modes: []types.RunMode{types.ModeAI, types.ModeFull}, Platform: "linux"}
+	if !r.AppliesTo(types.ModeAI, "linux") {
+		t.Error("expected rule to apply to ai/linux")
+	}
+	if r.AppliesTo(types.ModeGaming, "linux") {
+		t.Error("expected rule not to apply to gaming mode")
+	}
+	if r.AppliesTo(types.ModeFull, "windows") {
+		t.Error("expected linux rule not to apply on windows")
+	}
+}
+
+func TestPack_Xid(t *testing.T) {
+	p := Default()
+	x, ok := p.Xid(79)
+	if !ok {
+		t.Fatal("expected Xid 79 in embedded table")
+	}
+	if x.Severity != types.SeverityCrit {
+		t.Errorf("expected Xid 79 to be CRIT, got %s", x.Severity)
+	}
+	if _, ok := p.Xid(9999); ok {
+		t.Error("did not expect Xid 9999 to be known")
+	}
+}
@@ -31,10 +31,11 @@ const (
 
 // ExitCode for CLI
 const (
-	ExitOK       = 0
-	ExitWarnings = 1
-	ExitCritical = 2
-	ExitError    = 3
+	ExitOK          = 0
+	ExitWarnings    = 1
+	ExitCritical    = 2
+	ExitError       = 3
+	ExitInterrupted = 130 // Ctrl-C; a partial report was still written
 )
 
 // RiskLevel for remediation actions
@@ -48,18 +49,24 @@ const (
 
 // RunConfig holds all CLI flags and options for a run
 type RunConfig struct {
-	Mode        RunMode
-	OutDir      string
-	Zip         bool
-	JSON        bool
-	Markdown    bool
-	Verbose     bool
-	NoAdmin     bool
-	Timeout     int // seconds
-	Redact      bool
-	IncludeLogs    bool
-	NetworkTest    bool // run network diagnostics
-	KnowledgePath  string // optional path to override embedded knowledge pack
+	Mode          RunMode
+	OutDir        string
+	Zip           bool
+	JSON          bool
+	Markdown      bool
+	Verbose       bool
+	NoAdmin       bool
+	Timeout       int // seconds
+	Redact        bool
+	IncludeLogs   bool
+	NetworkTest   bool     // run network diagnostics
+	KnowledgePath string   // optional path to override embedded knowledge pack
+	Only          []string // run only these collectors (by name)
+	Skip          []string // never run these collectors (by name)
+	Budget        int      // overall collection deadline in seconds (0 = none)
+	RecordDir     string   // save every external command's output here
+	ReplayDir     string   // serve external commands from a recording instead of running them
+	Sysroot       string   // read /proc, /sys, /etc and /dev below this directory ("" = live system)
 }
 
 // DefaultRunConfig returns a RunConfig with safe defaults
@@ -113,6 +120,71 @@ type GPUInfo struct {
 	IsNVIDIA      bool   `json:"is_nvidia"`
 	PCIeLinkSpeed string `json:"pcie_link_speed,omitempty"` // "Gen4"
 	PCIeLinkWidth string `json:"pcie_link_width,omitempty"` // "x16"
+
+	// From nvidia-smi, or /proc/driver/nvidia when nvidia-smi cannot reach
+	// the driver
+	UUID            string `json:"uuid,omitempty"`
+	VBIOSVersion    string `json:"vbios_version,omitempty"`
+	FirmwareVersion string `json:"firmware_version,omitempty"` // GSP firmware, R525+
+	IRQ             int    `json:"irq,omitempty"`
+	// Sources maps a field's JSON name to where its value came from:
+	// "nvidia-smi", "procfs", "lspci" or "wmi"
+	Sources map[string]string `json:"sources,omitempty"`
+
+	// From the knowledge pack's PCI ID table; empty for devices it does not list
+	MarketingName     string `json:"marketing_name,omitempty"`     // "GeForce RTX 3080"
+	Architecture      string `json:"architecture,omitempty"`       // "Ampere"
+	ComputeCapability string `json:"compute_capability,omitempty"` // "8.6"
+	Class             string `json:"class,omitempty"`              // "desktop", "laptop" or "datacenter"
+	LastDriverBranch  string `json:"last_driver_branch,omitempty"` // "470" when the GPU is on a legacy branch
+
+	// Per-GPU telemetry from nvidia-smi; nil for GPUs it does not report
+	Thermal      *ThermalInfo      `json:"thermal,omitempty"`
+	PCIe         *PCIeInfo         `json:"pcie,omitempty"`
+	MemoryHealth *MemoryHealthInfo `json:"memory_health,omitempty"`
+	MIG          *MIGInfo          `json:"mig,omitempty"` // nil on GPUs without MIG support
+	Processes    []GPUProcess      `json:"processes,omitempty"`
+	ReBAR        *ReBARInfo        `json:"rebar,omitempty"`
+}
+
+// ReBARInfo holds a GPU's Resizable BAR state and BAR1 size. BAR1 is the
+// window through which the CPU maps GPU memory; 256 MB is the legacy size.
+type ReBARInfo struct {
+	Supported       bool     `json:"supported"`         // the GPU has the Resizable BAR capability
+	Enabled         *bool    `json:"enabled,omitempty"` // the resizable BAR1 is larger than the legacy 256 MB; nil when its current size is unknown
+	BAR1SizeMB      int64    `json:"bar1_size_mb,omitempty"`
+	BAR1MaxMB       int64    `json:"bar1_max_mb,omitempty"`       // largest size the capability allows
+	BAR1UsedMB      int64    `json:"bar1_used_mb,omitempty"`      // from nvidia-smi
+	Above4GDecoding string   `json:"above_4g_decoding,omitempty"` // "enabled" or "disabled" from where BAR1 is mapped; empty if unknown
+	Sources         []string `json:"sources,omitempty"`           // "nvidia-smi", "sysfs", "lspci"
+}
+
+// GPUProcess is a process holding a context (and usually memory) on a GPU
+type GPUProcess struct {
+	PID          int    `json:"pid"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`            // "C" compute, "G" graphics, "C+G"
+	UsedMemoryMB int64  `json:"used_memory_mb"`  // -1 when not reported (Windows WDDM)
+	State        string `json:"state,omitempty"` // Linux /proc state ("R", "S", "D", "Z"), or "missing" when the PID is not in /proc
+}
+
+// MIGInfo holds a GPU's Multi-Instance GPU mode and instances
+type MIGInfo struct {
+	Enabled        bool          `json:"enabled"`
+	PendingEnabled bool          `json:"pending_enabled"` // mode after the next GPU reset
+	Instances      []MIGInstance `json:"instances,omitempty"`
+}
+
+// MIGInstance is one MIG compute instance; CUDA sees each as a separate device
+type MIGInstance struct {
+	DeviceIndex       int    `json:"device_index"`
+	GPUInstanceID     int    `json:"gpu_instance_id"`
+	ComputeInstanceID int    `json:"compute_instance_id"`
+	Profile           string `json:"profile,omitempty"` // "3g.40gb"
+	UUID              string `json:"uuid,omitempty"`
+	SMCount           int    `json:"sm_count,omitempty"`
+	MemoryTotalMB     int64  `json:"memory_total_mb"`
+	MemoryUsedMB      int64  `json:"memory_used_mb"`
 }
 
 // DriverInfo holds NVIDIA driver details
@@ -122,6 +194,13 @@ type DriverInfo struct {
 	NvidiaSmiPath   string `json:"nvidia_smi_path,omitempty"`
 	NvidiaSmiOutput string `json:"nvidia_smi_output,omitempty"`
 	Source          string `json:"source,omitempty"` // "package", "runfile", "wmi", etc.
+
+	// KernelModuleVersion is the loaded module's version from
+	// /proc/driver/nvidia/version; it can differ from the userspace driver
+	KernelModuleVersion string `json:"kernel_module_version,omitempty"`
+	// Sources maps a field's JSON name to where its value came from:
+	// "nvidia-smi" or "procfs"
+	Sources map[string]string `json:"sources,omitempty"`
 }
 
 // WindowsInfo holds Windows-specific collected data
@@ -173,12 +252,17 @@ type LinuxInfo struct {
 	LoadedModules      map[string]bool `json:"loaded_modules,omitempty"` // nvidia, nvidia_drm, nouveau
 	DKMSStatus         string          `json:"dkms_status,omitempty"`
 	DKMSErrors         string          `json:"dkms_errors,omitempty"` // opt-in only
+	DKMSModules        []DKMSModule    `json:"dkms_modules,omitempty"`
+	Kernels            []KernelModules `json:"kernels,omitempty"` // from /lib/modules
+	NextBootKernel     string          `json:"next_boot_kernel,omitempty"`
+	NextBootSource     string          `json:"next_boot_source,omitempty"` // where NextBootKernel came from
 	SecureBootState    string          `json:"secure_boot_state,omitempty"`
 	MOKStatus          string          `json:"mok_status,omitempty"`
 	SessionType        string          `json:"session_type,omitempty"` // x11, wayland
 	PRIMEStatus        string          `json:"prime_status,omitempty"`
 	DevNvidiaNodes     []string        `json:"dev_nvidia_nodes,omitempty"`
 	LibCudaPath        string          `json:"libcuda_path,omitempty"`
+	DriverVersions     *DriverVersions `json:"driver_versions,omitempty"`
 	ContainerRuntime   string          `json:"container_runtime,omitempty"`
 	NVContainerToolkit string          `json:"nv_container_toolkit,omitempty"`
 	JournalSnippets    string          `json:"journal_snippets,omitempty"` // opt-in
@@ -188,6 +272,38 @@ type LinuxInfo struct {
 	GLRenderer         string          `json:"gl_renderer,omitempty"`
 }
 
+// DKMSModule is one line of dkms status: a module version's state for one
+// kernel. Kernel is empty for versions that are only added.
+type DKMSModule struct {
+	Module  string `json:"module"`  // "nvidia"
+	Version string `json:"version"` // "550.54.14"
+	Kernel  string `json:"kernel,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+	State   string `json:"state"`          // "added", "built" or "installed"
+	Note    string `json:"note,omitempty"` // e.g. "WARNING! Diff between built and installed module!"
+}
+
+// KernelModules is a kernel installed under /lib/modules and the nvidia
+// module built for it, if any.
+type KernelModules struct {
+	Version      string `json:"version"`
+	NVIDIAModule string `json:"nvidia_module,omitempty"` // path to nvidia.ko*
+}
+
+// DriverVersions holds the NVIDIA driver version as each part of a Linux
+// install reports it. They all match on a healthy system; empty means that
+// part was not found.
+type DriverVersions struct {
+	LoadedModule string `json:"loaded_module,omitempty"` // /sys/module/nvidia/version
+	NVML         string `json:"nvml,omitempty"`          // libnvidia-ml.so on disk
+	NVMLPath     string `json:"nvml_path,omitempty"`
+	LibCUDA      string `json:"libcuda,omitempty"` // libcuda.so on disk
+	LibCUDAPath  string `json:"libcuda_path,omitempty"`
+	Package      string `json:"package,omitempty"`      // installed driver package
+	PackageName  string `json:"package_name,omitempty"` // "nvidia-driver-550"
+	Modinfo      string `json:"modinfo,omitempty"`      // module on disk for the running kernel
+}
+
 // AIInfo holds AI/CUDA framework info
 type AIInfo struct {
 	CUDADriverVersion  string        `json:"cuda_driver_version,omitempty"`
@@ -241,6 +357,7 @@ type WSLInfo struct {
 
 // Finding represents an actionable diagnostic finding
 type Finding struct {
+	RuleID       string             `json:"rule_id,omitempty"` // knowledge/rules.json id
 	Severity     Severity           `json:"severity"`
 	Title        string             `json:"title"`
 	Evidence     string             `json:"evidence"`
@@ -265,6 +382,7 @@ type RemediationAction struct {
 	NeedsAdmin  bool      `json:"needs_admin"`
 	Category    string    `json:"category,omitempty"`     // "power", "registry", "driver"
 	RelatedFind string    `json:"related_find,omitempty"` // human description of related finding
+	FindingIDs  []string  `json:"finding_ids,omitempty"`  // rule IDs this action fixes
 }
 
 // RemediationResult holds the outcome of applying a remediation
@@ -293,25 +411,116 @@ type ChangeJournalEntry struct {
 
 // ThermalInfo holds GPU thermal and power state data
 type ThermalInfo struct {
-	TemperatureC    int    `json:"temperature_c"`
-	ThermalThrottle bool   `json:"thermal_throttle"`
-	PowerState      string `json:"power_state"`       // P0-P12
-	CurrentClockMHz int    `json:"current_clock_mhz"`
-	MaxClockMHz     int    `json:"max_clock_mhz"`
-	PowerLimitW     string `json:"power_limit_w"`
-	PowerDrawW      string `json:"power_draw_w"`
-	FanSpeedPct     int    `json:"fan_speed_pct"`
-	SlowdownActive  bool   `json:"slowdown_active"`
-	SlowdownReason  string `json:"slowdown_reason,omitempty"`
+	GPUIndex        int      `json:"gpu_index"`
+	PCIBusID        string   `json:"pci_bus_id,omitempty"`
+	TemperatureC    int      `json:"temperature_c"`
+	ThermalThrottle bool     `json:"thermal_throttle"`
+	PowerState      string   `json:"power_state"` // P0-P12
+	CurrentClockMHz int      `json:"current_clock_mhz"`
+	MaxClockMHz     int      `json:"max_clock_mhz"`
+	PowerLimitW     string   `json:"power_limit_w"`
+	PowerDrawW      string   `json:"power_draw_w"`
+	FanSpeedPct     int      `json:"fan_speed_pct"` // -1 when not reported (laptops, passive cards)
+	SlowdownActive  bool     `json:"slowdown_active"`
+	SlowdownReason  string   `json:"slowdown_reason,omitempty"`  // clocks_event_reasons bitmask, "0x..."
+	SlowdownReasons []string `json:"slowdown_reasons,omitempty"` // decoded: "sw_power_cap", "hw_thermal_slowdown", ...
+
+	// This GPU's own temperature limits from nvidia-smi; 0 when not reported
+	SlowdownTempC     int  `json:"slowdown_temp_c,omitempty"`
+	ShutdownTempC     int  `json:"shutdown_temp_c,omitempty"`
+	MaxOperatingTempC int  `json:"max_operating_temp_c,omitempty"`
+	TLimitMarginC     *int `json:"tlimit_margin_c,omitempty"` // °C below T.Limit (R535+); nil when not reported
+
+	// The board's stock power limit, to tell a lowered PowerLimitW from the
+	// default; empty when not reported
+	DefaultPowerLimitW string `json:"default_power_limit_w,omitempty"`
 }
 
 // PCIeInfo holds PCIe link state data
 type PCIeInfo struct {
+	GPUIndex     int    `json:"gpu_index"`
+	PCIBusID     string `json:"pci_bus_id,omitempty"`
 	CurrentSpeed string `json:"current_speed"` // "Gen4"
 	MaxSpeed     string `json:"max_speed"`     // "Gen4"
 	CurrentWidth string `json:"current_width"` // "x16"
 	MaxWidth     string `json:"max_width"`     // "x16"
 	Downshifted  bool   `json:"downshifted"`
+	Source       string `json:"source,omitempty"` // "nvidia-smi" or "sysfs"
+
+	// From sysfs on Linux
+	AER      *PCIeAER  `json:"aer,omitempty"`      // nil when the kernel does not expose AER counters
+	Upstream *PCIeLink `json:"upstream,omitempty"` // the bridge or root port above the GPU
+}
+
+// PCIeLink is the upstream end of a GPU's PCIe link: what the slot, switch or
+// riser side supports
+type PCIeLink struct {
+	BDF          string   `json:"bdf"` // "0000:00:01.0"
+	CurrentSpeed string   `json:"current_speed,omitempty"`
+	MaxSpeed     string   `json:"max_speed,omitempty"`
+	CurrentWidth string   `json:"current_width,omitempty"`
+	MaxWidth     string   `json:"max_width,omitempty"`
+	AER          *PCIeAER `json:"aer,omitempty"`
+}
+
+// PCIeAER holds a device's Advanced Error Reporting totals since boot
+type PCIeAER struct {
+	Correctable int64 `json:"correctable"`
+	NonFatal    int64 `json:"non_fatal"`
+	Fatal       int64 `json:"fatal"`
+}
+
+// MemoryHealthInfo holds a GPU's ECC counters, retired pages and row
+// remapping state. Each section is only filled when the GPU supports it.
+type MemoryHealthInfo struct {
+	GPUIndex int    `json:"gpu_index"`
+	PCIBusID string `json:"pci_bus_id,omitempty"`
+
+	ECCSupported           bool  `json:"ecc_supported"`
+	ECCEnabled             bool  `json:"ecc_enabled,omitempty"`
+	ECCPendingEnabled      bool  `json:"ecc_pending_enabled,omitempty"` // after the next reset
+	VolatileCorrectable    int64 `json:"volatile_correctable,omitempty"`
+	VolatileUncorrectable  int64 `json:"volatile_uncorrectable,omitempty"`
+	AggregateCorrectable   int64 `json:"aggregate_correctable,omitempty"`
+	AggregateUncorrectable int64 `json:"aggregate_uncorrectable,omitempty"`
+	SRAMThresholdExceeded  bool  `json:"sram_threshold_exceeded,omitempty"`
+
+	RetiredPagesSupported bool `json:"retired_pages_supported"`
+	RetiredSingleBit      int  `json:"retired_single_bit,omitempty"`
+	RetiredDoubleBit      int  `json:"retired_double_bit,omitempty"`
+	RetirementPending     bool `json:"retirement_pending,omitempty"`
+
+	RowRemapSupported     bool `json:"row_remap_supported"`
+	RemappedCorrectable   int  `json:"remapped_correctable,omitempty"`
+	RemappedUncorrectable int  `json:"remapped_uncorrectable,omitempty"`
+	RemapPending          bool `json:"remap_pending,omitempty"`
+	RemapFailed           bool `json:"remap_failed,omitempty"`
+	// Memory banks by spare rows left: max, high, partial, low, none
+	RemapHistogram []int `json:"remap_histogram,omitempty"`
+}
+
+// TopologyInfo holds the GPU interconnect matrix from nvidia-smi topo -m and
+// the CPUs and GPUs a job started from this shell would get.
+type TopologyInfo struct {
+	GPUs           []GPUTopology `json:"gpus"`
+	AllowedCPUs    string        `json:"allowed_cpus,omitempty"`    // Cpus_allowed_list of this process
+	VisibleDevices string        `json:"visible_devices,omitempty"` // CUDA_VISIBLE_DEVICES
+}
+
+// GPUTopology is one GPU's row of the topology matrix
+type GPUTopology struct {
+	Index       int           `json:"index"`
+	Paths       []string      `json:"paths"`                  // to each GPU in TopologyInfo.GPUs: "X", "NV12", "PIX", "PXB", "PHB", "NODE", "SYS"
+	CPUAffinity string        `json:"cpu_affinity,omitempty"` // "0-31,64-95"
+	NUMANode    int           `json:"numa_node"`              // -1 when not reported
+	NVLinks     []NVLinkState `json:"nvlinks,omitempty"`
+}
+
+// NVLinkState is the state of one NVLink
+type NVLinkState struct {
+	Link     int     `json:"link"`
+	Up       bool    `json:"up"`
+	SpeedGBs float64 `json:"speed_gbs,omitempty"`
 }
 
 // DisplayInfo holds display/monitor pipeline data
@@ -352,10 +561,39 @@ type HopInfo struct {
 
 // XidError holds a parsed NVIDIA Xid error from kernel logs
 type XidError struct {
-	Code      int       `json:"code"`
-	Message   string    `json:"message"`
-	Timestamp time.Time `json:"timestamp"`
-	Count     int       `json:"count"`
+	Code         int       `json:"code"`
+	Message      string    `json:"message"`
+	Severity     Severity  `json:"severity"`
+	Detail       string    `json:"detail,omitempty"`
+	Unrecognized bool      `json:"unrecognized,omitempty"` // code not in knowledge/xid_codes.json
+	Timestamp    time.Time `json:"timestamp"`
+	Count        int       `json:"count"`
+}
+
+// SkippedCheck records a check that did not run for lack of privileges
+type SkippedCheck struct {
+	Check   string   `json:"check"`
+	Needs   string   `json:"needs"` // "admin"
+	Reason  string   `json:"reason"`
+	Affects []string `json:"affects,omitempty"` // rule IDs whose findings rely on this check
+}
+
+// CollectorStatus is the outcome of a single collector in a run
+type CollectorStatus string
+
+const (
+	CollectorCompleted  CollectorStatus = "completed"
+	CollectorCancelled  CollectorStatus = "cancelled"   // still running at the budget deadline
+	CollectorNotStarted CollectorStatus = "not_started" // budget ran out or its dependencies never finished
+)
+
+// CollectorRun records which collectors ran and how long each took
+type CollectorRun struct {
+	Name       string          `json:"name"`
+	Status     CollectorStatus `json:"status"`
+	DurationMs int64           `json:"duration_ms"`
+	Errors     int             `json:"errors,omitempty"`
+	Note       string          `json:"note,omitempty"`
 }
 
 // CollectorError records a non-fatal error from a collector
@@ -375,12 +613,15 @@ type Report struct {
 	Linux           *LinuxInfo       `json:"linux,omitempty"`
 	WSL             *WSLInfo         `json:"wsl,omitempty"`
 	AI              *AIInfo          `json:"ai,omitempty"`
-	Thermal         *ThermalInfo     `json:"thermal,omitempty"`
-	PCIe            *PCIeInfo        `json:"pcie,omitempty"`
+	Thermal         *ThermalInfo     `json:"thermal,omitempty"` // first GPU; per-GPU data is on GPUInfo
+	PCIe            *PCIeInfo        `json:"pcie,omitempty"`    // first GPU; per-GPU data is on GPUInfo
 	Displays        []DisplayInfo    `json:"displays,omitempty"`
 	Network         *NetworkInfo     `json:"network,omitempty"`
+	Topology        *TopologyInfo    `json:"topology,omitempty"`
 	Findings        []Finding        `json:"findings"`
 	CollectorErrors []CollectorError `json:"collector_errors,omitempty"`
+	Collectors      []CollectorRun   `json:"collectors,omitempty"`
+	SkippedChecks   []SkippedCheck   `json:"skipped_checks,omitempty"`
 	TopIssues       []string         `json:"top_issues"`
 	NextSteps       []string         `json:"next_steps"`
 	SummaryBlock    string           `json:"summary_block"`
@@ -394,6 +635,11 @@ type ReportMetadata struct {
 	RuntimeSeconds   float64   `json:"runtime_seconds"`
 	RedactionEnabled bool      `json:"redaction_enabled"`
 	Platform         string    `json:"platform"` // "windows", "linux", "wsl"
+	Incomplete       bool      `json:"incomplete,omitempty"`
+	IncompleteReason string    `json:"incomplete_reason,omitempty"`
+	Replayed         bool      `json:"replayed,omitempty"`      // commands served from a --replay recording
+	ReanalyzedBy     string    `json:"reanalyzed_by,omitempty"` // set by "nvcheckup analyze"
+	Elevated         bool      `json:"elevated"`                // ran as root/Administrator
 }
 
 // Snapshot is a timestamped JSON snapshot for comparison